@@ -16,9 +16,14 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/DopplerHQ/cli/pkg/configuration"
 	"github.com/DopplerHQ/cli/pkg/controllers"
 	"github.com/DopplerHQ/cli/pkg/http"
+	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/printer"
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/spf13/cobra"
@@ -33,18 +38,98 @@ var activityCmd = &cobra.Command{
 		localConfig := configuration.LocalConfig(cmd)
 		page := utils.GetIntFlag(cmd, "page", 16)
 		number := utils.GetIntFlag(cmd, "number", 16)
+		format := cmd.Flag("format").Value.String()
+		all := utils.GetBoolFlag(cmd, "all")
 
 		utils.RequireValue("token", localConfig.Token.Value)
 
-		activity, err := http.GetActivityLogs(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, page, number)
-		if !err.IsNil() {
-			utils.HandleError(err.Unwrap(), err.Message)
+		if all && cmd.Flags().Changed("page") {
+			utils.HandleError(errors.New("--all cannot be used with --page"))
+		}
+
+		if !utils.Contains(activityFormats, format) {
+			utils.HandleError(fmt.Errorf("invalid format. Valid formats are %v", activityFormats))
+		}
+		if jsonFlag && cmd.Flags().Changed("format") && format != "json" {
+			utils.HandleError(errors.New("--json cannot be used with --format"))
+		}
+		if jsonFlag {
+			format = "json"
+		}
+		fields := jsonFieldsFlag[models.ActivityLogOutput](cmd, format == "json")
+
+		if utils.GetBoolFlag(cmd, "follow") {
+			for _, flag := range []string{"all", "page", "output"} {
+				if cmd.Flags().Changed(flag) {
+					utils.HandleError(fmt.Errorf("--follow cannot be used with --%s", flag))
+				}
+			}
+			if format == "csv" {
+				utils.HandleError(errors.New("--follow requires --format text or json"))
+			}
+			interval := utils.GetDurationFlag(cmd, "interval")
+			if interval <= 0 {
+				utils.HandleError(errors.New("--interval must be greater than 0"))
+			}
+
+			// runs until interrupted, which cancels http.Context
+			controllers.FollowActivityLogs(http.Context, localConfig, number, interval, func(logs []models.ActivityLog) {
+				for _, log := range logs {
+					if format == "json" {
+						// one JSON object per line, so the stream can be processed as the logs arrive
+						output := models.ConvertActivityLogToOutput(log)
+						if len(fields) > 0 {
+							selected, err := utils.SelectJSONFields([]models.ActivityLogOutput{output}, fields)
+							if err != nil {
+								utils.HandleError(err, "Unable to select fields")
+							}
+							printer.JSON(selected[0])
+						} else {
+							printer.JSON(output)
+						}
+					} else {
+						printer.ActivityLog(log, false, false)
+					}
+				}
+			})
+			return
 		}
 
-		printer.ActivityLogs(activity, len(activity), jsonFlag)
+		var activity []models.ActivityLog
+		if all {
+			var err controllers.Error
+			activity, err = controllers.GetAllActivityLogs(localConfig, number)
+			if !err.IsNil() {
+				utils.HandleError(err.Unwrap(), err.Message)
+			}
+		} else {
+			var err http.Error
+			activity, err = http.GetActivityLogs(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, page, number)
+			if !err.IsNil() {
+				utils.HandleError(err.Unwrap(), err.Message)
+			}
+		}
+
+		if format == "csv" {
+			body, e := controllers.ActivityLogsCSV(activity)
+			if e != nil {
+				utils.HandleError(e, "Unable to render activity logs as CSV")
+			}
+			fmt.Fprint(printer.Output, string(body))
+			return
+		}
+
+		if len(fields) > 0 {
+			printer.JSON(selectJSONFields(models.ConvertActivityLogsToOutput(activity), fields))
+			return
+		}
+
+		printer.ActivityLogs(activity, len(activity), format == "json")
 	},
 }
 
+var activityFormats = []string{"text", "json", "csv"}
+
 var activityGetCmd = &cobra.Command{
 	Use:               "get [log_id]",
 	Short:             "Get workplace activity log",
@@ -91,5 +176,10 @@ func init() {
 
 	activityCmd.Flags().IntP("number", "n", 20, "max number of logs to display")
 	activityCmd.Flags().Int("page", 1, "log page to display")
+	activityCmd.Flags().Bool("all", false, "fetch every page of logs, using --number as the page size")
+	activityCmd.Flags().String("format", "text", fmt.Sprintf("output format. one of %v", activityFormats))
+	activityCmd.Flags().StringSlice("fields", []string{}, "only include these fields in the JSON output (e.g. id,text,created_at)")
+	activityCmd.Flags().BoolP("follow", "f", false, "print the latest logs, then poll for new logs every --interval until interrupted. logs are printed oldest first, and JSON logs one per line")
+	activityCmd.Flags().Duration("interval", 5*time.Second, "how often to poll for new logs when using --follow. failed polls are retried with an increasing delay")
 	rootCmd.AddCommand(activityCmd)
 }
@@ -22,6 +22,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/DopplerHQ/cli/pkg/controllers"
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -124,6 +125,20 @@ var completionInstallCmd = &cobra.Command{
 	},
 }
 
+var completionClearCacheCmd = &cobra.Command{
+	Use:   "clear-cache",
+	Short: "Clear cached completion values",
+	Long: `Clear cached completion values.
+
+Project and config names used for completion are cached for a short time to reduce completion latency.
+Use this command to force the next completion to fetch the latest values.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		controllers.ClearCompletionCache()
+		utils.Print("Completion cache has been cleared")
+	},
+}
+
 func getShell(args []string) string {
 	shell := os.Getenv("SHELL")
 	if len(args) > 0 {
@@ -144,4 +159,5 @@ func getShell(args []string) string {
 func init() {
 	rootCmd.AddCommand(completionCmd)
 	completionCmd.AddCommand(completionInstallCmd)
+	completionCmd.AddCommand(completionClearCacheCmd)
 }
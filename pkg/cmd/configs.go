@@ -19,10 +19,12 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/DopplerHQ/cli/pkg/configuration"
 	"github.com/DopplerHQ/cli/pkg/controllers"
 	"github.com/DopplerHQ/cli/pkg/http"
+	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/printer"
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/spf13/cobra"
@@ -67,8 +69,12 @@ var configsUpdateCmd = &cobra.Command{
 }
 
 var configsLockCmd = &cobra.Command{
-	Use:               "lock [config]",
-	Short:             "Lock a config",
+	Use:   "lock [config]",
+	Short: "Lock a config",
+	Long: `Lock a config, which prevents it from being renamed or deleted.
+
+To also refuse changes to its secrets unless --force is specified, enable the protect-locked-configs flag:
+$ doppler configure flags enable protect-locked-configs`,
 	Args:              cobra.MaximumNArgs(1),
 	ValidArgsFunction: unlockedConfigNamesValidArgs,
 	Run:               lockConfigs,
@@ -95,6 +101,7 @@ func configs(cmd *cobra.Command, args []string) {
 	environment := cmd.Flag("environment").Value.String()
 	number := utils.GetIntFlag(cmd, "number", 16)
 	page := utils.GetIntFlag(cmd, "page", 16)
+	fields := jsonFieldsFlag[models.ConfigInfo](cmd, jsonFlag)
 	localConfig := configuration.LocalConfig(cmd)
 
 	utils.RequireValue("token", localConfig.Token.Value)
@@ -104,6 +111,11 @@ func configs(cmd *cobra.Command, args []string) {
 		utils.HandleError(err.Unwrap(), err.Message)
 	}
 
+	if len(fields) > 0 {
+		printer.JSON(selectJSONFields(configs, fields))
+		return
+	}
+
 	printer.ConfigsInfo(configs, jsonFlag)
 }
 
@@ -118,9 +130,19 @@ func getConfigs(cmd *cobra.Command, args []string) {
 		config = args[0]
 	}
 
-	configInfo, err := http.GetConfig(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, config)
-	if !err.IsNil() {
-		utils.HandleError(err.Unwrap(), err.Message)
+	var configInfo models.ConfigInfo
+	if utils.GetBoolFlag(cmd, "wait-until-ready") {
+		var err controllers.Error
+		configInfo, err = controllers.WaitForConfigReady(localConfig, config, utils.GetDurationFlag(cmd, "wait-timeout"))
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
+	} else {
+		var err http.Error
+		configInfo, err = http.GetConfig(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, config)
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
 	}
 
 	printer.ConfigInfo(configInfo, jsonFlag)
@@ -155,6 +177,8 @@ func createConfigs(cmd *cobra.Command, args []string) {
 		utils.HandleError(err.Unwrap(), err.Message)
 	}
 
+	controllers.ClearCompletionCache()
+
 	if !utils.Silent {
 		printer.ConfigInfo(info, jsonFlag)
 	}
@@ -183,6 +207,8 @@ func deleteConfigs(cmd *cobra.Command, args []string) {
 			utils.HandleError(err.Unwrap(), err.Message)
 		}
 
+		controllers.ClearCompletionCache()
+
 		if !utils.Silent {
 			configs, err := http.GetConfigs(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, "", 1, 100)
 			if !err.IsNil() {
@@ -221,6 +247,8 @@ func updateConfigs(cmd *cobra.Command, args []string) {
 		utils.HandleError(err.Unwrap(), err.Message)
 	}
 
+	controllers.ClearCompletionCache()
+
 	if !utils.Silent {
 		printer.ConfigInfo(info, jsonFlag)
 	}
@@ -301,6 +329,8 @@ func cloneConfigs(cmd *cobra.Command, args []string) {
 		utils.HandleError(err.Unwrap(), err.Message)
 	}
 
+	controllers.ClearCompletionCache()
+
 	if !utils.Silent {
 		printer.ConfigInfo(configInfo, jsonFlag)
 	}
@@ -310,7 +340,7 @@ func configNamesValidArgs(cmd *cobra.Command, args []string, toComplete string)
 	persistentValidArgsFunction(cmd)
 
 	localConfig := configuration.LocalConfig(cmd)
-	names, err := controllers.GetConfigNames(localConfig)
+	names, err := controllers.CachedConfigNames(localConfig)
 	if err.IsNil() {
 		return names, cobra.ShellCompDirectiveNoFileComp
 	}
@@ -364,6 +394,7 @@ func init() {
 	}
 	configsCmd.Flags().IntP("number", "n", 100, "max number of configs to display")
 	configsCmd.Flags().Int("page", 1, "page to display")
+	configsCmd.Flags().StringSlice("fields", []string{}, "only include these fields in the JSON output (e.g. name,environment,created_at)")
 
 	configsGetCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
 	if err := configsGetCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
@@ -373,6 +404,8 @@ func init() {
 	if err := configsGetCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
 		utils.HandleError(err)
 	}
+	configsGetCmd.Flags().Bool("wait-until-ready", false, "wait until the config exists and has no missing secrets")
+	configsGetCmd.Flags().Duration("wait-timeout", 30*time.Second, "max time to wait when using --wait-until-ready")
 	configsCmd.AddCommand(configsGetCmd)
 
 	configsCreateCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
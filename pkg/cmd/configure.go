@@ -18,6 +18,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/DopplerHQ/cli/pkg/configuration"
@@ -52,7 +53,16 @@ var configureDebugCmd = &cobra.Command{
 	Long: `View current configuration utilizing all config sources.
 
 This includes specified flags (--token=123), environment variables (DOPPLER_TOKEN=123),
-and your config file. Flags have the highest priority; config file has the least.`,
+and your config file. Flags have the highest priority; config file has the least.
+
+When used with --json and --sources, each option includes both its value and its source.
+
+With --require, the command instead reports whether each listed option resolves to a value, and
+exits with code 1 if any don't. This can be used to check that a machine is configured before
+running other commands.
+
+Ex: check that a token, project, and config are all configured:
+doppler configure debug --require token,project,config`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		jsonFlag := utils.OutputJSON
@@ -61,7 +71,36 @@ and your config file. Flags have the highest priority; config file has the least
 		utils.Log(fmt.Sprintf("%s %s", color.Green.Render("Configuration directory:"), configuration.UserConfigDir))
 
 		config := configuration.LocalConfig(cmd)
-		printer.ScopedConfigSource(config, jsonFlag, true, false)
+
+		if !cmd.Flags().Changed("require") {
+			// the JSON output only includes sources when requested, to keep its original shape
+			printer.ScopedConfigSource(config, jsonFlag, !jsonFlag || utils.GetBoolFlag(cmd, "sources"), false)
+			return
+		}
+
+		names, err := cmd.Flags().GetStringSlice("require")
+		if err != nil {
+			utils.HandleError(err)
+		}
+		required, err := configuration.CheckRequiredOptions(config, names)
+		if err != nil {
+			utils.HandleError(err)
+		}
+		printer.RequiredOptions(required, jsonFlag)
+
+		var missing []string
+		for _, option := range required {
+			if !option.Satisfied {
+				missing = append(missing, option.Name)
+			}
+		}
+		if len(missing) > 0 {
+			if jsonFlag {
+				// the output above already describes which options are missing
+				os.Exit(1)
+			}
+			utils.HandleError(fmt.Errorf("missing required options: %s", strings.Join(missing, ", ")))
+		}
 	},
 }
 
@@ -83,11 +122,22 @@ var configureGetCmd = &cobra.Command{
 	Long: `Get the value of one or more options in the config file.
 
 Ex: output the options "key" and "otherkey":
-doppler configure get key otherkey`,
+doppler configure get key otherkey
+
+Ex: output all options set in the current scope:
+doppler configure get --all
+
+Ex: output all options in the current scope whose name contains "host":
+doppler configure get --all host`,
 	ValidArgsFunction: currentConfigOptionsValidArgs,
 	Args: func(cmd *cobra.Command, args []string) error {
+		// with --all, args are optional filters rather than option names
+		if utils.GetBoolFlag(cmd, "all") {
+			return nil
+		}
+
 		if len(args) == 0 {
-			return errors.New("requires at least 1 arg(s), received 0")
+			return errors.New("requires at least 1 arg(s), received 0. Use --all to get all options")
 		}
 
 		for _, arg := range args {
@@ -106,8 +156,12 @@ doppler configure get key otherkey`,
 		conf := configuration.Get(configuration.Scope)
 
 		translatedArgs := []string{}
-		for _, arg := range args {
-			translatedArgs = append(translatedArgs, configuration.TranslateFriendlyOption(arg))
+		if utils.GetBoolFlag(cmd, "all") {
+			translatedArgs = matchingConfigOptions(conf, args)
+		} else {
+			for _, arg := range args {
+				translatedArgs = append(translatedArgs, configuration.TranslateFriendlyOption(arg))
+			}
 		}
 
 		printer.ScopedConfigValues(conf, translatedArgs, models.ScopedOptionsMap(&conf), jsonFlag, plain, copy)
@@ -139,7 +193,7 @@ doppler configure set key=123 otherkey=456`,
 				return nil
 			} else if len(args) == 2 {
 				if configuration.IsValidConfigOption(args[0]) || configuration.IsTranslatableConfigOption(args[0]) {
-					return nil
+					return configuration.ValidateConfigOptionValue(configuration.TranslateFriendlyOption(args[0]), args[1])
 				}
 				return errors.New("invalid option " + args[0])
 			}
@@ -155,6 +209,9 @@ doppler configure set key=123 otherkey=456`,
 			if len(option) < 2 {
 				return errors.New("option " + option[0] + " requires a value")
 			}
+			if err := configuration.ValidateConfigOptionValue(configuration.TranslateFriendlyOption(option[0]), option[1]); err != nil {
+				return err
+			}
 		}
 
 		return nil
@@ -172,6 +229,9 @@ doppler configure set key=123 otherkey=456`,
 			if value == nil {
 				utils.HandleError(errors.New("Unable to read input from stdin"))
 			}
+			if err := configuration.ValidateConfigOptionValue(configuration.TranslateFriendlyOption(args[0]), *value); err != nil {
+				utils.HandleError(err)
+			}
 
 			options[args[0]] = *value
 		} else if !strings.Contains(args[0], "=") {
@@ -266,6 +326,33 @@ func configOptionsValidArgs(cmd *cobra.Command, args []string, toComplete string
 	return names, cobra.ShellCompDirectiveNoFileComp
 }
 
+// matchingConfigOptions returns the options set in the scoped config whose name contains any of the filters (case-insensitive).
+// all set options are returned when no filters are specified
+func matchingConfigOptions(conf models.ScopedOptions, filters []string) []string {
+	configMap := models.ScopedOptionsStringMap(&conf)
+
+	var options []string
+	for _, option := range models.AllConfigOptions() {
+		if configMap[option] == "" {
+			continue
+		}
+
+		friendlyName := configuration.TranslateConfigOption(option)
+		matches := len(filters) == 0
+		for _, filter := range filters {
+			filter = strings.ToLower(filter)
+			if strings.Contains(strings.ToLower(option), filter) || strings.Contains(strings.ToLower(friendlyName), filter) {
+				matches = true
+				break
+			}
+		}
+		if matches {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
 // currentConfigOptionsValidArgs the options currently in use in the current scope
 func currentConfigOptionsValidArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	persistentValidArgsFunction(cmd)
@@ -285,12 +372,24 @@ func currentConfigOptionsValidArgs(cmd *cobra.Command, args []string, toComplete
 }
 
 func init() {
+	// accepted so that the reported project and config match commands invoked with the same flags (e.g. 'doppler run -p backend')
+	configureDebugCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := configureDebugCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	configureDebugCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := configureDebugCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	configureDebugCmd.Flags().Bool("sources", false, "include the source of each option in the JSON output (e.g. {\"value\":\"123\",\"source\":\"Flag\"}) rather than only its value")
+	configureDebugCmd.Flags().StringSlice("require", []string{}, "exit with code 1 unless each of these options resolves to a value (e.g. token,project,config)")
 	configureCmd.AddCommand(configureDebugCmd)
 
 	configureCmd.AddCommand(configureOptionsCmd)
 
 	configureGetCmd.Flags().Bool("plain", false, "print values without formatting. values will be printed in the same order as specified")
 	configureGetCmd.Flags().Bool("copy", false, "copy the value(s) to your clipboard")
+	configureGetCmd.Flags().Bool("all", false, "get all options set in the current scope. any args filter options by name")
 	configureCmd.AddCommand(configureGetCmd)
 
 	configureCmd.AddCommand(configureSetCmd)
@@ -16,6 +16,8 @@ limitations under the License.
 package cmd
 
 import (
+	"time"
+
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -101,6 +103,7 @@ func init() {
 	}
 	enclaveConfigsCmd.Flags().IntP("number", "n", 100, "max number of configs to display")
 	enclaveConfigsCmd.Flags().Int("page", 1, "page to display")
+	enclaveConfigsCmd.Flags().StringSlice("fields", []string{}, "only include these fields in the JSON output (e.g. name,environment,created_at)")
 
 	enclaveConfigsGetCmd.Flags().StringP("project", "p", "", "enclave project (e.g. backend)")
 	if err := enclaveConfigsGetCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
@@ -110,6 +113,8 @@ func init() {
 	if err := enclaveConfigsGetCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
 		utils.HandleError(err)
 	}
+	enclaveConfigsGetCmd.Flags().Bool("wait-until-ready", false, "wait until the config exists and has no missing secrets")
+	enclaveConfigsGetCmd.Flags().Duration("wait-timeout", 30*time.Second, "max time to wait when using --wait-until-ready")
 	enclaveConfigsCmd.AddCommand(enclaveConfigsGetCmd)
 
 	enclaveConfigsCreateCmd.Flags().StringP("project", "p", "", "enclave project (e.g. backend)")
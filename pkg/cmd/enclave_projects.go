@@ -73,6 +73,7 @@ var enclaveProjectsUpdateCmd = &cobra.Command{
 func init() {
 	enclaveProjectsCmd.Flags().IntP("number", "n", 100, "max number of projects to display")
 	enclaveProjectsCmd.Flags().Int("page", 1, "page to display")
+	enclaveProjectsCmd.Flags().StringSlice("fields", []string{}, "only include these fields in the JSON output (e.g. id,name,created_at)")
 
 	enclaveProjectsGetCmd.Flags().StringP("project", "p", "", "enclave project (e.g. backend)")
 	if err := enclaveProjectsGetCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
@@ -18,6 +18,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/DopplerHQ/cli/pkg/controllers"
 	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/spf13/cobra"
@@ -106,6 +107,12 @@ func init() {
 	enclaveSecretsCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
 	enclaveSecretsCmd.Flags().Bool("visibility", false, "include secret visibility in table output")
 	enclaveSecretsCmd.Flags().Bool("only-names", false, "only print the secret names; omit all values")
+	enclaveSecretsCmd.Flags().StringArray("tag", []string{}, "only print secrets with this tag. may be specified multiple times to print secrets with any of the tags")
+	enclaveSecretsCmd.Flags().Bool("smart-mask", false, "mask values, describing recognized formats (e.g. JWT algorithm, PEM type, URL host) without revealing them")
+	enclaveSecretsCmd.Flags().Bool("local-only", false, "only print secrets overridden in this config, omitting those inherited from the root config")
+	enclaveSecretsCmd.Flags().Bool("references-only", false, "only print secrets whose raw value contains a reference (e.g. '${OTHER_SECRET}'), showing the raw and computed values")
+	enclaveSecretsCmd.Flags().Bool("show-age", false, "include when each secret was last changed. if the API doesn't provide it, it's determined from the config's logs")
+	enclaveSecretsCmd.Flags().String("sort", "name", fmt.Sprintf("order to list secrets in. 'age' lists the least recently changed first. one of %v", secretsSortOrders))
 
 	enclaveSecretsGetCmd.Flags().StringP("project", "p", "", "enclave project (e.g. backend)")
 	if err := enclaveSecretsGetCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
@@ -120,6 +127,11 @@ func init() {
 	enclaveSecretsGetCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
 	enclaveSecretsGetCmd.Flags().Bool("visibility", false, "include secret visibility in table output")
 	enclaveSecretsGetCmd.Flags().Bool("no-exit-on-missing-secret", false, "do not exit if unable to find a requested secret")
+	enclaveSecretsGetCmd.Flags().String("output", "", "write the secret's value to this file, exactly as stored. '-' writes it to stdout. requires a single secret. unlike the global --output, the value isn't formatted")
+	enclaveSecretsGetCmd.Flags().String("mode", "0600", "octal permissions of the file written via --output")
+	enclaveSecretsGetCmd.Flags().Bool("mkdir", false, "create the parent directories of the --output file if they don't exist")
+	enclaveSecretsGetCmd.Flags().Bool("smart-mask", false, "mask values, describing recognized formats (e.g. JWT algorithm, PEM type, URL host) without revealing them")
+	enclaveSecretsGetCmd.Flags().Bool("ignore-case", false, "match secret names case-insensitively, failing if a name matches multiple secrets")
 	enclaveSecretsCmd.AddCommand(enclaveSecretsGetCmd)
 
 	enclaveSecretsSetCmd.Flags().StringP("project", "p", "", "enclave project (e.g. backend)")
@@ -132,6 +144,11 @@ func init() {
 	}
 	enclaveSecretsSetCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
 	enclaveSecretsSetCmd.Flags().Bool("no-interactive", false, "do not allow entering secret value via interactive mode")
+	enclaveSecretsSetCmd.Flags().Int("batch-size", 100, "max number of secrets to set per request. larger imports are split into sequential batches, which are not applied atomically")
+	enclaveSecretsSetCmd.Flags().String("if-match", "", "only set secrets if the config's version (from 'doppler secrets etag') still matches")
+	enclaveSecretsSetCmd.Flags().StringArray("tag", []string{}, "assign this tag to the secrets, replacing any existing tags. may be specified multiple times")
+	enclaveSecretsSetCmd.Flags().Bool("force", false, "set secrets without checking whether the config has changed since it was read, or whether it's locked (with the protect-locked-configs flag)")
+	enclaveSecretsSetCmd.Flags().Bool("no-references", false, "fail if any value contains a secret reference (e.g. '${OTHER_SECRET}')")
 	enclaveSecretsCmd.AddCommand(enclaveSecretsSetCmd)
 
 	enclaveSecretsDeleteCmd.Flags().StringP("project", "p", "", "enclave project (e.g. backend)")
@@ -144,6 +161,7 @@ func init() {
 	}
 	enclaveSecretsDeleteCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
 	enclaveSecretsDeleteCmd.Flags().BoolP("yes", "y", false, "proceed without confirmation")
+	enclaveSecretsDeleteCmd.Flags().Bool("force", false, "delete secrets even if other secrets reference them (e.g. '${SECRET}'), or the config is locked (with the protect-locked-configs flag)")
 	enclaveSecretsCmd.AddCommand(enclaveSecretsDeleteCmd)
 
 	enclaveSecretsDownloadCmd.Flags().StringP("project", "p", "", "enclave project (e.g. backend)")
@@ -154,9 +172,22 @@ func init() {
 	if err := enclaveSecretsDownloadCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
 		utils.HandleError(err)
 	}
-	enclaveSecretsDownloadCmd.Flags().String("format", models.JSON.String(), "output format. one of [json, env]")
+	enclaveSecretsDownloadCmd.Flags().StringArray("format", []string{models.JSON.String()}, fmt.Sprintf("output format. one of %s. may be specified multiple times, with a corresponding --output for each", validFormatList))
+	enclaveSecretsDownloadCmd.Flags().StringArray("output", []string{}, "path to write the secrets file to. may be specified multiple times, with a corresponding --format for each. unlike the global --output, '-' isn't supported; use --no-file to write to stdout")
 	enclaveSecretsDownloadCmd.Flags().String("passphrase", "", "passphrase to use for encrypting the secrets file. the default passphrase is computed using your current configuration.")
 	enclaveSecretsDownloadCmd.Flags().Bool("no-file", false, "print the response to stdout")
+	enclaveSecretsDownloadCmd.Flags().Bool("both", false, "output both the raw and computed value of each secret (e.g. {\"KEY\":{\"raw\":\"${X}\",\"computed\":\"value\"}}). only supported with JSON format")
+	enclaveSecretsDownloadCmd.Flags().String("values", "computed", fmt.Sprintf("which value of each secret to download. 'raw' keeps secret references (e.g. '${OTHER_SECRET}') unresolved. one of %v", secretValueTypes))
+	enclaveSecretsDownloadCmd.Flags().String("line-prefix", "", fmt.Sprintf("prepend this string to each line of output (e.g. 'export '). only supported by formats %v", linePrefixFormats))
+	enclaveSecretsDownloadCmd.Flags().String("encode", "", fmt.Sprintf("encode the value of each secret before rendering the output. this changes the values, so consumers must decode them. one of %v", utils.ValueEncodings))
+	enclaveSecretsDownloadCmd.Flags().Bool("json-array", false, "output JSON as an array of {\"name\":\"KEY\",\"value\":\"value\"} objects sorted by name, rather than an object. only supported with JSON format")
+	enclaveSecretsDownloadCmd.Flags().StringArray("gpg-recipient", []string{}, "encrypt the secrets to the public key of this recipient (e.g. an email address or key ID) in your GPG keyring, rather than with a passphrase. may be specified multiple times")
+	enclaveSecretsDownloadCmd.Flags().Bool("gpg-armor", false, "write the GPG encrypted file as ASCII armored text. always enabled with --no-file")
+	enclaveSecretsDownloadCmd.Flags().Bool("toml-section", false, "nest secrets under a [project.config] table when using TOML format")
+	enclaveSecretsDownloadCmd.Flags().String("cloudinit-path", "/etc/doppler/secrets.env", "path of the env file written on the instance when using cloudinit format")
+	enclaveSecretsDownloadCmd.Flags().String("cloudinit-owner", "root:root", "owner (user:group) of the env file written on the instance when using cloudinit format")
+	enclaveSecretsDownloadCmd.Flags().String("cloudinit-permissions", "0600", "octal permissions of the env file written on the instance when using cloudinit format")
+	enclaveSecretsDownloadCmd.Flags().Bool("strict", false, "when using systemd or xml format, fail on unsupported values and invalid names rather than escaping, replacing, or skipping them")
 	enclaveSecretsDownloadCmd.Flags().String("name-transformer", "", fmt.Sprintf("output name transformer. one of %v", validNameTransformersList))
 	enclaveSecretsDownloadCmd.Flags().Duration("dynamic-ttl", 0, "(BETA) dynamic secrets will expire after specified duration, (e.g. '3h', '15m')")
 	// fallback flags
@@ -167,6 +198,9 @@ func init() {
 	enclaveSecretsDownloadCmd.Flags().Bool("fallback-readonly", false, "disable modifying the fallback file. secrets can still be read from the file.")
 	enclaveSecretsDownloadCmd.Flags().Bool("fallback-only", false, "read all secrets directly from the fallback file, without contacting Doppler. secrets will not be updated. (implies --fallback-readonly)")
 	enclaveSecretsDownloadCmd.Flags().Bool("no-exit-on-write-failure", false, "do not exit if unable to write the fallback file")
+	enclaveSecretsDownloadCmd.Flags().Duration("fallback-max-age", 0, "refuse to use a fallback file whose secrets were fetched longer ago than this duration (e.g. '24h'), as recorded in its metadata file. 0 for no limit")
+	enclaveSecretsDownloadCmd.Flags().String("fallback-format", "json", fmt.Sprintf("format to write the fallback file in. one of %s. env writes an unencrypted dotenv file that can be read by other tools. either format can be read", controllers.FallbackFormats))
+	enclaveSecretsDownloadCmd.Flags().String("fallback-stale", "error", fmt.Sprintf("behavior when the fallback file exceeds --fallback-max-age. one of %s", controllers.FallbackStaleActions))
 	enclaveSecretsCmd.AddCommand(enclaveSecretsDownloadCmd)
 
 	enclaveCmd.AddCommand(enclaveSecretsCmd)
@@ -0,0 +1,65 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/printer"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var examplesCmd = &cobra.Command{
+	Use:   "examples [command]",
+	Short: "View examples of common commands",
+	Long: fmt.Sprintf(`View runnable examples of common commands. Examples are available for: %s
+
+Examples are bundled with the CLI, so no internet access is required.`, strings.Join(exampleCommands(), ", ")),
+	Example:   "doppler examples run",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: exampleCommands(),
+	Run: func(cmd *cobra.Command, args []string) {
+		jsonFlag := utils.OutputJSON
+
+		examples := models.Examples
+		if len(args) > 0 {
+			commandExamples, ok := models.Examples[args[0]]
+			if !ok {
+				utils.HandleError(fmt.Errorf("no examples available for command %s. Valid commands are %s", args[0], strings.Join(exampleCommands(), ", ")))
+			}
+			examples = map[string][]models.Example{args[0]: commandExamples}
+		}
+
+		printer.Examples(examples, jsonFlag)
+	},
+}
+
+func exampleCommands() []string {
+	var commands []string
+	for command := range models.Examples {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+func init() {
+	rootCmd.AddCommand(examplesCmd)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2021 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/controllers"
+	"github.com/DopplerHQ/cli/pkg/printer"
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Check that the Doppler API is reachable and your token is valid",
+	Long: `Check that the Doppler API is reachable and your token is valid.
+
+Makes a single lightweight authenticated request and reports the API's latency, whether the token
+is valid, and the scope the token was configured at. Exits with code 0 if both checks pass, and 1 otherwise.
+
+Ex: use as a health check:
+doppler ping --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		jsonFlag := utils.OutputJSON
+		localConfig := configuration.LocalConfig(cmd)
+
+		result := controllers.Ping(localConfig)
+		printer.PingResult(result, jsonFlag)
+
+		if !result.Reachable || !result.Authenticated {
+			if jsonFlag {
+				// the output above already describes the failure
+				os.Exit(1)
+			}
+			utils.HandleError(errors.New(result.Error))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pingCmd)
+}
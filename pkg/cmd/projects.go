@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/DopplerHQ/cli/pkg/configuration"
@@ -70,6 +71,7 @@ func projects(cmd *cobra.Command, args []string) {
 	localConfig := configuration.LocalConfig(cmd)
 	number := utils.GetIntFlag(cmd, "number", 16)
 	page := utils.GetIntFlag(cmd, "page", 16)
+	fields := jsonFieldsFlag[models.ProjectInfo](cmd, jsonFlag)
 
 	utils.RequireValue("token", localConfig.Token.Value)
 
@@ -78,6 +80,11 @@ func projects(cmd *cobra.Command, args []string) {
 		utils.HandleError(err.Unwrap(), err.Message)
 	}
 
+	if len(fields) > 0 {
+		printer.JSON(selectJSONFields(info, fields))
+		return
+	}
+
 	printer.ProjectsInfo(info, jsonFlag)
 }
 
@@ -118,6 +125,8 @@ func createProjects(cmd *cobra.Command, args []string) {
 		utils.HandleError(err.Unwrap(), err.Message)
 	}
 
+	controllers.ClearCompletionCache()
+
 	if !utils.Silent {
 		printer.ProjectInfo(info, jsonFlag)
 	}
@@ -135,25 +144,26 @@ func deleteProjects(cmd *cobra.Command, args []string) {
 		project = args[0]
 	}
 
-	prompt := "Delete project"
-	if project != "" {
-		prompt = fmt.Sprintf("%s %s", prompt, project)
+	utils.RequireValue("project", project)
+
+	if !yes && !utils.RetypeConfirmationPrompt(fmt.Sprintf("Delete project %s? This cannot be undone", project), project) {
+		utils.HandleError(errors.New("project name did not match"), "Project was not deleted")
 	}
 
-	if yes || utils.ConfirmationPrompt(prompt, false) {
-		err := http.DeleteProject(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, project)
+	err := http.DeleteProject(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, project)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	controllers.ClearCompletionCache()
+
+	if !utils.Silent {
+		info, err := http.GetProjects(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, 1, 100)
 		if !err.IsNil() {
 			utils.HandleError(err.Unwrap(), err.Message)
 		}
 
-		if !utils.Silent {
-			info, err := http.GetProjects(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, 1, 100)
-			if !err.IsNil() {
-				utils.HandleError(err.Unwrap(), err.Message)
-			}
-
-			printer.ProjectsInfo(info, jsonFlag)
-		}
+		printer.ProjectsInfo(info, jsonFlag)
 	}
 }
 
@@ -191,6 +201,9 @@ func updateProjects(cmd *cobra.Command, args []string) {
 		utils.HandleError(httpErr.Unwrap(), httpErr.Message)
 	}
 
+	// the project may have been renamed
+	controllers.ClearCompletionCache()
+
 	if !utils.Silent {
 		printer.ProjectInfo(info, jsonFlag)
 	}
@@ -200,7 +213,7 @@ func projectIDsValidArgs(cmd *cobra.Command, args []string, toComplete string) (
 	persistentValidArgsFunction(cmd)
 
 	localConfig := configuration.LocalConfig(cmd)
-	ids, err := controllers.GetProjectIDs(localConfig)
+	ids, err := controllers.CachedProjectIDs(localConfig)
 	if err.IsNil() {
 		return ids, cobra.ShellCompDirectiveNoFileComp
 	}
@@ -210,6 +223,7 @@ func projectIDsValidArgs(cmd *cobra.Command, args []string, toComplete string) (
 func init() {
 	projectsCmd.Flags().IntP("number", "n", 100, "max number of projects to display")
 	projectsCmd.Flags().Int("page", 1, "page to display")
+	projectsCmd.Flags().StringSlice("fields", []string{}, "only include these fields in the JSON output (e.g. id,name,created_at)")
 
 	projectsGetCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
 	if err := projectsGetCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
@@ -16,9 +16,16 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"syscall"
 
 	"github.com/DopplerHQ/cli/pkg/configuration"
 	"github.com/DopplerHQ/cli/pkg/controllers"
@@ -35,15 +42,40 @@ import (
 
 var printConfig = false
 
+// outputFile the file the command's output is written to, via --output. empty writes to stdout
+var outputFile string
+
+// outputBuffer holds the command's output until it's written to outputFile
+var outputBuffer bytes.Buffer
+
 var rootCmd = &cobra.Command{
 	Use:   "doppler",
 	Short: "The official Doppler CLI",
-	Args:  cobra.NoArgs,
+	Example: `doppler login
+doppler setup
+doppler run -- YOUR_COMMAND --YOUR-FLAG
+doppler secrets set API_KEY=123
+
+Run 'doppler examples' for more examples`,
+	Args: cobra.NoArgs,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		loadFlags(cmd)
 		configuration.Setup()
 		configuration.LoadConfig()
 
+		// the CA certificate can be set per scope, so it's resolved once the config file is loaded
+		if caCert := configuration.CACert(cmd); caCert.Value != "" {
+			pool, err := http.LoadCACert(caCert.Value)
+			if err == nil {
+				http.RootCAs = pool
+			} else if strings.HasPrefix(cmd.CommandPath(), "doppler configure") {
+				// don't prevent an invalid ca-cert from being fixed
+				utils.LogWarning(fmt.Sprintf("Unable to load CA certificate (source: %s): %s", caCert.Source, err))
+			} else {
+				utils.HandleError(err, fmt.Sprintf("Unable to load CA certificate (source: %s)", caCert.Source))
+			}
+		}
+
 		controllers.CaptureCommand(cmd.CommandPath())
 
 		if utils.Debug && utils.Silent {
@@ -137,6 +169,93 @@ func loadFlags(cmd *cobra.Command) {
 	// flag takes precedence over env var
 	http.UseCustomDNSResolver = utils.GetBoolFlagIfChanged(cmd, "enable-dns-resolver", http.UseCustomDNSResolver)
 
+	// retry backoff strategy
+	if cmd.Flags().Changed("retry-strategy") {
+		strategy := cmd.Flag("retry-strategy").Value.String()
+		backoff, ok := utils.BackoffStrategies[strategy]
+		if !ok {
+			utils.HandleError(fmt.Errorf("invalid retry strategy %q. Valid strategies are %v", strategy, utils.BackoffStrategyNames))
+		}
+		http.RetryBackoff = backoff
+	}
+	if http.BaseBackoff < 0 {
+		utils.HandleError(errors.New("--retry-base-delay cannot be negative"))
+	}
+
+	// user agent suffix
+	if configuration.CanReadEnv {
+		userAgentSuffix := os.Getenv("DOPPLER_UA_SUFFIX")
+		if userAgentSuffix != "" {
+			http.UserAgentSuffix = userAgentSuffix
+		}
+	}
+	// flag takes precedence over env var
+	http.UserAgentSuffix = utils.GetFlagIfChanged(cmd, "user-agent-suffix", http.UserAgentSuffix)
+
+	// client request ID header
+	if configuration.CanReadEnv {
+		if requestIDHeader, ok := os.LookupEnv("DOPPLER_REQUEST_ID_HEADER"); ok {
+			http.RequestIDHeader = requestIDHeader
+		}
+	}
+	// flag takes precedence over env var
+	http.RequestIDHeader = utils.GetFlagIfChanged(cmd, "request-id-header", http.RequestIDHeader)
+
+	// TLS server name
+	if configuration.CanReadEnv {
+		if tlsServerName := os.Getenv("DOPPLER_TLS_SERVER_NAME"); tlsServerName != "" {
+			http.TLSServerName = tlsServerName
+		}
+	}
+	// flag takes precedence over env var
+	http.TLSServerName = utils.GetFlagIfChanged(cmd, "tls-server-name", http.TLSServerName)
+	if strings.ContainsAny(http.TLSServerName, "/:[] ") {
+		utils.HandleError(fmt.Errorf("invalid TLS server name %q. Specify a hostname without a scheme or port (e.g. api.example.com)", http.TLSServerName))
+	}
+
+	// proxy, which otherwise is read from the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY env vars
+	if cmd.Flags().Changed("proxy") {
+		proxy := cmd.Flag("proxy").Value.String()
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" || !utils.Contains([]string{"http", "https", "socks5"}, proxyURL.Scheme) {
+			utils.HandleError(fmt.Errorf("invalid proxy %q. Specify a URL beginning with http://, https://, or socks5:// (e.g. http://proxy.example.com:8080)", proxy))
+		}
+		http.ProxyURL = proxyURL
+	}
+
+	// request log
+	logFile := ""
+	if configuration.CanReadEnv {
+		logFile = os.Getenv("DOPPLER_LOG_FILE")
+	}
+	// flag takes precedence over env var
+	logFile = utils.GetPathFlagIfChanged(cmd, "log-file", logFile)
+	if logFile != "" {
+		// #nosec G304
+		file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			utils.HandleError(err, fmt.Sprintf("Unable to open log file %s", logFile))
+		}
+		http.RequestLog = file
+	}
+
+	// output file, unless the command has its own --output flag
+	if flag := cmd.Flags().Lookup("output"); flag != nil && flag == cmd.Root().PersistentFlags().Lookup("output") && flag.Changed {
+		outputFile = ""
+		printer.Output = os.Stdout
+		utils.Stdout = os.Stdout
+		if output := flag.Value.String(); output != "-" {
+			path, err := utils.GetFilePath(output)
+			if err != nil {
+				utils.HandleError(err, "Unable to parse output file path")
+			}
+			// the output is buffered so that the file is only written once the command succeeds
+			outputFile = path
+			printer.Output = &outputBuffer
+			utils.Stdout = &outputBuffer
+		}
+	}
+
 	// no-file is used by the 'secrets download' command to output secrets to stdout
 	utils.Silent = utils.GetBoolFlagIfChanged(cmd, "no-file", utils.Silent)
 }
@@ -149,6 +268,36 @@ func deprecatedCommand(newCommand string) {
 	}
 }
 
+// jsonFieldsFlag returns the fields passed via --fields, which are only supported with JSON output
+func jsonFieldsFlag[T any](cmd *cobra.Command, jsonOutput bool) []string {
+	fields, err := cmd.Flags().GetStringSlice("fields")
+	if err != nil {
+		utils.HandleError(err)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	if !jsonOutput {
+		utils.HandleError(errors.New("--fields can only be used with JSON output"))
+	}
+	if err := utils.ValidateJSONFields[T](fields); err != nil {
+		utils.HandleError(err)
+	}
+	return fields
+}
+
+// selectJSONFields returns the items with only the named fields, or the items unchanged if no fields are named
+func selectJSONFields[T any](items []T, fields []string) interface{} {
+	if len(fields) == 0 {
+		return items
+	}
+	selected, err := utils.SelectJSONFields(items, fields)
+	if err != nil {
+		utils.HandleError(err, "Unable to select fields")
+	}
+	return selected
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -165,6 +314,23 @@ func Execute() {
 	// initialize the wait group before executing the command
 	global.WaitGroup = new(sync.WaitGroup)
 
+	// the first interrupt cancels in-flight http requests and their retries, rather than leaving them to time out.
+	// later interrupts are handled normally. signals received while running a child process are left to its handler
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	http.Context = ctx
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go http.CancelOnInterrupt(interrupts, cancel, utils.SignalsHandled, func(sig os.Signal) {
+		signal.Stop(interrupts)
+
+		// a command that isn't waiting on a request (e.g. one reading from stdin) is interrupted as it would be without
+		// this handler
+		if !http.RequestsInFlight() {
+			interrupt(sig)
+		}
+	})
+
 	err := rootCmd.Execute()
 
 	// wait for group before checking error
@@ -173,6 +339,26 @@ func Execute() {
 	if err != nil {
 		os.Exit(1)
 	}
+
+	if outputFile != "" {
+		// the output may contain secrets
+		if err := utils.WriteFile(outputFile, outputBuffer.Bytes(), 0600); err != nil {
+			utils.HandleError(err, fmt.Sprintf("Unable to write output to %s", outputFile))
+		}
+	}
+
+	utils.ExitOnWarnings()
+}
+
+// interrupt re-raises the signal now that it's no longer caught, so that it takes its default action. where signals
+// can't be sent (e.g. an interrupt on windows), the process exits as if it had been interrupted
+func interrupt(sig os.Signal) {
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		if err := p.Signal(sig); err == nil {
+			return
+		}
+	}
+	os.Exit(130)
 }
 
 func init() {
@@ -180,14 +366,26 @@ func init() {
 	rootCmd.SetVersionTemplate(rootCmd.Version + "\n")
 	rootCmd.Flags().BoolP("version", "v", false, "Get the version of the Doppler CLI")
 
-	rootCmd.PersistentFlags().StringP("token", "t", "", "doppler token")
+	rootCmd.PersistentFlags().StringP("token", "t", "", "doppler token. '-' reads the token from stdin, consuming all of its input so it can't also be used for other input (e.g. piped secrets or the stdin of 'doppler run')")
+	rootCmd.PersistentFlags().String("token-file", "", "read the doppler token from this file (e.g. a Docker or Kubernetes secret mount). the file must not be readable by all users. cannot be used with --token")
 	rootCmd.PersistentFlags().String("api-host", "https://api.doppler.com", "The host address for the Doppler API")
 	rootCmd.PersistentFlags().String("dashboard-host", "https://dashboard.doppler.com", "The host address for the Doppler Dashboard")
+	rootCmd.PersistentFlags().String("api-env", "", fmt.Sprintf("The Doppler environment to use, which sets the API and Dashboard hosts. one of %v. cannot be used with --api-host", models.APIEnvironmentNames()))
 	rootCmd.PersistentFlags().Bool("no-check-version", !version.PerformVersionCheck, "disable checking for Doppler CLI updates")
 	rootCmd.PersistentFlags().Bool("no-verify-tls", false, "do not verify the validity of TLS certificates on HTTP requests (not recommended)")
 	rootCmd.PersistentFlags().Bool("no-timeout", !http.UseTimeout, "disable http timeout")
 	rootCmd.PersistentFlags().DurationVar(&http.TimeoutDuration, "timeout", http.TimeoutDuration, "max http request duration")
+	rootCmd.PersistentFlags().DurationVar(&http.ConnectTimeoutDuration, "connect-timeout", http.ConnectTimeoutDuration, "max duration to establish an http connection. unlike --timeout, this does not include reading the response, and it applies even with --no-timeout. defaults to --timeout")
 	rootCmd.PersistentFlags().IntVar(&http.RequestAttempts, "attempts", http.RequestAttempts, "number of http request attempts made before failing")
+	rootCmd.PersistentFlags().String("retry-strategy", "full-jitter", fmt.Sprintf("backoff strategy between http request attempts. one of %v", utils.BackoffStrategyNames))
+	rootCmd.PersistentFlags().DurationVar(&http.BaseBackoff, "retry-base-delay", http.BaseBackoff, "delay the backoff strategy starts from between http request attempts. a 429 response's Retry-After header takes precedence")
+	rootCmd.PersistentFlags().String("user-agent-suffix", http.UserAgentSuffix, "identifier to append to the user agent of http requests (e.g. the name of the tool invoking the CLI)")
+	rootCmd.PersistentFlags().String("ca-cert", "", "path to a PEM-encoded CA certificate to trust, in addition to the system's, when verifying the API's TLS certificate (e.g. for a self-hosted instance with a private CA)")
+	rootCmd.PersistentFlags().String("output", "", "write the command's output to this file rather than stdout. messages logged to stderr aren't included. the file is created with 0600 permissions. '-' writes to stdout. 'secrets get', 'secrets download' and 'secrets substitute' have their own --output flag, which writes the secrets themselves instead")
+	rootCmd.PersistentFlags().String("log-file", "", "append a JSON line for each http request attempt to this file (method, url, status, duration, request ids, and attempt number). credentials are redacted and bodies are never logged")
+	rootCmd.PersistentFlags().String("proxy", "", "URL of the proxy to send http requests through (e.g. http://proxy.example.com:8080). overrides the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY env vars")
+	rootCmd.PersistentFlags().String("tls-server-name", http.TLSServerName, "hostname to send via SNI and to verify the API's TLS certificate against, rather than the --api-host's host (e.g. when --api-host is an IP address)")
+	rootCmd.PersistentFlags().String("request-id-header", http.RequestIDHeader, "header used to send a client-generated ID with each http request. specify an empty value to disable")
 	// DNS resolver
 	rootCmd.PersistentFlags().Bool("no-dns-resolver", !http.UseCustomDNSResolver, "use the OS's default DNS resolver")
 	if err := rootCmd.PersistentFlags().MarkDeprecated("no-dns-resolver", "the DNS resolver is disabled by default"); err != nil {
@@ -212,7 +410,9 @@ func init() {
 		utils.HandleError(err)
 	}
 	rootCmd.PersistentFlags().BoolVar(&utils.OutputJSON, "json", utils.OutputJSON, "output json")
+	rootCmd.PersistentFlags().BoolVar(&utils.PrettyJSON, "pretty", utils.PrettyJSON, "indent json output for readability, rather than printing it on a single line")
 	rootCmd.PersistentFlags().BoolVar(&utils.Debug, "debug", utils.Debug, "output additional information")
 	rootCmd.PersistentFlags().BoolVar(&printConfig, "print-config", printConfig, "output active configuration")
 	rootCmd.PersistentFlags().BoolVar(&utils.Silent, "silent", utils.Silent, "disable output of info messages")
+	rootCmd.PersistentFlags().BoolVar(&utils.FailOnWarning, "fail-on-warning", utils.FailOnWarning, "exit with a non-zero code if any warnings are logged (e.g. for strict CI pipelines)")
 }
@@ -16,12 +16,16 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -44,20 +48,71 @@ const defaultFallbackFileMaxAge = 14 * 24 * time.Hour // 14 days
 
 var secretsToInclude []string
 
+// restartStableDuration how long a process restarted by --restart-on-exit must run before it's considered stable, which
+// resets the restart count and backoff. matches the max backoff, so a crash loop can't be mistaken for a stable process
+const restartStableDuration = 30 * time.Second
+
+// the exit codes used when the command can't be run, matching the shell's, so they're distinct from the command's own exit code
+const (
+	commandNotExecutableExitCode = 126
+	commandNotFoundExitCode      = 127
+)
+
+var fdFormats = []string{models.JSONMountFormat, models.EnvMountFormat, models.DotNETJSONMountFormat}
+
 var runCmd = &cobra.Command{
 	Use:   "run [command]",
 	Short: "Run a command with secrets injected into the environment",
 	Long: `Run a command with secrets injected into the environment.
 Secrets can also be mounted to an ephemeral file using the --mount flag.
 
+Doppler flags must be specified before the command. Everything after the command is passed to it,
+so 'doppler run app --verbose' passes --verbose to app. Use --strict-args to require a '--' separator
+before the command (e.g. doppler run --strict-args -- app --verbose).
+
+Secrets can also be passed on an inherited file descriptor using the --fd flag, so they're never
+written to disk or the environment. The command reads the secrets from the file descriptor
+specified by DOPPLER_CLI_SECRETS_FD until EOF. The file descriptor is a pipe, so it can only be
+read once and isn't seekable. With --watch, each restarted process receives a new pipe.
+File descriptors between 3 and the specified one are closed in the command.
+
+With --no-disk, the fallback file is disabled so secrets are only ever held in memory, and it's an
+error to use any flag that would read or write secrets on disk (e.g. --fallback). Secrets mounted
+via --mount are still permitted, as they're served from a named pipe rather than written to disk.
+
+With --secrets-from-stdin, a JSON object of secrets (e.g. the output of 'doppler secrets download --no-file')
+is read from stdin and injected as-is, without contacting Doppler or using the fallback file. The
+command's stdin is then empty.
+
+With --procfile, each process defined in the Procfile (e.g. 'web: npm start') is run with the secrets
+injected and its output prefixed with the process's name. When any process exits, the others are
+stopped, unless --keep-alive is specified.
+
+With --watch or --restart-on-exit, the secrets last fetched are kept in memory. If they can't be
+refetched when restarting the process (e.g. due to a transient API error), the process is restarted
+with them and a warning is logged. Use --strict-refresh to fail instead.
+
+Doppler exits with the command's exit code. If the command can't be found, it exits with code 127,
+or 126 if the command can't be started, like a shell.
+
+When using --user, secrets are fetched (and the fallback file is read and written) as the current user.
+Only the command itself runs as the specified user.
+
 To view the CLI's active configuration, run ` + "`doppler configure debug`",
 	Example: `doppler run -- YOUR_COMMAND --YOUR-FLAG
 doppler run --command "YOUR_COMMAND && YOUR_OTHER_COMMAND"
-doppler run --mount secrets.json -- cat secrets.json`,
+doppler run --mount secrets.json -- cat secrets.json
+doppler run --fd 3 -- sh -c 'cat <&3'
+doppler run --procfile Procfile
+doppler secrets download --no-file | ssh host doppler run --secrets-from-stdin -- YOUR_COMMAND`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		// The --command flag and args are mututally exclusive
 		usingCommandFlag := cmd.Flags().Changed("command")
-		if usingCommandFlag {
+		if cmd.Flags().Changed("procfile") {
+			if usingCommandFlag || len(args) > 0 {
+				return errors.New("a command may not be specified when using --procfile flag")
+			}
+		} else if usingCommandFlag {
 			command := cmd.Flag("command").Value.String()
 			if command == "" {
 				return errors.New("--command flag requires a value")
@@ -67,12 +122,17 @@ doppler run --mount secrets.json -- cat secrets.json`,
 				return errors.New("arg(s) may not be set when using --command flag")
 			}
 		} else if len(args) == 0 {
-			return errors.New("requires at least 1 arg(s), received 0")
+			return errors.New("no command specified. Use '--' to separate doppler flags from your command (e.g. doppler run -- YOUR_COMMAND)")
+		} else if utils.GetBoolFlag(cmd, "strict-args") && cmd.ArgsLenAtDash() != 0 {
+			return fmt.Errorf("--strict-args requires '--' before the command, so that its flags aren't mistaken for doppler flags (e.g. doppler run --strict-args -- %s)", args[0])
 		}
 
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		// a command run with --tty places the terminal in raw mode, which must not outlive the CLI (e.g. after a panic)
+		defer utils.RestoreTerminals()
+
 		enableFallback := !utils.GetBoolFlag(cmd, "no-fallback")
 		enableCache := enableFallback && !utils.GetBoolFlag(cmd, "no-cache")
 		fallbackReadonly := utils.GetBoolFlag(cmd, "fallback-readonly")
@@ -80,16 +140,202 @@ doppler run --mount secrets.json -- cat secrets.json`,
 		exitOnWriteFailure := !utils.GetBoolFlag(cmd, "no-exit-on-write-failure")
 		preserveEnv := cmd.Flag("preserve-env").Value.String()
 		forwardSignals := utils.GetBoolFlag(cmd, "forward-signals")
+		injectMetadata := utils.GetBoolFlag(cmd, "inject-metadata")
+		encoding := cmd.Flag("encode").Value.String()
+		secretsFromStdin := utils.GetBoolFlag(cmd, "secrets-from-stdin")
 		localConfig := configuration.LocalConfig(cmd)
 		dynamicSecretsTTL := utils.GetDurationFlag(cmd, "dynamic-ttl")
 		exitOnMissingIncludedSecrets := !cmd.Flags().Changed("no-exit-on-missing-only-secrets")
+		secretsToExclude, e := cmd.Flags().GetStringArray("except")
+		if e != nil {
+			utils.HandleError(e)
+		}
+
+		// local overrides are read once, and applied on top of the secrets each time they're loaded
+		envFiles, e := cmd.Flags().GetStringArray("env-file")
+		if e != nil {
+			utils.HandleError(e)
+		}
+		envFileSecrets := map[string]string{}
+		for _, envFile := range envFiles {
+			content, err := ioutil.ReadFile(envFile) // #nosec G304
+			if err != nil {
+				utils.HandleError(err, fmt.Sprintf("Unable to read env file %s", envFile))
+			}
+			parsed, err := utils.ParseDotenv(string(content))
+			if err != nil {
+				utils.HandleError(err, fmt.Sprintf("Unable to parse env file %s", envFile))
+			}
+			for name, value := range parsed {
+				envFileSecrets[name] = value
+			}
+		}
+
+		// prefetched secrets are read once up front, and reused if the process is restarted
+		var stdinSecrets map[string]string
+		if secretsFromStdin {
+			for _, flag := range []string{"watch", "tag", "name-transformer", "fallback-only"} {
+				if cmd.Flags().Changed(flag) {
+					utils.HandleError(fmt.Errorf("--%s cannot be used with --secrets-from-stdin, as the secrets aren't fetched from Doppler", flag))
+				}
+			}
+			if cmd.Flag("token").Value.String() == "-" {
+				utils.HandleError(errors.New("--token - cannot be used with --secrets-from-stdin, as both read from stdin"))
+			}
+			if isatty.IsTerminal(os.Stdin.Fd()) {
+				utils.HandleError(errors.New("--secrets-from-stdin requires secrets to be piped to stdin (e.g. doppler secrets download --no-file | doppler run --secrets-from-stdin -- YOUR_COMMAND)"))
+			}
 
-		utils.RequireValue("token", localConfig.Token.Value)
+			data, err := ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				utils.HandleError(err, "Unable to read secrets from stdin")
+			}
+			var parseErr controllers.Error
+			if stdinSecrets, parseErr = controllers.ParseDeploySecrets(data); !parseErr.IsNil() {
+				utils.HandleError(parseErr.Unwrap(), parseErr.Message)
+			}
+
+			// the fallback file only stores secrets fetched from Doppler
+			enableFallback = false
+			enableCache = false
+		} else {
+			utils.RequireValue("token", localConfig.Token.Value)
+		}
+
+		// the fallback file (and its metadata) is the only place secrets are written to disk
+		if utils.GetBoolFlag(cmd, "no-disk") {
+			for _, flag := range []string{"fallback", "fallback-only", "fallback-readonly", "passphrase", "no-exit-on-write-failure", "fallback-max-age", "fallback-stale", "fallback-format"} {
+				if cmd.Flags().Changed(flag) {
+					utils.HandleError(fmt.Errorf("--%s cannot be used with --no-disk, as it reads or writes secrets on disk", flag))
+				}
+			}
+			utils.LogDebug("Disabling the fallback file due to --no-disk")
+			enableFallback = false
+			enableCache = false
+		}
+
+		if encoding != "" && !utils.Contains(utils.ValueEncodings, encoding) {
+			utils.HandleError(fmt.Errorf("invalid encoding. Valid encodings are %v", utils.ValueEncodings))
+		}
+
+		commandOpts := utils.CommandOptions{}
+		if cmd.Flags().Changed("chdir") {
+			dir, err := utils.ParsePath(cmd.Flag("chdir").Value.String())
+			if err != nil {
+				utils.HandleError(err, "Unable to parse --chdir path")
+			}
+			info, err := os.Stat(dir)
+			if err != nil {
+				utils.HandleError(err, "Unable to access --chdir directory")
+			}
+			if !info.IsDir() {
+				utils.HandleError(fmt.Errorf("--chdir path is not a directory: %s", dir))
+			}
+			commandOpts.Dir = dir
+		}
+
+		if cmd.Flags().Changed("user") {
+			processUser, err := utils.ParseProcessUser(cmd.Flag("user").Value.String())
+			if err != nil {
+				utils.HandleError(err)
+			}
+			commandOpts.User = processUser
+		}
+
+		var accessLog *os.File
+		if cmd.Flags().Changed("log-secrets-access") {
+			accessLogPath, err := utils.GetFilePath(cmd.Flag("log-secrets-access").Value.String())
+			if err != nil {
+				utils.HandleError(err, "Unable to parse --log-secrets-access path")
+			}
+			// open the log up front so we fail before starting the command if it can't be written
+			var logErr controllers.Error
+			if accessLog, logErr = controllers.OpenSecretsAccessLog(accessLogPath); !logErr.IsNil() {
+				utils.HandleError(logErr.Unwrap(), logErr.Message)
+			}
+		}
+
+		if utils.GetBoolFlag(cmd, "tty") {
+			if !utils.SupportsPTY {
+				utils.HandleError(errors.New("--tty is only supported on Linux"))
+			}
+			if isatty.IsTerminal(os.Stdin.Fd()) {
+				commandOpts.TTY = true
+			} else {
+				utils.LogDebug("Not allocating a pseudo-terminal as stdin is not a terminal")
+			}
+		}
+
+		var procfileProcesses []controllers.ProcfileProcess
+		if cmd.Flags().Changed("procfile") {
+			for _, flag := range []string{"watch", "restart-on-exit", "mount", "fd", "tty", "log-secrets-access"} {
+				if cmd.Flags().Changed(flag) {
+					utils.HandleError(fmt.Errorf("--%s cannot be used with --procfile", flag))
+				}
+			}
+
+			procfilePath, err := utils.GetFilePath(cmd.Flag("procfile").Value.String())
+			if err != nil {
+				utils.HandleError(err, "Unable to parse --procfile path")
+			}
+			data, err := ioutil.ReadFile(procfilePath) // #nosec G304
+			if err != nil {
+				utils.HandleError(err, "Unable to read Procfile")
+			}
+			if procfileProcesses, err = controllers.ParseProcfile(data); err != nil {
+				utils.HandleError(err, "Unable to parse Procfile")
+			}
+		} else if cmd.Flags().Changed("keep-alive") {
+			utils.LogWarning("--keep-alive has no effect when used without --procfile")
+		}
+
+		// fail before fetching secrets if the command can't be executed
+		if len(procfileProcesses) == 0 && !cmd.Flags().Changed("command") {
+			command := args[0]
+			// relative command paths are resolved against the child's working directory
+			if commandOpts.Dir != "" && strings.ContainsRune(command, filepath.Separator) && !filepath.IsAbs(command) {
+				command = filepath.Join(commandOpts.Dir, command)
+			}
+			commandPath, err := exec.LookPath(command)
+			if err != nil {
+				utils.LogDebugError(err)
+				utils.ErrExit(fmt.Errorf("command not found: %s", args[0]), commandNotFoundExitCode)
+			}
+			utils.LogDebug(fmt.Sprintf("Resolved command %s to %s", args[0], commandPath))
+		}
 
 		if cmd.Flags().Changed("only-secrets") && len(secretsToInclude) == 0 {
 			utils.HandleError(fmt.Errorf("you must specify secrets when using --only-secrets"))
 		}
 
+		tags, e := cmd.Flags().GetStringArray("tag")
+		if e != nil {
+			utils.HandleError(e)
+		}
+		if len(tags) > 0 {
+			if fallbackOnly {
+				utils.HandleError(errors.New("--tag cannot be used with --fallback-only, as tags are resolved via the Doppler API"))
+			}
+
+			// resolve tags to secret names once; the names are then used like --only-secrets
+			taggedSecrets, err := controllers.GetSecretsByTags(localConfig, tags)
+			if !err.IsNil() {
+				utils.HandleError(err.Unwrap(), err.Message)
+			}
+
+			var names []string
+			for name := range taggedSecrets {
+				if len(secretsToInclude) == 0 || utils.Contains(secretsToInclude, name) {
+					names = append(names, name)
+				}
+			}
+			if len(names) == 0 {
+				utils.HandleError(fmt.Errorf("no secrets found with tag(s): %s", strings.Join(tags, ", ")))
+			}
+			sort.Strings(names)
+			secretsToInclude = names
+		}
+
 		nameTransformerString := cmd.Flag("name-transformer").Value.String()
 		var nameTransformer *models.SecretsNameTransformer
 		if nameTransformerString != "" {
@@ -106,13 +352,18 @@ doppler run --mount secrets.json -- cat secrets.json`,
 		if enableFallback {
 			fallbackPath, legacyFallbackPath = initFallbackDir(cmd, localConfig, format, nameTransformer, secretsToInclude, exitOnWriteFailure)
 		}
-		if enableCache {
+		// the metadata file is also used to check the fallback file's age
+		if enableCache || enableFallback {
 			metadataPath = controllers.MetadataFilePath(localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, format, nameTransformer, secretsToInclude)
 		}
 
-		passphrase := getPassphrase(cmd, "passphrase", localConfig)
-		if passphrase == "" {
-			utils.HandleError(errors.New("invalid passphrase"))
+		// the default passphrase is derived from the token, which isn't required for prefetched secrets
+		passphrase := ""
+		if !secretsFromStdin {
+			passphrase = getPassphrase(cmd, "passphrase", localConfig)
+			if passphrase == "" {
+				utils.HandleError(errors.New("invalid passphrase"))
+			}
 		}
 
 		if !enableFallback {
@@ -133,6 +384,8 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			ExitOnWriteFailure: exitOnWriteFailure,
 			Passphrase:         passphrase,
 		}
+		fallbackOpts.MaxAge, fallbackOpts.WarnOnStale = fallbackMaxAgeOptions(cmd)
+		fallbackOpts.Format = fallbackFormatOption(cmd)
 
 		mountPath := cmd.Flag("mount").Value.String()
 		mountFormatString := cmd.Flag("mount-format").Value.String()
@@ -150,14 +403,56 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			utils.HandleError(fmt.Errorf("Invalid mount format. Valid formats are %s", models.SecretsMountFormats))
 		}
 
+		fdOptions := controllers.FDOptions{}
+		if cmd.Flags().Changed("fd") {
+			if utils.IsWindows() {
+				utils.HandleError(errors.New("--fd is not supported on Windows"))
+			}
+			if shouldMountFile {
+				utils.HandleError(errors.New("--fd cannot be used with --mount"))
+			}
+
+			fd := utils.GetIntFlag(cmd, "fd", 32)
+			if err := controllers.ValidateSecretsFD(fd); err != nil {
+				utils.HandleError(err)
+			}
+
+			fdFormat := cmd.Flag("fd-format").Value.String()
+			if !utils.Contains(fdFormats, fdFormat) {
+				utils.HandleError(fmt.Errorf("invalid --fd-format. Valid formats are %s", fdFormats))
+			}
+
+			fdOptions = controllers.FDOptions{Enable: true, FD: fd, Format: fdFormat}
+		} else if cmd.Flags().Changed("fd-format") {
+			utils.LogWarning("--fd-format has no effect when used without --fd")
+		}
+
 		if preserveEnv != "false" {
 			if shouldMountFile {
 				utils.LogWarning("--preserve-env has no effect when used with --mount")
+			} else if fdOptions.Enable {
+				utils.LogWarning("--preserve-env has no effect when used with --fd")
 			} else {
 				utils.LogWarning("Ignoring Doppler secrets already defined in the environment due to --preserve-env flag")
 			}
 		}
 
+		// the environment the secrets are added to
+		originalEnv := os.Environ
+		if utils.GetBoolFlag(cmd, "clear-env") {
+			var keep []string
+			if preserveEnv == "true" {
+				utils.HandleError(errors.New("--clear-env cannot be used with --preserve-env=true. specify the variables to keep instead (e.g. --preserve-env=\"FOO,BAR\")"))
+			} else if preserveEnv != "false" {
+				keep = strings.Split(preserveEnv, ",")
+			}
+			originalEnv = func() []string { return controllers.ClearEnv(os.Environ(), keep) }
+		}
+
+		if shouldMountFile && commandOpts.User != nil {
+			utils.HandleError(errors.New("--user cannot be used with --mount, as the mounted file is only readable by the current user"))
+		}
+
 		if shouldMountTemplate && !shouldMountFile {
 			utils.HandleError(errors.New("--mount-template must be used with --mount"))
 		}
@@ -204,6 +499,30 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			MaxReads: maxReads,
 		}
 
+		restartOnExit := utils.GetBoolFlag(cmd, "restart-on-exit")
+		maxRestarts := utils.GetIntFlag(cmd, "max-restarts", 32)
+		restartBackoff := utils.GetDurationFlag(cmd, "restart-backoff")
+		if restartOnExit {
+			if maxRestarts < 0 {
+				utils.HandleError(errors.New("--max-restarts must be 0 (unlimited) or greater"))
+			}
+			if restartBackoff <= 0 {
+				utils.HandleError(errors.New("--restart-backoff must be greater than 0"))
+			}
+		} else {
+			for _, flag := range []string{"max-restarts", "restart-backoff"} {
+				if cmd.Flags().Changed(flag) {
+					utils.LogWarning(fmt.Sprintf("--%s has no effect when used without --restart-on-exit", flag))
+				}
+			}
+		}
+		restarts := 0
+		// once we've been asked to stop, a process that exits is not restarted
+		stopRequested := make(chan os.Signal, 1)
+		if restartOnExit {
+			signal.Notify(stopRequested, syscall.SIGINT, syscall.SIGTERM)
+		}
+
 		watch := cmd.Flags().Changed("watch")
 
 		if watch && fallbackOpts.Exclusive {
@@ -211,6 +530,13 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			watch = false
 		}
 
+		strictRefresh := utils.GetBoolFlag(cmd, "strict-refresh")
+		if strictRefresh && !watch && !restartOnExit {
+			utils.LogWarning("--strict-refresh has no effect when used without --watch or --restart-on-exit")
+		}
+		// the last secrets successfully fetched, which are reused if refetching them fails when restarting the process
+		var lastFetchedSecrets map[string]string
+
 		var c *exec.Cmd
 		var cleanupMount func()
 		var err error
@@ -223,16 +549,91 @@ doppler run --mount secrets.json -- cat secrets.json`,
 		// this variable has the potential to be racey, but is made safe by our use of the mutex
 		terminatedByWatch := false
 
-		startProcess := func() {
-			// ensure we can fetch the new secrets before restarting the process
-			secrets := controllers.FetchSecrets(localConfig, enableCache, fallbackOpts, metadataPath, nameTransformer, dynamicSecretsTTL, format, secretsToInclude)
+		// loadSecrets fetches the secrets and prepares them for injection
+		loadSecrets := func() map[string]string {
+			var secrets map[string]string
+			if secretsFromStdin {
+				// copy the secrets, as they're modified below
+				secrets = map[string]string{}
+				for name, value := range stdinSecrets {
+					if len(secretsToInclude) == 0 || utils.Contains(secretsToInclude, name) {
+						secrets[name] = value
+					}
+				}
+			} else if lastFetchedSecrets == nil || strictRefresh {
+				// ensure we can fetch the new secrets before restarting the process
+				secrets = controllers.FetchSecrets(localConfig, enableCache, fallbackOpts, metadataPath, nameTransformer, dynamicSecretsTTL, format, secretsToInclude)
+			} else {
+				fetched, fetchErr := controllers.TryFetchSecrets(localConfig, enableCache, fallbackOpts, metadataPath, nameTransformer, dynamicSecretsTTL, format, secretsToInclude)
+				if fetchErr.IsNil() {
+					secrets = fetched
+				} else {
+					utils.LogWarning("Unable to refresh secrets; using the secrets last fetched. Use --strict-refresh to fail instead")
+					utils.LogDebugError(fetchErr.Unwrap())
+					secrets = map[string]string{}
+					for name, value := range lastFetchedSecrets {
+						secrets[name] = value
+					}
+				}
+			}
+
+			// keep a copy for restarts, as the secrets are modified below
+			if !secretsFromStdin && (watch || restartOnExit) {
+				lastFetchedSecrets = map[string]string{}
+				for name, value := range secrets {
+					lastFetchedSecrets[name] = value
+				}
+			}
+
+			for name, value := range envFileSecrets {
+				if _, ok := secrets[name]; ok {
+					utils.LogDebug(fmt.Sprintf("Overriding secret %s with the value from --env-file", name))
+				}
+				secrets[name] = value
+			}
+
+			controllers.ValidateSecrets(secrets, secretsToInclude, exitOnMissingIncludedSecrets, mountOptions)
+
+			if injectMetadata {
+				for _, warning := range controllers.InjectConfigMetadata(secrets, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value) {
+					utils.LogWarning(warning)
+				}
+			} else {
+				controllers.RemoveConfigMetadata(secrets)
+			}
+
+			// excluded last, so they're removed even if included via --only-secrets or --inject-metadata
+			for _, name := range secretsToExclude {
+				delete(secrets, name)
+			}
+
+			if encoding != "" {
+				secrets = encodeSecretValues(secrets, encoding)
+			}
+			return secrets
+		}
+
+		if len(procfileProcesses) > 0 {
+			env, _ := controllers.PrepareSecrets(loadSecrets(), originalEnv(), preserveEnv, mountOptions)
+			exitCode, procfileErr := controllers.RunProcfile(procfileProcesses, env, utils.GetBoolFlag(cmd, "keep-alive"), forwardSignals, commandOpts)
+			// like a command, a process that can't be started exits with the shell's code for it
+			if !procfileErr.IsNil() {
+				utils.ErrExit(procfileErr.Unwrap(), commandNotExecutableExitCode, procfileErr.Message)
+			}
+			if exitCode == 0 {
+				utils.ExitOnWarnings()
+			}
+			os.Exit(exitCode)
+		}
+
+		var startProcess func()
+		startProcess = func() {
+			secrets := loadSecrets()
 			secretsFetchedAt := time.Now()
 			if secretsFetchedAt.After(lastSecretsFetch) {
 				lastSecretsFetch = secretsFetchedAt
 			}
 
-			controllers.ValidateSecrets(secrets, secretsToInclude, exitOnMissingIncludedSecrets, mountOptions)
-
 			isRestart := c != nil
 			// terminate the old process
 			if isRestart {
@@ -280,7 +681,12 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			terminatedByWatch = false
 
 			var env []string
-			env, cleanupMount = controllers.PrepareSecrets(secrets, os.Environ(), preserveEnv, mountOptions)
+			processOpts := commandOpts
+			if fdOptions.Enable {
+				env, processOpts.ExtraFiles, cleanupMount = controllers.PrepareSecretsFD(secrets, originalEnv(), fdOptions)
+			} else {
+				env, cleanupMount = controllers.PrepareSecrets(secrets, originalEnv(), preserveEnv, mountOptions)
+			}
 
 			global.WaitGroup.Add(1)
 
@@ -289,13 +695,25 @@ doppler run --mount secrets.json -- cat secrets.json`,
 			}
 
 			// start the process
-			c, err = controllers.Run(cmd, args, env, forwardSignals)
+			c, err = controllers.Run(cmd, args, env, forwardSignals, processOpts)
 			if err != nil {
 				defer global.WaitGroup.Done()
 				if cleanupMount != nil {
 					cleanupMount()
 				}
-				utils.HandleError(err)
+				utils.ErrExit(err, commandNotExecutableExitCode)
+			}
+			startedAt := time.Now()
+
+			if accessLog != nil {
+				command := args
+				if cmd.Flags().Changed("command") {
+					command = []string{cmd.Flag("command").Value.String()}
+				}
+				if logErr := controllers.LogSecretsAccess(accessLog, c.Process.Pid, command, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, secrets); !logErr.IsNil() {
+					utils.LogWarning(logErr.Message)
+					utils.LogDebugError(logErr.Unwrap())
+				}
 			}
 
 			go func() {
@@ -320,6 +738,54 @@ doppler run --mount secrets.json -- cat secrets.json`,
 						utils.LogDebugError(err)
 					}
 
+					// the process likely exited because of the signal, so it shouldn't be restarted
+					stopping := false
+					select {
+					case <-stopRequested:
+						stopping = true
+						utils.LogDebug("Not restarting process; received signal to stop")
+					default:
+					}
+
+					// a process that crashes after running for a while isn't crash looping, so it gets a fresh set of restarts
+					if restartOnExit && restarts > 0 && time.Since(startedAt) >= restartStableDuration {
+						utils.LogDebug(fmt.Sprintf("Process ran for %s; resetting the restart count", time.Since(startedAt).Round(time.Second)))
+						restarts = 0
+					}
+
+					if restartOnExit && !stopping && exitCode != 0 && (maxRestarts == 0 || restarts < maxRestarts) {
+						restarts++
+						delay := utils.ExponentialBackoff(restartBackoff, restarts-1)
+						utils.Log(fmt.Sprintf("Process exited with code %d, restarting in %s (restart %d of %s)", exitCode, delay, restarts, maxRestartsString(maxRestarts)))
+
+						select {
+						case <-stopRequested:
+							utils.LogDebug("Not restarting process; received signal to stop")
+							os.Exit(exitCode)
+						case <-time.After(delay):
+						}
+
+						// the process has exited, so there's nothing for startProcess to terminate
+						c = nil
+						// restart once this goroutine has released the process lock
+						global.WaitGroup.Add(1)
+						go func() {
+							defer global.WaitGroup.Done()
+							watchMutex.Lock()
+							defer watchMutex.Unlock()
+							startProcess()
+						}()
+						return
+					}
+
+					if restartOnExit && !stopping && exitCode != 0 {
+						utils.LogError(fmt.Errorf("Process exited with code %d after %d restarts; giving up", exitCode, restarts))
+					}
+					// the process won't be restarted, so the secrets kept for restarts are no longer needed
+					lastFetchedSecrets = nil
+					if exitCode == 0 {
+						utils.ExitOnWarnings()
+					}
 					os.Exit(exitCode)
 				}
 			}()
@@ -370,13 +836,18 @@ doppler run --mount secrets.json -- cat secrets.json`,
 		if watch {
 			maxAttempts := 10
 			attempt := 0
-			_ = utils.Retry(maxAttempts, time.Second, func() error {
+			_ = utils.Retry(maxAttempts, time.Second, http.RetryBackoff, func() error {
 				attempt = attempt + 1
 
 				statusCode, headers, httpErr := http.WatchSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, watchHandler)
 				if !httpErr.IsNil() {
 					e := httpErr.Unwrap()
 
+					// the CLI was interrupted, so the process is being stopped
+					if errors.Is(e, context.Canceled) {
+						return utils.StopRetryError(e)
+					}
+
 					msg := "Unable to watch for secrets changes"
 					// a 200 is sent as soon as the connection is established, so if it dies after that the status code
 					// will still be 200. we should retry these requests
@@ -520,8 +991,16 @@ func initFallbackDir(cmd *cobra.Command, config models.ScopedOptions, format mod
 	fallbackPath := ""
 	legacyFallbackPath := ""
 	if cmd.Flags().Changed("fallback") {
-		var err error
-		fallbackPath, err = utils.GetFilePath(cmd.Flag("fallback").Value.String())
+		// expand variables ourselves so paths behave consistently regardless of the invoking shell
+		vars := map[string]string{
+			"DOPPLER_PROJECT": config.EnclaveProject.Value,
+			"DOPPLER_CONFIG":  config.EnclaveConfig.Value,
+		}
+		path, err := utils.ExpandPathVariables(cmd.Flag("fallback").Value.String(), vars)
+		if err != nil {
+			utils.HandleError(err, "Unable to parse --fallback flag")
+		}
+		fallbackPath, err = utils.GetFilePath(path)
 		if err != nil {
 			utils.HandleError(err, "Unable to parse --fallback flag")
 		}
@@ -558,6 +1037,48 @@ func initFallbackDir(cmd *cobra.Command, config models.ScopedOptions, format mod
 	return fallbackPath, legacyFallbackPath
 }
 
+// maxRestartsString describes the --max-restarts limit for logging
+func maxRestartsString(maxRestarts int) string {
+	if maxRestarts == 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(maxRestarts)
+}
+
+// fallbackMaxAgeOptions parses the max age of the fallback file and whether to only warn when it's exceeded
+func fallbackMaxAgeOptions(cmd *cobra.Command) (time.Duration, bool) {
+	maxAge := utils.GetDurationFlag(cmd, "fallback-max-age")
+	if maxAge < 0 {
+		utils.HandleError(errors.New("--fallback-max-age must not be negative"))
+	}
+
+	staleAction := cmd.Flag("fallback-stale").Value.String()
+	if !utils.Contains(controllers.FallbackStaleActions, staleAction) {
+		utils.HandleError(fmt.Errorf("invalid --fallback-stale value. Valid values are %s", strings.Join(controllers.FallbackStaleActions, ", ")))
+	}
+	if cmd.Flags().Changed("fallback-stale") && maxAge == 0 {
+		utils.LogWarning("--fallback-stale has no effect without --fallback-max-age")
+	}
+
+	return maxAge, staleAction == "warn"
+}
+
+// fallbackFormatOption the format to write the fallback file in, as specified by --fallback-format
+func fallbackFormatOption(cmd *cobra.Command) string {
+	format := cmd.Flag("fallback-format").Value.String()
+	if !utils.Contains(controllers.FallbackFormats, format) {
+		utils.HandleError(fmt.Errorf("invalid --fallback-format value. Valid values are %s", strings.Join(controllers.FallbackFormats, ", ")))
+	}
+	if format == "env" {
+		for _, flag := range []string{"passphrase", "fallback-passphrase"} {
+			if cmd.Flags().Changed(flag) {
+				utils.LogWarning(fmt.Sprintf("--%s has no effect with --fallback-format=env, as the fallback file isn't encrypted", flag))
+			}
+		}
+	}
+	return format
+}
+
 func init() {
 	defaultFallbackDir = filepath.Join(configuration.UserConfigDir, "fallback")
 	controllers.DefaultMetadataDir = defaultFallbackDir
@@ -573,6 +1094,8 @@ func init() {
 		utils.HandleError(err)
 	}
 	runCmd.Flags().String("command", "", "command to execute (e.g. \"echo hi\")")
+	runCmd.Flags().String("chdir", "", "working directory of the command. defaults to the current directory")
+	runCmd.Flags().String("user", "", "run the command as the specified user, in the format 'user[:group]' (e.g. 'app' or '1000:1000'). secrets are fetched as the current user before the command drops privileges. not supported on Windows")
 	// note: requires using "--preserve-env=VALUE", doesn't work with "--preserve-env VALUE"
 	runCmd.Flags().String("preserve-env", "false", "a comma separated list of secrets for which the existing value from the environment, if any, should take precedence over the Doppler secret value. value must be specified with an equals sign (e.g. --preserve-env=\"FOO,BAR\"). specify \"true\" to give precedence to all existing environment values, however this has potential security implications and should be used at your own risk.")
 	// we must specify a default when no value is passed as this flag used to be a boolean
@@ -587,7 +1110,7 @@ func init() {
 	}
 	runCmd.Flags().Duration("dynamic-ttl", 0, "(BETA) dynamic secrets will expire after specified duration, (e.g. '3h', '15m')")
 	// fallback flags
-	runCmd.Flags().String("fallback", "", "path to the fallback file. encrypted secrets are written to this file after each successful fetch. secrets will be read from this file if subsequent connections are unsuccessful.")
+	runCmd.Flags().String("fallback", "", "path to the fallback file. encrypted secrets are written to this file after each successful fetch. secrets will be read from this file if subsequent connections are unsuccessful. '${VAR}' is expanded using DOPPLER_PROJECT, DOPPLER_CONFIG, and the environment (e.g. '${HOME}/.doppler/${DOPPLER_CONFIG}.json')")
 	// TODO rename this to 'fallback-passphrase' in CLI v4 (DPLR-435)
 	runCmd.Flags().String("passphrase", "", "passphrase to use for encrypting the fallback file. the default passphrase is computed using your current configuration.")
 	runCmd.Flags().Bool("no-cache", false, "disable using the fallback file to speed up fetches. the fallback file is only used when the API indicates that it's still current.")
@@ -595,6 +1118,25 @@ func init() {
 	runCmd.Flags().Bool("fallback-readonly", false, "disable modifying the fallback file. secrets can still be read from the file.")
 	runCmd.Flags().Bool("fallback-only", false, "read all secrets directly from the fallback file, without contacting Doppler. secrets will not be updated. (implies --fallback-readonly)")
 	runCmd.Flags().Bool("no-exit-on-write-failure", false, "do not exit if unable to write the fallback file")
+	runCmd.Flags().Bool("no-disk", false, "guarantee secrets are never written to or read from disk by disabling the fallback file. using any fallback flag is an error (implies --no-fallback)")
+	runCmd.Flags().Duration("fallback-max-age", 0, "refuse to use a fallback file whose secrets were fetched longer ago than this duration (e.g. '24h'), as recorded in its metadata file. 0 for no limit")
+	runCmd.Flags().String("fallback-format", "json", fmt.Sprintf("format to write the fallback file in. one of %s. env writes an unencrypted dotenv file that can be read by other tools. either format can be read", controllers.FallbackFormats))
+	runCmd.Flags().String("fallback-stale", "error", fmt.Sprintf("behavior when the fallback file exceeds --fallback-max-age. one of %s", controllers.FallbackStaleActions))
+	runCmd.Flags().String("log-secrets-access", "", "append a JSON line to this file each time the command is started, recording its PID, the command, and the names (never the values) of the secrets it was given")
+	// stop parsing doppler flags at the command, so its flags are passed to it even without '--'
+	runCmd.Flags().SetInterspersed(false)
+	runCmd.Flags().String("procfile", "", "run each process defined in this Procfile (e.g. 'web: npm start') with secrets injected, prefixing each line of output with the process's name")
+	runCmd.Flags().Bool("keep-alive", false, "when using --procfile, keep the other processes running when one exits, rather than stopping them all")
+	runCmd.Flags().Bool("strict-args", false, "require '--' between doppler flags and the command (e.g. doppler run --strict-args -- YOUR_COMMAND)")
+	runCmd.Flags().Bool("secrets-from-stdin", false, "read a JSON object of secrets from stdin and inject them, rather than fetching secrets from Doppler. the fallback file is not used")
+	runCmd.Flags().String("encode", "", fmt.Sprintf("encode the value of each secret before injecting it. this changes the values, so the command must decode them. one of %v", utils.ValueEncodings))
+	runCmd.Flags().Bool("inject-metadata", true, "set DOPPLER_PROJECT and DOPPLER_CONFIG in the command's environment, describing the config the secrets came from. when false, DOPPLER_PROJECT, DOPPLER_CONFIG, and DOPPLER_ENVIRONMENT are removed")
+	runCmd.Flags().Bool("clear-env", false, fmt.Sprintf("start the command with an empty environment, rather than inheriting this process's environment, then add the secrets. only %v and any variables named by --preserve-env are kept", controllers.ClearEnvAllowlist))
+	runCmd.Flags().Bool("tty", false, "run the command in a pseudo-terminal when stdin is a terminal, for interactive programs (e.g. REPLs and shells) that check isatty. stderr is merged into stdout. only supported on Linux")
+	runCmd.Flags().Bool("restart-on-exit", false, "if the command exits with a non-zero code, fetch the latest secrets and restart it, waiting --restart-backoff before the first restart and twice as long before each subsequent one (max 30s)")
+	runCmd.Flags().Int("max-restarts", 5, "max number of consecutive times to restart the command when using --restart-on-exit, after which Doppler exits with the command's exit code. the count and the backoff are reset once the command has run for "+restartStableDuration.String()+". 0 for no limit")
+	runCmd.Flags().Duration("restart-backoff", time.Second, "delay before the first restart when using --restart-on-exit")
+	runCmd.Flags().Bool("strict-refresh", false, "when using --watch or --restart-on-exit, fail if the secrets can't be refetched before restarting the process, rather than restarting it with the secrets last fetched")
 	runCmd.Flags().Bool("forward-signals", forwardSignals, "forward signals to the child process (defaults to false when STDOUT is a TTY)")
 	// secrets mount flags
 	runCmd.Flags().String("mount", "", "write secrets to an ephemeral file, accessible at DOPPLER_CLI_SECRETS_PATH. when enabled, secrets are NOT injected into the environment")
@@ -607,7 +1149,19 @@ func init() {
 	}
 	runCmd.Flags().String("mount-template", "", "template file to use. secrets will be rendered into this template before mount. see 'doppler secrets substitute' for more info.")
 	runCmd.Flags().Int("mount-max-reads", 0, "maximum number of times the mounted secrets file can be read (0 for unlimited)")
+	// secrets fd flags
+	runCmd.Flags().Int("fd", 0, "pass secrets to the command on this inherited file descriptor (e.g. 3), accessible at "+controllers.SecretsFDEnvVar+". must be between 3 and "+strconv.Itoa(controllers.MaxSecretsFD)+". when enabled, secrets are NOT injected into the environment")
+	runCmd.Flags().String("fd-format", models.JSONMountFormat, fmt.Sprintf("format of the secrets passed via --fd. one of %v", fdFormats))
+	err = runCmd.RegisterFlagCompletionFunc("fd-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return fdFormats, cobra.ShellCompDirectiveDefault
+	})
+	if err != nil {
+		utils.HandleError(err)
+	}
 	runCmd.Flags().StringSliceVar(&secretsToInclude, "only-secrets", []string{}, "only include the specified secrets")
+	runCmd.Flags().StringArray("tag", []string{}, "only include secrets with this tag. may be specified multiple times to include secrets with any of the tags. tags are resolved when the command starts")
+	runCmd.Flags().StringArray("env-file", []string{}, "merge the KEY=value lines of this dotenv file into the secrets, taking precedence over Doppler's values (e.g. for local overrides). may be specified multiple times, with later files taking precedence")
+	runCmd.Flags().StringArray("except", []string{}, "exclude this secret, matched exactly by name. may be specified multiple times. applied after --only-secrets and --tag")
 	runCmd.Flags().Bool("no-exit-on-missing-only-secrets", false, "do not exit on missing secrets via --only-secrets")
 	// we only restart the process if it hasn't already exited
 	runCmd.Flags().Bool("watch", false, "(BETA) automatically restart the process when secrets change")
@@ -621,6 +1175,11 @@ func init() {
 		utils.HandleError(err)
 	}
 
+	// flags intended for the command are parsed as doppler flags when '--' is omitted
+	runCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return fmt.Errorf("%w\nIf this flag is intended for your command, use '--' to separate doppler flags from your command (e.g. doppler run -- YOUR_COMMAND --YOUR-FLAG)", err)
+	})
+
 	rootCmd.AddCommand(runCmd)
 
 	runCleanCmd.Flags().Duration("max-age", defaultFallbackFileMaxAge, "delete fallback files that exceed this age")
@@ -23,7 +23,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/DopplerHQ/cli/pkg/configuration"
 	"github.com/DopplerHQ/cli/pkg/controllers"
@@ -33,6 +36,7 @@ import (
 	"github.com/DopplerHQ/cli/pkg/printer"
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 type secretsResponse struct {
@@ -53,12 +57,28 @@ var secretsGetCmd = &cobra.Command{
 	Long: `Get the value of one or more secrets.
 
 Ex: output the secrets "API_KEY" and "CRYPTO_KEY":
-doppler secrets get API_KEY CRYPTO_KEY`,
+doppler secrets get API_KEY CRYPTO_KEY
+
+Ex: write the secret "TLS_KEY" to a file readable only by the current user:
+doppler secrets get TLS_KEY --output /etc/ssl/key.pem --mode 0600 --mkdir`,
 	Args:              cobra.MinimumNArgs(1),
 	ValidArgsFunction: secretNamesValidArgs,
 	Run:               getSecrets,
 }
 
+var secretsResolveCmd = &cobra.Command{
+	Use:   "resolve [secret]",
+	Short: "Trace how a secret's value resolves",
+	Long: `Trace how a secret's value resolves, following each secret reference (e.g. '${API_HOST}',
+'${stg.API_HOST}', or '${project.stg.API_HOST}') through the configs it refers to.
+
+Ex: trace the secret "DATABASE_URL" in the dev config:
+doppler secrets resolve DATABASE_URL --config dev`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: secretNamesValidArgs,
+	Run:               resolveSecret,
+}
+
 var secretsSetCmd = &cobra.Command{
 	Use:   "set [secrets]",
 	Short: "Set the value of one or more secrets",
@@ -80,15 +100,168 @@ value
 $ doppler secrets set API_KEY '123'
 
 4) multiple secrets
-$ doppler secrets set API_KEY='123' DATABASE_URL='postgres:random@127.0.0.1:5432'`,
+$ doppler secrets set API_KEY='123' DATABASE_URL='postgres:random@127.0.0.1:5432'
+
+5) from an environment variable
+$ doppler secrets set DB_PASS=env:CI_DB_PASS
+
+Values prefixed with 'env:' are read from the named environment variable, which must be set.
+To set a literal value beginning with 'env:', escape it with a backslash (e.g. '\env:foo').
+
+Secret references:
+Values containing '${NAME}' are stored as references to other secrets. The stored (raw) value
+keeps the reference, while the computed value resolves it:
+$ doppler secrets set HOST=example.com URL='https://${HOST}/api'
+$ doppler secrets get URL --plain        # https://example.com/api
+$ doppler secrets get URL --plain --raw  # https://${HOST}/api
+
+Be sure to single-quote values containing references so your shell doesn't expand them.
+Use --no-references to fail rather than create a reference.
+
+Tags:
+Use --tag to assign tags to the secrets, replacing any tags they already have:
+$ doppler secrets set DB_HOST=localhost DB_PORT=5432 --tag db --tag prod
+
+Concurrent changes:
+Use --if-match with the version printed by 'doppler secrets etag' to fail if the config has
+changed since it was read, rather than overwriting someone else's change:
+$ ETAG=$(doppler secrets etag)
+$ doppler secrets set API_KEY='123' --if-match "$ETAG"
+
+In interactive mode, the config's version is captured before prompting for the value.
+Use --force to skip this check.`,
 	Args: cobra.MinimumNArgs(1),
 	Run:  setSecrets,
 }
 
+var secretsETagCmd = &cobra.Command{
+	Use:   "etag",
+	Short: "Print the current version of a config's secrets",
+	Long: `Print the current version (ETag) of a config's secrets.
+
+The version changes whenever the config is modified. Pass it to 'doppler secrets set --if-match'
+to only apply changes if the config hasn't changed since it was read.`,
+	Args: cobra.NoArgs,
+	Run:  secretsETag,
+}
+
+var secretsCASCmd = &cobra.Command{
+	Use:   "cas <secret>",
+	Short: "Set a secret only if it has the expected value",
+	Long: `Atomically set a secret only if its current (raw) value equals --expect.
+
+Use --expect-missing instead of --expect to only create the secret if it doesn't exist.
+If the secret doesn't have the expected value, or the config changes before the new value
+is written, nothing is changed and the command exits with code 2. Other failures exit
+with code 1, so scripts can retry on a failed precondition.
+
+Ex: claim leadership if no other node holds it:
+doppler secrets cas LEADER --expect-missing --set node-1
+
+Ex: hand leadership from node-1 to node-2:
+doppler secrets cas LEADER --expect node-1 --set node-2`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: secretNamesValidArgs,
+	Run:               compareAndSetSecret,
+}
+
+// casPreconditionFailedExitCode the exit code of 'secrets cas' when the secret doesn't have the expected value
+const casPreconditionFailedExitCode = 2
+
+var secretsSetFileCmd = &cobra.Command{
+	Use:   "set-file <secret> <path>",
+	Short: "Set a secret's value to the contents of a file",
+	Long: `Set a secret's value to the contents of a file, stored inline (e.g. a TLS certificate or keystore).
+
+Binary files must be stored base64 encoded via --base64. Files larger than --max-size bytes
+are rejected, to avoid accidentally uploading large files.
+
+Use 'doppler secrets get --output' to write the value back to a file.
+
+Ex: round-trip a certificate:
+doppler secrets set-file TLS_CERT ./cert.pem
+doppler secrets get TLS_CERT --output ./cert.pem
+
+Ex: round-trip a binary keystore:
+doppler secrets set-file KEYSTORE ./keystore.p12 --base64
+doppler secrets get KEYSTORE --plain | base64 --decode > ./keystore.p12`,
+	Args: cobra.ExactArgs(2),
+	Run:  setSecretFromFile,
+}
+
+// defaultSecretFileMaxSize the default max size, in bytes, of a file set via 'secrets set-file'
+const defaultSecretFileMaxSize = 64 * 1024
+
+var secretsReplaceCmd = &cobra.Command{
+	Use:   "replace",
+	Short: "Find and replace text in the values of a config's secrets",
+	Long: `Find and replace text in the raw values of a config's secrets (e.g. to rotate an embedded hostname).
+
+By default, this is a dry run that prints each affected secret's value before and after the replacement,
+masking everything but the matched and replaced text. Use --apply to set all changed secrets in a single
+request, which fails if the config changes after its secrets are read.
+
+With --regex, --find is a regular expression and the replacement may reference its capture groups (e.g. '$1').
+
+Ex: preview rotating a hostname:
+doppler secrets replace --find old.example.com --replace new.example.com
+
+Ex: apply it:
+doppler secrets replace --find old.example.com --replace new.example.com --apply`,
+	Args: cobra.NoArgs,
+	Run:  replaceSecretValues,
+}
+
+var secretsHistoryCmd = &cobra.Command{
+	Use:   "history <secret>",
+	Short: "View the change history of a secret",
+	Long: `View the change history of a secret.
+
+The history is reconstructed from the config's audit logs, showing who changed the secret and when.
+
+Ex: view the history of the secret "API_KEY":
+doppler secrets history API_KEY`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: secretNamesValidArgs,
+	Run:               secretHistory,
+}
+
+var secretsHashCmd = &cobra.Command{
+	Use:   "hash",
+	Short: "Print a hash of a config's secrets",
+	Long: `Print a stable SHA-256 hash of a config's secret names and values.
+
+The hash changes whenever a secret is added, removed, or modified, allowing you to detect
+changes between runs without storing secret values. Configs with identical secrets produce
+the same hash.
+
+Ex: only redeploy when secrets have changed:
+[ "$(doppler secrets hash)" != "$(cat .secrets-hash)" ] && ./deploy.sh`,
+	Args: cobra.NoArgs,
+	Run:  hashSecrets,
+}
+
+var secretsDiffCmd = &cobra.Command{
+	Use:   "diff <config> <config>",
+	Short: "Compare the secrets of two configs",
+	Long: `Compare the secrets of two configs in the same project, listing the secrets that are only set in
+one config and the secrets whose raw values differ. Secrets whose values are restricted in both configs
+can't be compared, and are listed with the status "unknown".
+
+Ex: review the differences before promoting staging to production:
+doppler secrets diff stg prd --names-only`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: configNamesValidArgs,
+	Run:               diffSecrets,
+}
+
 var secretsUploadCmd = &cobra.Command{
 	Use:   "upload <filepath>",
 	Short: "Upload a secrets file",
-	Long: `Upload a json or env secrets file.
+	Long: `Upload a json, yaml, or env secrets file.
+
+Non-string values in json and yaml files (e.g. numbers and booleans) are stored as strings,
+and a warning lists each converted secret. Use --strict to reject such files instead.
 
 Ex: upload an env file:
 doppler secrets upload dev.env
@@ -125,7 +298,28 @@ Save your secrets to /root/ encrypted in Env format
 $ doppler secrets download --format=env /root/secrets.env
 
 Print your secrets to stdout in env format without writing to the filesystem
-$ doppler secrets download --format=env --no-file`,
+$ doppler secrets download --format=env --no-file
+
+Save your secrets in multiple formats
+$ doppler secrets download --format env --output .env --format json --output secrets.json
+
+Print both the raw and computed value of each secret
+$ doppler secrets download --both --no-file
+
+Generate cloud-init user data that writes your secrets to an env file when an instance boots
+$ doppler secrets download --format=cloudinit --cloudinit-path /etc/myapp/secrets.env --no-file > user-data.yaml
+
+Write your secrets to a file for systemd's EnvironmentFile= directive
+$ doppler secrets download --format=systemd --no-file > /etc/myapp/secrets.env
+
+Save your secrets as XML for apps that read XML config
+$ doppler secrets download --format=xml --no-file > secrets.xml
+
+Print your secrets with base64 encoded values (e.g. for a Kubernetes Secret's data)
+$ doppler secrets download --format=yaml --encode base64 --no-file
+
+Print your secrets as shell commands that export them when sourced
+$ doppler secrets download --format=env --line-prefix 'export ' --no-file`,
 	Args: cobra.MaximumNArgs(1),
 	Run:  downloadSecrets,
 }
@@ -156,22 +350,101 @@ JSON Secret: "{\"logging\": \"info\"}"`,
 	Run:  substituteSecrets,
 }
 
+var secretsPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Pull secrets into a local dotenv file",
+	Long: `Pull your config's secrets into a local dotenv file.
+
+With --merge, an existing file is updated in place. Only the block of Doppler-managed secrets is rewritten, preserving comments and local-only keys outside of it.`,
+	Example: `Write your secrets to a new .env file
+$ doppler secrets pull
+
+Update the Doppler-managed secrets in .env.local, keeping your local overrides
+$ doppler secrets pull --into .env.local --merge`,
+	Args: cobra.NoArgs,
+	Run:  pullSecrets,
+}
+
 func secrets(cmd *cobra.Command, args []string) {
 	jsonFlag := utils.OutputJSON
 	raw := utils.GetBoolFlag(cmd, "raw")
 	visibility := utils.GetBoolFlag(cmd, "visibility")
 	onlyNames := utils.GetBoolFlag(cmd, "only-names")
+	smartMask := utils.GetBoolFlag(cmd, "smart-mask")
+	referencesOnly := utils.GetBoolFlag(cmd, "references-only")
+	localOnly := utils.GetBoolFlag(cmd, "local-only")
+	showAge := utils.GetBoolFlag(cmd, "show-age")
+	sortBy := cmd.Flag("sort").Value.String()
 	localConfig := configuration.LocalConfig(cmd)
 
 	utils.RequireValue("token", localConfig.Token.Value)
 
-	if onlyNames {
+	if !utils.Contains(secretsSortOrders, sortBy) {
+		utils.HandleError(fmt.Errorf("invalid sort order. Valid orders are %v", secretsSortOrders))
+	}
+	if onlyNames && (showAge || sortBy == "age") {
+		utils.HandleError(errors.New("--only-names cannot be used with --show-age or --sort age"))
+	}
+	if jsonFlag && sortBy == "age" {
+		utils.LogWarning("--sort has no effect when used with --json")
+	}
+
+	if referencesOnly {
+		if onlyNames {
+			utils.HandleError(errors.New("--references-only cannot be used with --only-names"))
+		}
+		// the raw reference is shown alongside its computed value
+		raw = true
+	}
+	if localOnly && onlyNames {
+		utils.HandleError(errors.New("--local-only cannot be used with --only-names"))
+	}
+
+	tags, e := cmd.Flags().GetStringArray("tag")
+	if e != nil {
+		utils.HandleError(e)
+	}
+
+	if len(tags) > 0 {
+		secrets, err := controllers.GetSecretsByTags(localConfig, tags)
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
+
+		if referencesOnly {
+			secrets = controllers.FilterReferencingSecrets(secrets)
+		}
+		if localOnly {
+			var err controllers.Error
+			if secrets, err = controllers.FilterLocalSecrets(secrets); !err.IsNil() {
+				utils.HandleError(err.Unwrap(), err.Message)
+			}
+		}
+
+		if onlyNames {
+			var secretNames []string
+			for name := range secrets {
+				secretNames = append(secretNames, name)
+			}
+			sort.Strings(secretNames)
+			if !logNoSecretsFound(localConfig, len(secretNames), jsonFlag) {
+				printer.SecretsNames(secretNames, jsonFlag)
+			}
+		} else if !logNoSecretsFound(localConfig, len(secrets), jsonFlag) {
+			if smartMask {
+				secrets = smartMaskSecrets(secrets)
+			}
+			printListedSecrets(localConfig, secrets, jsonFlag, raw, visibility, showAge, sortBy)
+		}
+	} else if onlyNames {
 		secretNames, err := http.GetSecretNames(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, false)
 		if !err.IsNil() {
 			utils.HandleError(err.Unwrap(), err.Message)
 		}
 
-		printer.SecretsNames(secretNames, jsonFlag)
+		if !logNoSecretsFound(localConfig, len(secretNames), jsonFlag) {
+			printer.SecretsNames(secretNames, jsonFlag)
+		}
 	} else {
 		response, err := http.GetSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, nil, false, 0)
 		if !err.IsNil() {
@@ -182,8 +455,82 @@ func secrets(cmd *cobra.Command, args []string) {
 			utils.HandleError(parseErr, "Unable to parse API response")
 		}
 
+		if referencesOnly {
+			secrets = controllers.FilterReferencingSecrets(secrets)
+		}
+		if localOnly {
+			var err controllers.Error
+			if secrets, err = controllers.FilterLocalSecrets(secrets); !err.IsNil() {
+				utils.HandleError(err.Unwrap(), err.Message)
+			}
+		}
+
+		if !logNoSecretsFound(localConfig, len(secrets), jsonFlag) {
+			if smartMask {
+				secrets = smartMaskSecrets(secrets)
+			}
+			printListedSecrets(localConfig, secrets, jsonFlag, raw, visibility, showAge, sortBy)
+		}
+	}
+}
+
+// secretsSortOrders the orders 'doppler secrets' can list secrets in
+var secretsSortOrders = []string{"name", "age"}
+
+// printListedSecrets prints the secrets listed by 'doppler secrets', first determining when each last changed if needed
+func printListedSecrets(localConfig models.ScopedOptions, secrets map[string]models.ComputedSecret, jsonFlag bool, raw bool, visibility bool, showAge bool, sortBy string) {
+	if !showAge && sortBy != "age" {
 		printer.Secrets(secrets, []string{}, jsonFlag, false, raw, false, visibility)
+		return
+	}
+
+	if err := controllers.FillSecretAges(localConfig, secrets); !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message, "Unable to determine when secrets last changed")
+	}
+
+	names := []string{}
+	if sortBy == "age" {
+		names = controllers.SortSecretNamesByAge(secrets)
 	}
+	if showAge {
+		printer.SecretsWithAge(secrets, names, jsonFlag, raw, visibility)
+	} else {
+		printer.Secrets(secrets, names, jsonFlag, false, raw, false, visibility)
+	}
+}
+
+// smartMaskSecrets returns a copy of the secrets with each value replaced by a description that doesn't reveal it
+func smartMaskSecrets(secrets map[string]models.ComputedSecret) map[string]models.ComputedSecret {
+	masked := map[string]models.ComputedSecret{}
+	for name, secret := range secrets {
+		if secret.RawValue != nil {
+			value := utils.SmartMaskValue(*secret.RawValue)
+			secret.RawValue = &value
+		}
+		if secret.ComputedValue != nil {
+			value := utils.SmartMaskValue(*secret.ComputedValue)
+			secret.ComputedValue = &value
+		}
+		masked[name] = secret
+	}
+	return masked
+}
+
+// logNoSecretsFound logs a message to stderr in place of an empty table, returning whether it did so.
+// JSON output is always printed so that scripts receive '{}'
+func logNoSecretsFound(localConfig models.ScopedOptions, count int, jsonFlag bool) bool {
+	if count > 0 || jsonFlag {
+		return false
+	}
+
+	project := localConfig.EnclaveProject.Value
+	config := localConfig.EnclaveConfig.Value
+	if project != "" && config != "" {
+		utils.Log(fmt.Sprintf("No secrets found for %s/%s", project, config))
+	} else {
+		utils.Log("No secrets found")
+	}
+	return true
 }
 
 func getSecrets(cmd *cobra.Command, args []string) {
@@ -193,12 +540,42 @@ func getSecrets(cmd *cobra.Command, args []string) {
 	raw := utils.GetBoolFlag(cmd, "raw")
 	visibility := utils.GetBoolFlag(cmd, "visibility")
 	exitOnMissingSecret := !utils.GetBoolFlag(cmd, "no-exit-on-missing-secret")
+	output := cmd.Flag("output").Value.String()
+	smartMask := utils.GetBoolFlag(cmd, "smart-mask")
+	ignoreCase := utils.GetBoolFlag(cmd, "ignore-case")
 	localConfig := configuration.LocalConfig(cmd)
 
 	utils.RequireValue("token", localConfig.Token.Value)
 
+	if smartMask && (copy || output != "") {
+		utils.HandleError(errors.New("--smart-mask cannot be used with --copy or --output"))
+	}
+
+	if output != "" && len(args) != 1 {
+		utils.HandleError(errors.New("--output requires exactly one secret"))
+	}
+
+	var outputPath string
+	var outputMode os.FileMode
+	if output != "" && output != "-" {
+		var e error
+		if outputPath, e = utils.GetFilePath(output); e != nil {
+			utils.HandleError(e, "Unable to parse output file path")
+		}
+		if outputMode, e = utils.ParseFileMode(cmd.Flag("mode").Value.String()); e != nil {
+			utils.HandleError(e)
+		}
+	} else {
+		for _, flag := range []string{"mode", "mkdir"} {
+			if cmd.Flags().Changed(flag) {
+				utils.LogWarning(fmt.Sprintf("--%s has no effect unless --output is a file", flag))
+			}
+		}
+	}
+
 	var requestedSecrets []string
-	if len(args) > 0 {
+	// matching names case-insensitively requires every name, so all secrets are fetched
+	if len(args) > 0 && !ignoreCase {
 		requestedSecrets = args
 	}
 	response, err := http.GetSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, requestedSecrets, false, 0)
@@ -210,11 +587,28 @@ func getSecrets(cmd *cobra.Command, args []string) {
 		utils.HandleError(parseErr, "Unable to parse API response")
 	}
 
+	if ignoreCase {
+		var names []string
+		for name := range secrets {
+			names = append(names, name)
+		}
+
+		var resolved []string
+		for _, arg := range args {
+			name, err := controllers.ResolveSecretNameIgnoreCase(arg, names)
+			if !err.IsNil() {
+				utils.HandleError(err.Unwrap(), err.Message)
+			}
+			resolved = append(resolved, name)
+		}
+		args = resolved
+	}
+
 	if exitOnMissingSecret && len(args) > 0 {
 		var missingSecrets []string
 
 		for _, name := range args {
-			if secrets[name] == (models.ComputedSecret{}) {
+			if _, ok := secrets[name]; !ok {
 				missingSecrets = append(missingSecrets, name)
 			}
 		}
@@ -224,21 +618,97 @@ func getSecrets(cmd *cobra.Command, args []string) {
 			if len(missingSecrets) == 1 {
 				pluralized = "secret"
 			}
-			utils.HandleError(fmt.Errorf("Could not find requested %s: %s", pluralized, strings.Join(missingSecrets, ", ")))
+			utils.HandleError(fmt.Errorf("Could not find requested %s: %s", pluralized, strings.Join(suggestSecretNames(localConfig, missingSecrets), ", ")))
+		}
+	}
+
+	if output == "-" {
+		value, err := controllers.SecretValue(secrets, args[0], raw)
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
+		if _, e := os.Stdout.WriteString(value); e != nil {
+			utils.HandleError(e, "Unable to write the secret to stdout")
+		}
+		return
+	}
+
+	if outputPath != "" {
+		err := controllers.WriteSecretValue(secrets, args[0], raw, outputPath, outputMode, utils.GetBoolFlag(cmd, "mkdir"))
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
 		}
+		utils.Print(fmt.Sprintf("Wrote secret %s to %s", args[0], outputPath))
+		return
 	}
 
+	if smartMask {
+		secrets = smartMaskSecrets(secrets)
+	}
 	printer.Secrets(secrets, args, jsonFlag, plain, raw, copy, visibility)
 }
 
+// maxSuggestionDistance the max number of edits between a missing secret's name and a suggested name
+const maxSuggestionDistance = 2
+
+// suggestSecretNames annotates each missing secret with the existing secret with the most similar name, if any
+// (e.g. 'DATABSE_URL (did you mean DATABASE_URL?)')
+func suggestSecretNames(localConfig models.ScopedOptions, missingSecrets []string) []string {
+	names, err := http.GetSecretNames(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, false)
+	if !err.IsNil() {
+		utils.LogDebugError(err.Unwrap())
+		return missingSecrets
+	}
+
+	var annotated []string
+	for _, missing := range missingSecrets {
+		if match, ok := utils.ClosestMatch(missing, names, maxSuggestionDistance); ok {
+			annotated = append(annotated, fmt.Sprintf("%s (did you mean %s?)", missing, match))
+		} else {
+			annotated = append(annotated, missing)
+		}
+	}
+	return annotated
+}
+
+// requireUnlockedConfig refuses to modify the config's secrets if it's locked, unless --force is specified.
+// This only applies when the protect-locked-configs flag is enabled, as a Doppler lock otherwise only prevents
+// renaming and deleting a config
+func requireUnlockedConfig(cmd *cobra.Command, localConfig models.ScopedOptions) {
+	if !configuration.GetFlag(models.FlagProtectLockedConfigs) || utils.GetBoolFlag(cmd, "force") {
+		return
+	}
+
+	if err := controllers.CheckConfigUnlocked(localConfig); !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message, "Use --force to modify it anyway")
+	}
+}
+
 func setSecrets(cmd *cobra.Command, args []string) {
 	jsonFlag := utils.OutputJSON
 	raw := utils.GetBoolFlag(cmd, "raw")
 	canPromptUser := !utils.GetBoolFlag(cmd, "no-interactive")
+	batchSize := utils.GetIntFlag(cmd, "batch-size", 16)
+	noReferences := utils.GetBoolFlag(cmd, "no-references")
+	ifMatch := cmd.Flag("if-match").Value.String()
+	force := utils.GetBoolFlag(cmd, "force")
 	localConfig := configuration.LocalConfig(cmd)
 
+	tags, e := cmd.Flags().GetStringArray("tag")
+	if e != nil {
+		utils.HandleError(e)
+	}
+
 	utils.RequireValue("token", localConfig.Token.Value)
 
+	if batchSize < 1 {
+		utils.HandleError(errors.New("--batch-size must be greater than 0"))
+	}
+	if force && ifMatch != "" {
+		utils.HandleError(errors.New("--if-match cannot be used with --force"))
+	}
+	requireUnlockedConfig(cmd, localConfig)
+
 	secrets := map[string]interface{}{}
 	var keys []string
 
@@ -259,6 +729,16 @@ func setSecrets(cmd *cobra.Command, args []string) {
 				utils.HandleError(errors.New("Secret value must be provided when using --no-interactive"))
 			}
 
+			// capture the config's version so changes made while the user is typing aren't overwritten. this is best
+			// effort: if the version can't be determined, the secret is set without checking for concurrent changes
+			if !force && ifMatch == "" {
+				etag, err := controllers.GetSecretsETag(localConfig)
+				if !err.IsNil() {
+					utils.LogDebug(fmt.Sprintf("Unable to determine config version, concurrent changes won't be detected: %s", err.Unwrap()))
+				}
+				ifMatch = etag
+			}
+
 			utils.Print("Enter your secret value")
 			utils.Print("When finished, type a newline followed by a period")
 			utils.Print("Run 'doppler secrets set --help' for more information")
@@ -311,7 +791,10 @@ func setSecrets(cmd *cobra.Command, args []string) {
 	} else if len(args) == 2 && !strings.Contains(args[0], "=") {
 		// format: 'doppler secrets set KEY value'
 		key := args[0]
-		value := args[1]
+		value, e := controllers.ResolveSecretValue(args[1])
+		if e != nil {
+			utils.HandleError(e)
+		}
 		keys = append(keys, key)
 		secrets[key] = value
 	} else {
@@ -322,115 +805,491 @@ func setSecrets(cmd *cobra.Command, args []string) {
 			if len(secretArr) < 2 {
 				secrets[secretArr[0]] = ""
 			} else {
-				secrets[secretArr[0]] = secretArr[1]
+				value, e := controllers.ResolveSecretValue(secretArr[1])
+				if e != nil {
+					utils.HandleError(e)
+				}
+				secrets[secretArr[0]] = value
+			}
+		}
+	}
+
+	if noReferences {
+		var invalid []string
+		for _, key := range keys {
+			if value, ok := secrets[key].(string); ok {
+				if references := controllers.SecretReferences(value); len(references) > 0 {
+					invalid = append(invalid, fmt.Sprintf("%s (%s)", key, strings.Join(references, ", ")))
+				}
 			}
 		}
+		if len(invalid) > 0 {
+			utils.HandleError(fmt.Errorf("the following secrets contain references, which are not allowed when using --no-references:\n- %s", strings.Join(invalid, "\n- ")))
+		}
 	}
 
-	response, err := http.SetSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, secrets, nil)
+	var response map[string]models.ComputedSecret
+	var err controllers.Error
+	if len(tags) > 0 {
+		response, err = controllers.SetSecretsWithTags(localConfig, secrets, tags, batchSize, ifMatch)
+	} else {
+		response, err = controllers.SetSecretsInBatches(localConfig, secrets, batchSize, ifMatch)
+	}
 	if !err.IsNil() {
+		if errors.Is(err.Unwrap(), controllers.ErrConfigChanged) {
+			utils.HandleError(err.Unwrap(), err.Message, "Review the config's current secrets, or use --force to overwrite them")
+		}
 		utils.HandleError(err.Unwrap(), err.Message)
 	}
 
+	for _, name := range controllers.ReferencedSecrets(secrets, response) {
+		utils.LogDebug(fmt.Sprintf("Secret %s was stored as a reference; its computed value differs from its raw value", name))
+	}
+
 	if !utils.Silent {
 		printer.Secrets(response, keys, jsonFlag, false, raw, false, false)
 	}
 }
 
-func uploadSecrets(cmd *cobra.Command, args []string) {
+func compareAndSetSecret(cmd *cobra.Command, args []string) {
 	jsonFlag := utils.OutputJSON
 	raw := utils.GetBoolFlag(cmd, "raw")
+	expectMissing := utils.GetBoolFlag(cmd, "expect-missing")
 	localConfig := configuration.LocalConfig(cmd)
+	name := args[0]
 
 	utils.RequireValue("token", localConfig.Token.Value)
 
-	filePath, err := utils.GetFilePath(args[0])
-	if err != nil {
-		utils.HandleError(err, "Unable to parse upload file path")
+	if !cmd.Flags().Changed("set") {
+		utils.HandleError(errors.New("you must specify the new value with --set"))
 	}
+	value := cmd.Flag("set").Value.String()
 
-	if !utils.Exists(filePath) {
-		utils.HandleError(errors.New("Upload file does not exist"))
+	var expected *string
+	if cmd.Flags().Changed("expect") {
+		if expectMissing {
+			utils.HandleError(errors.New("--expect cannot be used with --expect-missing"))
+		}
+		expectedValue := cmd.Flag("expect").Value.String()
+		expected = &expectedValue
+	} else if !expectMissing {
+		utils.HandleError(errors.New("you must specify the current value with --expect, or use --expect-missing"))
 	}
 
-	var file []byte
-	file, err = ioutil.ReadFile(filePath) // #nosec G304
-	if err != nil {
-		utils.HandleError(err, "Unable to read upload file")
-	}
+	requireUnlockedConfig(cmd, localConfig)
 
-	response, httpErr := http.UploadSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, string(file))
-	if !httpErr.IsNil() {
-		utils.HandleError(httpErr.Unwrap(), httpErr.Message)
+	response, err := controllers.CompareAndSetSecret(localConfig, name, expected, value)
+	if !err.IsNil() {
+		if errors.Is(err.Unwrap(), controllers.ErrUnexpectedSecretValue) || errors.Is(err.Unwrap(), controllers.ErrConfigChanged) {
+			utils.ErrExit(err.Unwrap(), casPreconditionFailedExitCode, err.Message)
+		}
+		utils.HandleError(err.Unwrap(), err.Message)
 	}
 
 	if !utils.Silent {
-		printer.Secrets(response, []string{}, jsonFlag, false, raw, false, false)
+		printer.Secrets(response, []string{name}, jsonFlag, false, raw, false, false)
 	}
 }
 
-func deleteSecrets(cmd *cobra.Command, args []string) {
+func setSecretFromFile(cmd *cobra.Command, args []string) {
 	jsonFlag := utils.OutputJSON
 	raw := utils.GetBoolFlag(cmd, "raw")
-	yes := utils.GetBoolFlag(cmd, "yes")
+	base64Encode := utils.GetBoolFlag(cmd, "base64")
 	localConfig := configuration.LocalConfig(cmd)
+	name := args[0]
 
 	utils.RequireValue("token", localConfig.Token.Value)
 
-	if yes || utils.ConfirmationPrompt("Delete secret(s)", false) {
-		secrets := map[string]interface{}{}
-		for _, arg := range args {
-			secrets[arg] = nil
-		}
+	maxSize, e := cmd.Flags().GetInt64("max-size")
+	if e != nil {
+		utils.HandleError(e)
+	}
+	if maxSize < 1 {
+		utils.HandleError(errors.New("--max-size must be greater than 0"))
+	}
 
-		response, err := http.SetSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, secrets, nil)
-		if !err.IsNil() {
-			utils.HandleError(err.Unwrap(), err.Message)
-		}
+	path, e := utils.GetFilePath(args[1])
+	if e != nil {
+		utils.HandleError(e, "Unable to parse file path")
+	}
 
-		if !utils.Silent {
-			printer.Secrets(response, []string{}, jsonFlag, false, raw, false, false)
-		}
+	value, err := controllers.ReadSecretFile(path, maxSize, base64Encode)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	requireUnlockedConfig(cmd, localConfig)
+
+	response, err := controllers.SetSecretsInBatches(localConfig, map[string]interface{}{name: value}, 1, "")
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	if jsonFlag {
+		printer.Secrets(response, []string{name}, jsonFlag, false, raw, false, false)
+	} else {
+		utils.Print(fmt.Sprintf("Set secret %s from %s", name, path))
 	}
 }
 
-func downloadSecrets(cmd *cobra.Command, args []string) {
-	saveFile := !utils.GetBoolFlag(cmd, "no-file")
+func replaceSecretValues(cmd *cobra.Command, args []string) {
 	jsonFlag := utils.OutputJSON
+	find := cmd.Flag("find").Value.String()
+	replacement := cmd.Flag("replace").Value.String()
+	useRegex := utils.GetBoolFlag(cmd, "regex")
+	apply := utils.GetBoolFlag(cmd, "apply")
 	localConfig := configuration.LocalConfig(cmd)
 
-	enableFallback := !utils.GetBoolFlag(cmd, "no-fallback")
-	enableCache := enableFallback && !utils.GetBoolFlag(cmd, "no-cache")
-	fallbackReadonly := utils.GetBoolFlag(cmd, "fallback-readonly")
-	fallbackOnly := utils.GetBoolFlag(cmd, "fallback-only")
-	exitOnWriteFailure := !utils.GetBoolFlag(cmd, "no-exit-on-write-failure")
-	dynamicSecretsTTL := utils.GetDurationFlag(cmd, "dynamic-ttl")
-
 	utils.RequireValue("token", localConfig.Token.Value)
 
-	formatString := cmd.Flag("format").Value.String()
-	var format models.SecretsFormat
-	if jsonFlag {
-		format = models.JSON
+	if find == "" {
+		utils.HandleError(errors.New("you must specify the text to find with --find"))
+	}
+	if !cmd.Flags().Changed("replace") {
+		utils.HandleError(errors.New("you must specify the replacement with --replace"))
+	}
+	if apply && utils.GetBoolFlag(cmd, "dry-run") {
+		utils.HandleError(errors.New("--apply cannot be used with --dry-run"))
 	}
 
-	if formatString != "" {
-		isValid := false
+	expression := regexp.QuoteMeta(find)
+	if useRegex {
+		expression = find
+	}
+	pattern, e := regexp.Compile(expression)
+	if e != nil {
+		utils.HandleError(e, "Invalid --find regular expression")
+	}
 
-		for _, val := range models.SecretsFormatList {
-			if val.String() == formatString {
-				format = val
-				isValid = true
-				break
-			}
-		}
+	if apply {
+		requireUnlockedConfig(cmd, localConfig)
+	}
 
-		if !isValid {
-			utils.HandleError(fmt.Errorf("invalid format. Valid formats are %s", validFormatList))
+	replacements, err := controllers.ReplaceSecretValues(localConfig, pattern, replacement, !useRegex, apply)
+	if !err.IsNil() {
+		if errors.Is(err.Unwrap(), controllers.ErrConfigChanged) {
+			utils.HandleError(err.Unwrap(), err.Message, "Run the command again to replace values in the config's current secrets")
 		}
+		utils.HandleError(err.Unwrap(), err.Message)
 	}
 
-	nameTransformerString := cmd.Flag("name-transformer").Value.String()
+	if jsonFlag {
+		printer.JSON(map[string]interface{}{"applied": apply, "secrets": replacements})
+		return
+	}
+
+	if len(replacements) == 0 {
+		utils.Print("No secret values matched")
+		return
+	}
+
+	var rows [][]string
+	for _, r := range replacements {
+		rows = append(rows, []string{r.Name, r.MaskedBefore, r.MaskedAfter})
+	}
+	printer.Table([]string{"name", "before", "after"}, rows, printer.TableOptions())
+
+	pluralized := "secrets"
+	if len(replacements) == 1 {
+		pluralized = "secret"
+	}
+	if apply {
+		utils.Print(fmt.Sprintf("Updated %d %s", len(replacements), pluralized))
+	} else {
+		utils.Print(fmt.Sprintf("Dry run: %d %s would be updated. Use --apply to update them", len(replacements), pluralized))
+	}
+}
+
+func secretHistory(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	number := utils.GetIntFlag(cmd, "number", 16)
+	localConfig := configuration.LocalConfig(cmd)
+
+	utils.RequireValue("token", localConfig.Token.Value)
+
+	history, err := controllers.GetSecretHistory(localConfig, args[0], number)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	printer.SecretHistory(history, jsonFlag)
+}
+
+func secretsETag(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	localConfig := configuration.LocalConfig(cmd)
+
+	utils.RequireValue("token", localConfig.Token.Value)
+
+	etag, err := controllers.GetSecretsETag(localConfig)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+	if etag == "" {
+		utils.HandleError(errors.New("the API response did not include an ETag"), "Unable to determine config version")
+	}
+
+	if jsonFlag {
+		printer.JSON(map[string]string{"etag": etag})
+		return
+	}
+	utils.Print(etag)
+}
+
+func hashSecrets(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	raw := utils.GetBoolFlag(cmd, "raw")
+	localConfig := configuration.LocalConfig(cmd)
+
+	utils.RequireValue("token", localConfig.Token.Value)
+
+	secrets, err := controllers.GetSecrets(localConfig)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	hash, count, err := controllers.HashSecrets(secrets, raw)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	printer.SecretsHash(hash, count, jsonFlag)
+}
+
+func diffSecrets(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	namesOnly := utils.GetBoolFlag(cmd, "names-only")
+	localConfig := configuration.LocalConfig(cmd)
+
+	utils.RequireValue("token", localConfig.Token.Value)
+
+	configSecrets := make([]map[string]models.ComputedSecret, len(args))
+	for i, config := range args {
+		scopedConfig := localConfig
+		scopedConfig.EnclaveConfig.Value = config
+
+		secrets, err := controllers.GetSecrets(scopedConfig)
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), fmt.Sprintf("%s (config %s)", err.Message, config))
+		}
+		configSecrets[i] = secrets
+	}
+
+	printer.SecretsDiff(controllers.DiffSecrets(configSecrets[0], configSecrets[1]), args[0], args[1], namesOnly, jsonFlag)
+}
+
+func uploadSecrets(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	raw := utils.GetBoolFlag(cmd, "raw")
+	strict := utils.GetBoolFlag(cmd, "strict")
+	decoding := cmd.Flag("decode").Value.String()
+	localConfig := configuration.LocalConfig(cmd)
+
+	utils.RequireValue("token", localConfig.Token.Value)
+
+	if decoding != "" && !utils.Contains(utils.ValueEncodings, decoding) {
+		utils.HandleError(fmt.Errorf("invalid encoding. Valid encodings are %v", utils.ValueEncodings))
+	}
+
+	filePath, err := utils.GetFilePath(args[0])
+	if err != nil {
+		utils.HandleError(err, "Unable to parse upload file path")
+	}
+
+	if !utils.Exists(filePath) {
+		utils.HandleError(errors.New("Upload file does not exist"))
+	}
+
+	var file []byte
+	file, err = ioutil.ReadFile(filePath) // #nosec G304
+	if err != nil {
+		utils.HandleError(err, "Unable to read upload file")
+	}
+
+	format := ""
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		format = "json"
+	case ".yaml", ".yml":
+		format = "yaml"
+	}
+	if format != "" {
+		secrets, coerced, err := controllers.ParseStructuredSecrets(file, format, strict)
+		if err != nil {
+			utils.HandleError(err, "Unable to parse upload file")
+		}
+
+		if len(coerced) > 0 {
+			var lines []string
+			for _, secret := range coerced {
+				lines = append(lines, fmt.Sprintf("%s: %s (%s → string)", secret.Name, secret.Value, secret.Type))
+			}
+			utils.LogWarning(fmt.Sprintf("The following non-string values will be stored as strings:\n%s", strings.Join(lines, "\n")))
+		}
+
+		if decoding != "" {
+			if secrets, err = utils.DecodeValues(secrets, decoding); err != nil {
+				utils.HandleError(err, "Unable to decode upload file")
+			}
+		}
+
+		if file, err = json.Marshal(secrets); err != nil {
+			utils.HandleError(err, "Unable to encode secrets")
+		}
+	} else {
+		if decoding != "" {
+			// env files are parsed by the API, so their values can't be decoded beforehand
+			utils.HandleError(errors.New("--decode is only supported when uploading a json or yaml file"))
+		}
+		if strict {
+			utils.LogWarning("--strict has no effect when uploading an env file")
+		}
+	}
+
+	requireUnlockedConfig(cmd, localConfig)
+
+	response, httpErr := http.UploadSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, string(file))
+	if !httpErr.IsNil() {
+		utils.HandleError(httpErr.Unwrap(), httpErr.Message)
+	}
+
+	if !utils.Silent {
+		printer.Secrets(response, []string{}, jsonFlag, false, raw, false, false)
+	}
+}
+
+func resolveSecret(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	localConfig := configuration.LocalConfig(cmd)
+
+	utils.RequireValue("token", localConfig.Token.Value)
+
+	fetch := func(project string, config string) (map[string]models.ComputedSecret, controllers.Error) {
+		options := localConfig
+		options.EnclaveProject.Value = project
+		options.EnclaveConfig.Value = config
+		return controllers.GetSecrets(options)
+	}
+
+	steps, err := controllers.TraceSecretResolution(args[0], localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, fetch)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	printer.SecretResolution(steps, jsonFlag)
+}
+
+func deleteSecrets(cmd *cobra.Command, args []string) {
+	jsonFlag := utils.OutputJSON
+	raw := utils.GetBoolFlag(cmd, "raw")
+	yes := utils.GetBoolFlag(cmd, "yes")
+	force := utils.GetBoolFlag(cmd, "force")
+	localConfig := configuration.LocalConfig(cmd)
+
+	utils.RequireValue("token", localConfig.Token.Value)
+	requireUnlockedConfig(cmd, localConfig)
+
+	// refuse to delete secrets that other secrets reference, as doing so silently breaks their values
+	if !force {
+		existingSecrets, err := controllers.GetSecrets(localConfig)
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
+
+		dependents := controllers.DependentSecrets(existingSecrets, args)
+		if len(dependents) > 0 {
+			var names []string
+			for name := range dependents {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			var references []string
+			for _, name := range names {
+				references = append(references, fmt.Sprintf("%s is referenced by %s", name, strings.Join(dependents[name], ", ")))
+			}
+			utils.HandleError(fmt.Errorf("Unable to delete secrets that other secrets reference: %s. Use --force to delete them anyway", strings.Join(references, "; ")))
+		}
+	}
+
+	if yes || utils.ConfirmationPrompt("Delete secret(s)", false) {
+		secrets := map[string]interface{}{}
+		for _, arg := range args {
+			secrets[arg] = nil
+		}
+
+		response, err := http.SetSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, secrets, nil, "")
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
+
+		if !utils.Silent {
+			printer.Secrets(response, []string{}, jsonFlag, false, raw, false, false)
+		}
+	}
+}
+
+func downloadSecrets(cmd *cobra.Command, args []string) {
+	saveFile := !utils.GetBoolFlag(cmd, "no-file")
+	localConfig := configuration.LocalConfig(cmd)
+
+	enableFallback := !utils.GetBoolFlag(cmd, "no-fallback")
+	enableCache := enableFallback && !utils.GetBoolFlag(cmd, "no-cache")
+	fallbackReadonly := utils.GetBoolFlag(cmd, "fallback-readonly")
+	fallbackOnly := utils.GetBoolFlag(cmd, "fallback-only")
+	exitOnWriteFailure := !utils.GetBoolFlag(cmd, "no-exit-on-write-failure")
+	dynamicSecretsTTL := utils.GetDurationFlag(cmd, "dynamic-ttl")
+	both := utils.GetBoolFlag(cmd, "both")
+	values := cmd.Flag("values").Value.String()
+
+	utils.RequireValue("token", localConfig.Token.Value)
+
+	if !utils.Contains(secretValueTypes, values) {
+		utils.HandleError(fmt.Errorf("invalid --values %q. Valid values are %v", values, secretValueTypes))
+	}
+	rawValues := values == "raw"
+	if rawValues {
+		if both {
+			utils.HandleError(errors.New("--values cannot be used with --both"))
+		}
+		// raw values are fetched with their metadata, which doesn't support transforming names server-side
+		if cmd.Flags().Changed("name-transformer") {
+			utils.HandleError(errors.New("--values raw cannot be used with --name-transformer"))
+		}
+	}
+
+	formatStrings, err := cmd.Flags().GetStringArray("format")
+	if err != nil {
+		utils.HandleError(err)
+	}
+	outputs, err := cmd.Flags().GetStringArray("output")
+	if err != nil {
+		utils.HandleError(err)
+	}
+
+	var formats []models.SecretsFormat
+	for _, formatString := range formatStrings {
+		isValid := false
+
+		for _, val := range models.SecretsFormatList {
+			if val.String() == formatString {
+				formats = append(formats, val)
+				isValid = true
+				break
+			}
+		}
+
+		if !isValid {
+			utils.HandleError(fmt.Errorf("invalid format. Valid formats are %s", validFormatList))
+		}
+	}
+
+	format := models.JSON
+	if len(formats) > 0 {
+		format = formats[0]
+	}
+
+	nameTransformerString := cmd.Flag("name-transformer").Value.String()
 	var nameTransformer *models.SecretsNameTransformer
 	if nameTransformerString != "" {
 		nameTransformer = models.SecretsNameTransformerMap[nameTransformerString]
@@ -444,15 +1303,118 @@ func downloadSecrets(cmd *cobra.Command, args []string) {
 		utils.HandleError(errors.New("invalid fallback file passphrase"))
 	}
 
+	encoding := cmd.Flag("encode").Value.String()
+	if encoding != "" && !utils.Contains(utils.ValueEncodings, encoding) {
+		utils.HandleError(fmt.Errorf("invalid encoding. Valid encodings are %v", utils.ValueEncodings))
+	}
+
+	linePrefix := cmd.Flag("line-prefix").Value.String()
+	if linePrefix != "" {
+		if strings.ContainsAny(linePrefix, "\r\n") {
+			utils.HandleError(errors.New("--line-prefix cannot contain a newline"))
+		}
+		// the default format is JSON, which doesn't support a prefix
+		if len(formats) == 0 {
+			formats = []models.SecretsFormat{format}
+		}
+		for _, f := range formats {
+			if !utils.Contains(linePrefixFormats, f) {
+				utils.HandleError(fmt.Errorf("--line-prefix can only be used with formats %v", linePrefixFormats))
+			}
+		}
+	}
+
+	if len(formats) > 1 || len(outputs) > 1 {
+		if both {
+			utils.HandleError(errors.New("--both cannot be used when downloading multiple formats"))
+		}
+		if rawValues {
+			utils.HandleError(errors.New("--values raw cannot be used when downloading multiple formats"))
+		}
+		downloadSecretsToFiles(cmd, args, localConfig, formats, outputs, nameTransformer, dynamicSecretsTTL)
+		return
+	}
+
+	if utils.GetBoolFlag(cmd, "json-array") {
+		if format != models.JSON {
+			utils.HandleError(errors.New("--json-array can only be used with JSON format"))
+		}
+		if both {
+			utils.HandleError(errors.New("--json-array cannot be used with --both"))
+		}
+	}
+
+	if both {
+		if format != models.JSON {
+			utils.HandleError(errors.New("--both can only be used with JSON format"))
+		}
+		if nameTransformer != nil {
+			utils.HandleError(errors.New("--both cannot be used with --name-transformer"))
+		}
+		if encoding != "" {
+			utils.HandleError(errors.New("--both cannot be used with --encode"))
+		}
+	}
+
+	if encoding != "" {
+		warnOnEncodedValues(format, encoding)
+	}
+
 	var body []byte
-	if format == models.JSON {
+	if both {
+		// fallback file is not supported when fetching raw and computed values
+		enableFallback = false
+		enableCache = false
+		flags := []string{"fallback", "fallback-only", "fallback-readonly", "no-exit-on-write-failure", "dynamic-ttl"}
+		for _, flag := range flags {
+			if cmd.Flags().Changed(flag) {
+				utils.LogWarning(fmt.Sprintf("--%s has no effect when used with --both", flag))
+			}
+		}
+
+		secrets, apiError := controllers.GetSecrets(localConfig)
+		if !apiError.IsNil() {
+			utils.HandleError(apiError.Unwrap(), apiError.Message)
+		}
+
+		var err error
+		body, err = json.Marshal(controllers.RawAndComputedSecrets(secrets))
+		if err != nil {
+			utils.HandleError(err, "Unable to parse JSON secrets")
+		}
+	} else if rawValues {
+		// fallback file only contains computed values
+		enableFallback = false
+		enableCache = false
+		flags := []string{"fallback", "fallback-only", "fallback-readonly", "no-exit-on-write-failure", "dynamic-ttl"}
+		for _, flag := range flags {
+			if cmd.Flags().Changed(flag) {
+				utils.LogWarning(fmt.Sprintf("--%s has no effect when used with --values raw", flag))
+			}
+		}
+
+		computedSecrets, apiError := controllers.GetSecrets(localConfig)
+		if !apiError.IsNil() {
+			utils.HandleError(apiError.Unwrap(), apiError.Message)
+		}
+		secrets, err := controllers.RawSecretValues(computedSecrets)
+		if err != nil {
+			utils.HandleError(err, "Unable to download raw values")
+		}
+		if encoding != "" {
+			secrets = encodeSecretValues(secrets, encoding)
+		}
+
+		body = []byte(renderSecrets(cmd, format, secrets, localConfig))
+	} else if format == models.JSON {
 		fallbackPath := ""
 		legacyFallbackPath := ""
 		metadataPath := ""
 		if enableFallback {
 			fallbackPath, legacyFallbackPath = initFallbackDir(cmd, localConfig, format, nameTransformer, nil, exitOnWriteFailure)
 		}
-		if enableCache {
+		// the metadata file is also used to check the fallback file's age
+		if enableCache || enableFallback {
 			metadataPath = controllers.MetadataFilePath(localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, format, nameTransformer, nil)
 		}
 
@@ -465,13 +1427,43 @@ func downloadSecrets(cmd *cobra.Command, args []string) {
 			ExitOnWriteFailure: exitOnWriteFailure,
 			Passphrase:         fallbackPassphrase,
 		}
+		fallbackOpts.MaxAge, fallbackOpts.WarnOnStale = fallbackMaxAgeOptions(cmd)
+		fallbackOpts.Format = fallbackFormatOption(cmd)
 		secrets := controllers.FetchSecrets(localConfig, enableCache, fallbackOpts, metadataPath, nameTransformer, dynamicSecretsTTL, format, nil)
+		if encoding != "" {
+			secrets = encodeSecretValues(secrets, encoding)
+		}
 
 		var err error
-		body, err = json.Marshal(secrets)
+		body, err = json.Marshal(jsonSecrets(cmd, secrets))
 		if err != nil {
 			utils.HandleError(err, "Unable to parse JSON secrets")
 		}
+	} else if format.RenderedLocally() || encoding != "" || linePrefix != "" {
+		// fallback file is not supported when rendering formats locally
+		enableFallback = false
+		enableCache = false
+		flags := []string{"fallback", "fallback-only", "fallback-readonly", "no-exit-on-write-failure"}
+		for _, flag := range flags {
+			if cmd.Flags().Changed(flag) {
+				utils.LogWarning(fmt.Sprintf("--%s has no effect when format is %s", flag, format))
+			}
+		}
+
+		_, _, response, apiError := http.DownloadSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, models.JSON, nameTransformer, "", dynamicSecretsTTL, nil)
+		if !apiError.IsNil() {
+			utils.HandleError(apiError.Unwrap(), apiError.Message)
+		}
+
+		secrets := map[string]string{}
+		if err := json.Unmarshal(response, &secrets); err != nil {
+			utils.HandleError(err, "Unable to parse JSON secrets")
+		}
+		if encoding != "" {
+			secrets = encodeSecretValues(secrets, encoding)
+		}
+
+		body = []byte(renderSecrets(cmd, format, secrets, localConfig))
 	} else {
 		// fallback file is not supported when fetching env/yaml format
 		enableFallback = false
@@ -490,32 +1482,52 @@ func downloadSecrets(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	gpgRecipients, err := cmd.Flags().GetStringArray("gpg-recipient")
+	if err != nil {
+		utils.HandleError(err)
+	}
+
 	if !saveFile {
+		if len(gpgRecipients) > 0 {
+			// binary output isn't suitable for a terminal
+			body = encryptForGPGRecipients(cmd, body, gpgRecipients, true)
+		}
 		utils.Print(string(body))
 		return
 	}
 
 	var filePath string
-	if len(args) > 0 {
+	if len(args) > 0 && len(outputs) > 0 {
+		utils.HandleError(errors.New("the download file path may not be specified as both an argument and via --output"))
+	}
+	if len(args) > 0 || len(outputs) > 0 {
+		path := append(args, outputs...)[0]
 		var err error
-		filePath, err = utils.GetFilePath(args[0])
+		filePath, err = utils.GetFilePath(path)
 		if err != nil {
 			utils.HandleError(err, "Unable to parse download file path")
 		}
+	} else if len(gpgRecipients) > 0 {
+		filePath = filepath.Join(".", format.OutputFile()+gpgFileExtension(cmd))
 	} else {
 		filePath = filepath.Join(".", format.OutputFile())
 	}
 
-	utils.LogDebug("Encrypting secrets")
+	var encryptedBody string
+	if len(gpgRecipients) > 0 {
+		encryptedBody = string(encryptForGPGRecipients(cmd, body, gpgRecipients, utils.GetBoolFlag(cmd, "gpg-armor")))
+	} else {
+		utils.LogDebug("Encrypting secrets")
 
-	passphrase := getPassphrase(cmd, "passphrase", localConfig)
-	if passphrase == "" {
-		utils.HandleError(errors.New("invalid passphrase"))
-	}
+		passphrase := getPassphrase(cmd, "passphrase", localConfig)
+		if passphrase == "" {
+			utils.HandleError(errors.New("invalid passphrase"))
+		}
 
-	encryptedBody, err := crypto.Encrypt(passphrase, body, "base64")
-	if err != nil {
-		utils.HandleError(err, "Unable to encrypt your secrets. No file has been written.")
+		encryptedBody, err = crypto.Encrypt(passphrase, body, "base64")
+		if err != nil {
+			utils.HandleError(err, "Unable to encrypt your secrets. No file has been written.")
+		}
 	}
 
 	if err := utils.WriteFile(filePath, []byte(encryptedBody), utils.RestrictedFilePerms()); err != nil {
@@ -525,6 +1537,268 @@ func downloadSecrets(cmd *cobra.Command, args []string) {
 	utils.Print(fmt.Sprintf("Downloaded secrets to %s", filePath))
 }
 
+// downloadSecretsToFiles writes secrets to each output in its corresponding format
+func downloadSecretsToFiles(cmd *cobra.Command, args []string, localConfig models.ScopedOptions, formats []models.SecretsFormat, outputs []string, nameTransformer *models.SecretsNameTransformer, dynamicSecretsTTL time.Duration) {
+	if len(args) > 0 {
+		utils.HandleError(errors.New("the download file path must be specified via --output when downloading multiple formats"))
+	}
+	if utils.GetBoolFlag(cmd, "no-file") {
+		utils.HandleError(errors.New("--no-file cannot be used when downloading multiple formats"))
+	}
+	if len(formats) != len(outputs) {
+		utils.HandleError(fmt.Errorf("each --format must have a corresponding --output (received %d formats and %d outputs)", len(formats), len(outputs)))
+	}
+
+	// fallback file is not supported when downloading multiple formats
+	flags := []string{"fallback", "fallback-only", "fallback-readonly", "no-exit-on-write-failure"}
+	for _, flag := range flags {
+		if cmd.Flags().Changed(flag) {
+			utils.LogWarning(fmt.Sprintf("--%s has no effect when downloading multiple formats", flag))
+		}
+	}
+
+	var filePaths []string
+	for _, output := range outputs {
+		filePath, err := utils.GetFilePath(output)
+		if err != nil {
+			utils.HandleError(err, "Unable to parse download file path")
+		}
+		filePaths = append(filePaths, filePath)
+	}
+
+	gpgRecipients, err := cmd.Flags().GetStringArray("gpg-recipient")
+	if err != nil {
+		utils.HandleError(err)
+	}
+
+	var passphrase string
+	if len(gpgRecipients) == 0 {
+		passphrase = getPassphrase(cmd, "passphrase", localConfig)
+		if passphrase == "" {
+			utils.HandleError(errors.New("invalid passphrase"))
+		}
+	}
+
+	encoding := cmd.Flag("encode").Value.String()
+	linePrefix := cmd.Flag("line-prefix").Value.String()
+
+	// formats the API renders are fetched individually, so each file matches a single-format download. the rest are
+	// rendered locally from a single JSON fetch, as they are for a single-format download
+	var secrets map[string]string
+	for i, format := range formats {
+		var body string
+		if format != models.JSON && !format.RenderedLocally() && encoding == "" && linePrefix == "" {
+			_, _, response, apiError := http.DownloadSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, format, nameTransformer, "", dynamicSecretsTTL, nil)
+			if !apiError.IsNil() {
+				utils.HandleError(apiError.Unwrap(), apiError.Message)
+			}
+			body = string(response)
+		} else {
+			if secrets == nil {
+				_, _, response, apiError := http.DownloadSecrets(localConfig.APIHost.Value, utils.GetBool(localConfig.VerifyTLS.Value, true), localConfig.Token.Value, localConfig.EnclaveProject.Value, localConfig.EnclaveConfig.Value, models.JSON, nameTransformer, "", dynamicSecretsTTL, nil)
+				if !apiError.IsNil() {
+					utils.HandleError(apiError.Unwrap(), apiError.Message)
+				}
+
+				secrets = map[string]string{}
+				if err := json.Unmarshal(response, &secrets); err != nil {
+					utils.HandleError(err, "Unable to parse JSON secrets")
+				}
+				if encoding != "" {
+					secrets = encodeSecretValues(secrets, encoding)
+				}
+			}
+			if encoding != "" {
+				warnOnEncodedValues(format, encoding)
+			}
+			body = renderSecrets(cmd, format, secrets, localConfig)
+		}
+
+		var encryptedBody string
+		if len(gpgRecipients) > 0 {
+			encryptedBody = string(encryptForGPGRecipients(cmd, []byte(body), gpgRecipients, utils.GetBoolFlag(cmd, "gpg-armor")))
+		} else {
+			utils.LogDebug(fmt.Sprintf("Encrypting secrets in %s format", format))
+			var err error
+			encryptedBody, err = crypto.Encrypt(passphrase, []byte(body), "base64")
+			if err != nil {
+				utils.HandleError(err, "Unable to encrypt your secrets. No file has been written.")
+			}
+		}
+
+		if err := utils.WriteFile(filePaths[i], []byte(encryptedBody), utils.RestrictedFilePerms()); err != nil {
+			utils.HandleError(err, "Unable to write the secrets file")
+		}
+
+		utils.Print(fmt.Sprintf("Downloaded secrets to %s", filePaths[i]))
+	}
+}
+
+// encodeSecretValues encodes each secret's value (e.g. as base64). Config metadata (e.g. DOPPLER_PROJECT) is left as is
+func encodeSecretValues(secrets map[string]string, encoding string) map[string]string {
+	values := map[string]string{}
+	for name, value := range secrets {
+		if !controllers.IsConfigMetadata(name) {
+			values[name] = value
+		}
+	}
+
+	encoded, err := utils.EncodeValues(values, encoding)
+	if err != nil {
+		utils.HandleError(err, "Unable to encode secrets")
+	}
+	for name, value := range secrets {
+		if controllers.IsConfigMetadata(name) {
+			encoded[name] = value
+		}
+	}
+	return encoded
+}
+
+// warnOnEncodedValues warns when encoding values for a format whose consumers don't expect encoded values.
+// JSON and YAML are commonly used for manifests (e.g. a Kubernetes Secret's data) that do
+func warnOnEncodedValues(format models.SecretsFormat, encoding string) {
+	if format != models.JSON && format != models.YAML {
+		utils.LogWarning(fmt.Sprintf("--encode %s changes the value of each secret, which consumers of %s format won't decode", encoding, format))
+	}
+}
+
+// encryptForGPGRecipients encrypts the downloaded secrets to the GPG recipients rather than with a passphrase
+func encryptForGPGRecipients(cmd *cobra.Command, body []byte, recipients []string, armored bool) []byte {
+	if cmd.Flags().Changed("passphrase") {
+		utils.LogWarning("--passphrase has no effect when used with --gpg-recipient")
+	}
+
+	utils.LogDebug(fmt.Sprintf("Encrypting secrets to GPG recipients %s", strings.Join(recipients, ", ")))
+	encrypted, err := controllers.EncryptForGPGRecipients(body, recipients, armored)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+	return encrypted
+}
+
+// gpgFileExtension the extension of the default file name when encrypting to GPG recipients
+func gpgFileExtension(cmd *cobra.Command) string {
+	if utils.GetBoolFlag(cmd, "gpg-armor") {
+		return ".asc"
+	}
+	return ".gpg"
+}
+
+// jsonSecrets the value to marshal for JSON format, which is a sorted array of name/value objects when using --json-array
+func jsonSecrets(cmd *cobra.Command, secrets map[string]string) interface{} {
+	if utils.GetBoolFlag(cmd, "json-array") {
+		return utils.MapToJSONArray(secrets)
+	}
+	return secrets
+}
+
+// secretValueTypes the values of a secret that can be downloaded
+var secretValueTypes = []string{"computed", "raw"}
+
+// linePrefixFormats the formats that render one line per secret, and so support --line-prefix
+var linePrefixFormats = []models.SecretsFormat{models.ENV, models.ENV_NO_QUOTES, models.DOCKER}
+
+// prefixSecretLines prepends the --line-prefix to each line. A multi-line value can't be prefixed without changing
+// it, so it's an error for any secret to contain a newline
+func prefixSecretLines(cmd *cobra.Command, format models.SecretsFormat, secrets map[string]string, lines []string) []string {
+	linePrefix := cmd.Flag("line-prefix").Value.String()
+	if linePrefix == "" {
+		return lines
+	}
+
+	var multiline []string
+	for name, value := range secrets {
+		if strings.ContainsAny(value, "\r\n") {
+			multiline = append(multiline, name)
+		}
+	}
+	if len(multiline) > 0 {
+		sort.Strings(multiline)
+		utils.HandleError(fmt.Errorf("--line-prefix cannot be used with multi-line secrets in %s format: %s", format, strings.Join(multiline, ", ")))
+	}
+
+	prefixed := make([]string, len(lines))
+	for i, line := range lines {
+		prefixed[i] = linePrefix + line
+	}
+	return prefixed
+}
+
+// renderSecrets renders secrets in the specified format without using the API
+func renderSecrets(cmd *cobra.Command, format models.SecretsFormat, secrets map[string]string, localConfig models.ScopedOptions) string {
+	switch format {
+	case models.JSON:
+		body, err := json.Marshal(jsonSecrets(cmd, secrets))
+		if err != nil {
+			utils.HandleError(err, "Unable to parse JSON secrets")
+		}
+		return string(body)
+	case models.DOTNET_JSON:
+		body, err := json.Marshal(utils.MapToDotNETJSONFormat(secrets))
+		if err != nil {
+			utils.HandleError(err, "Unable to parse JSON secrets")
+		}
+		return string(body)
+	case models.ENV:
+		return strings.Join(prefixSecretLines(cmd, format, secrets, utils.MapToEnvFormat(secrets, true)), "\n")
+	case models.ENV_NO_QUOTES, models.DOCKER:
+		return strings.Join(prefixSecretLines(cmd, format, secrets, utils.MapToEnvFormat(secrets, false)), "\n")
+	case models.YAML:
+		body, err := yaml.Marshal(secrets)
+		if err != nil {
+			utils.HandleError(err, "Unable to parse YAML secrets")
+		}
+		return string(body)
+	case models.TOML:
+		var section []string
+		if utils.GetBoolFlag(cmd, "toml-section") {
+			project := localConfig.EnclaveProject.Value
+			if value, ok := secrets["DOPPLER_PROJECT"]; ok {
+				project = value
+			}
+			config := localConfig.EnclaveConfig.Value
+			if value, ok := secrets["DOPPLER_CONFIG"]; ok {
+				config = value
+			}
+			section = []string{project, config}
+		}
+		return utils.MapToTOMLFormat(secrets, section)
+	case models.CLOUDINIT:
+		opts := utils.CloudInitOptions{
+			Path:        cmd.Flag("cloudinit-path").Value.String(),
+			Owner:       cmd.Flag("cloudinit-owner").Value.String(),
+			Permissions: cmd.Flag("cloudinit-permissions").Value.String(),
+		}
+		body, err := utils.MapToCloudInitFormat(secrets, opts)
+		if err != nil {
+			utils.HandleError(err, "Unable to render cloud-init secrets")
+		}
+		return body
+	case models.SYSTEMD:
+		body, warnings, err := utils.MapToSystemdEnvFormat(secrets, utils.GetBoolFlag(cmd, "strict"))
+		if err != nil {
+			utils.HandleError(err, "Unable to render systemd secrets", "Omit --strict to escape or skip unsupported secrets instead")
+		}
+		for _, warning := range warnings {
+			utils.LogWarning(warning)
+		}
+		return body
+	case models.XML:
+		body, warnings, err := utils.MapToXMLFormat(secrets, utils.GetBoolFlag(cmd, "strict"))
+		if err != nil {
+			utils.HandleError(err, "Unable to render XML secrets", "Omit --strict to replace or skip unsupported secrets instead")
+		}
+		for _, warning := range warnings {
+			utils.LogWarning(warning)
+		}
+		return body
+	}
+
+	utils.HandleError(fmt.Errorf("unsupported format %s", format))
+	return ""
+}
+
 func substituteSecrets(cmd *cobra.Command, args []string) {
 	localConfig := configuration.LocalConfig(cmd)
 
@@ -533,7 +1807,7 @@ func substituteSecrets(cmd *cobra.Command, args []string) {
 	var outputFilePath string
 	var err error
 	output := cmd.Flag("output").Value.String()
-	if len(output) != 0 {
+	if len(output) != 0 && output != "-" {
 		outputFilePath, err = utils.GetFilePath(output)
 		if err != nil {
 			utils.HandleError(err, "Unable to parse output file path")
@@ -577,6 +1851,60 @@ func substituteSecrets(cmd *cobra.Command, args []string) {
 	}
 }
 
+func pullSecrets(cmd *cobra.Command, args []string) {
+	merge := utils.GetBoolFlag(cmd, "merge")
+	localConfig := configuration.LocalConfig(cmd)
+
+	utils.RequireValue("token", localConfig.Token.Value)
+
+	path, err := utils.GetFilePath(cmd.Flag("into").Value.String())
+	if err != nil {
+		utils.HandleError(err, "Unable to parse file path")
+	}
+
+	var existing []byte
+	perms := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		if !merge {
+			utils.HandleError(fmt.Errorf("%s already exists. Use --merge to update its Doppler-managed secrets", path))
+		}
+		// preserve the permissions of the existing file
+		perms = info.Mode().Perm()
+		if existing, err = ioutil.ReadFile(path); err != nil { // #nosec G304
+			utils.HandleError(err, "Unable to read existing file")
+		}
+	} else if !os.IsNotExist(err) {
+		utils.HandleError(err, "Unable to read existing file")
+	}
+
+	secrets, apiErr := controllers.GetSecrets(localConfig)
+	if !apiErr.IsNil() {
+		utils.HandleError(apiErr.Unwrap(), apiErr.Message)
+	}
+
+	secretsMap := map[string]string{}
+	for name, secret := range secrets {
+		if secret.ComputedValue == nil {
+			utils.LogWarning(fmt.Sprintf("Skipping secret %s, which has a restricted value", name))
+			continue
+		}
+		secretsMap[name] = *secret.ComputedValue
+	}
+
+	merged, conflicts, err := utils.MergeDotenv(string(existing), secretsMap)
+	if err != nil {
+		utils.HandleError(err, fmt.Sprintf("Unable to merge secrets into %s", path))
+	}
+	for _, name := range conflicts {
+		utils.LogWarning(fmt.Sprintf("%s is also assigned outside of the Doppler-managed block", name))
+	}
+
+	if err := utils.WriteFile(path, []byte(merged), perms); err != nil {
+		utils.HandleError(err, fmt.Sprintf("Unable to write %s", path))
+	}
+	utils.Print(fmt.Sprintf("Wrote %d secrets to %s", len(secretsMap), path))
+}
+
 func secretNamesValidArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	persistentValidArgsFunction(cmd)
 
@@ -600,6 +1928,12 @@ func init() {
 	secretsCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
 	secretsCmd.Flags().Bool("visibility", false, "include secret visibility in table output")
 	secretsCmd.Flags().Bool("only-names", false, "only print the secret names; omit all values")
+	secretsCmd.Flags().StringArray("tag", []string{}, "only print secrets with this tag. may be specified multiple times to print secrets with any of the tags")
+	secretsCmd.Flags().Bool("smart-mask", false, "mask values, describing recognized formats (e.g. JWT algorithm, PEM type, URL host) without revealing them")
+	secretsCmd.Flags().Bool("local-only", false, "only print secrets overridden in this config, omitting those inherited from the root config")
+	secretsCmd.Flags().Bool("references-only", false, "only print secrets whose raw value contains a reference (e.g. '${OTHER_SECRET}'), showing the raw and computed values")
+	secretsCmd.Flags().Bool("show-age", false, "include when each secret was last changed. if the API doesn't provide it, it's determined from the config's logs")
+	secretsCmd.Flags().String("sort", "name", fmt.Sprintf("order to list secrets in. 'age' lists the least recently changed first. one of %v", secretsSortOrders))
 
 	secretsGetCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
 	if err := secretsGetCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
@@ -614,8 +1948,23 @@ func init() {
 	secretsGetCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
 	secretsGetCmd.Flags().Bool("visibility", false, "include secret visibility in table output")
 	secretsGetCmd.Flags().Bool("no-exit-on-missing-secret", false, "do not exit if unable to find a requested secret")
+	secretsGetCmd.Flags().String("output", "", "write the secret's value to this file, exactly as stored. '-' writes it to stdout. requires a single secret. unlike the global --output, the value isn't formatted")
+	secretsGetCmd.Flags().String("mode", "0600", "octal permissions of the file written via --output")
+	secretsGetCmd.Flags().Bool("mkdir", false, "create the parent directories of the --output file if they don't exist")
+	secretsGetCmd.Flags().Bool("smart-mask", false, "mask values, describing recognized formats (e.g. JWT algorithm, PEM type, URL host) without revealing them")
+	secretsGetCmd.Flags().Bool("ignore-case", false, "match secret names case-insensitively, failing if a name matches multiple secrets")
 	secretsCmd.AddCommand(secretsGetCmd)
 
+	secretsResolveCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := secretsResolveCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsResolveCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := secretsResolveCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsCmd.AddCommand(secretsResolveCmd)
+
 	secretsSetCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
 	if err := secretsSetCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
 		utils.HandleError(err)
@@ -625,9 +1974,98 @@ func init() {
 		utils.HandleError(err)
 	}
 	secretsSetCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
+	secretsSetCmd.Flags().Bool("no-references", false, "fail if any value contains a secret reference (e.g. '${OTHER_SECRET}')")
 	secretsSetCmd.Flags().Bool("no-interactive", false, "do not allow entering secret value via interactive mode")
+	secretsSetCmd.Flags().Int("batch-size", 100, "max number of secrets to set per request. larger imports are split into sequential batches, which are not applied atomically")
+	secretsSetCmd.Flags().String("if-match", "", "only set secrets if the config's version (from 'doppler secrets etag') still matches")
+	secretsSetCmd.Flags().StringArray("tag", []string{}, "assign this tag to the secrets, replacing any existing tags. may be specified multiple times")
+	secretsSetCmd.Flags().Bool("force", false, "set secrets without checking whether the config has changed since it was read, or whether it's locked (with the protect-locked-configs flag)")
 	secretsCmd.AddCommand(secretsSetCmd)
 
+	secretsETagCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := secretsETagCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsETagCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := secretsETagCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsCmd.AddCommand(secretsETagCmd)
+
+	secretsCASCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := secretsCASCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsCASCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := secretsCASCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsCASCmd.Flags().String("expect", "", "only set the secret if its current raw value is this")
+	secretsCASCmd.Flags().Bool("expect-missing", false, "only set the secret if it doesn't exist")
+	secretsCASCmd.Flags().String("set", "", "the secret's new value")
+	secretsCASCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
+	secretsCASCmd.Flags().Bool("force", false, "set the secret even if the config is locked (with the protect-locked-configs flag)")
+	secretsCmd.AddCommand(secretsCASCmd)
+
+	secretsSetFileCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := secretsSetFileCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsSetFileCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := secretsSetFileCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsSetFileCmd.Flags().Bool("base64", false, "store the file's contents base64 encoded (e.g. for binary files)")
+	secretsSetFileCmd.Flags().Int64("max-size", defaultSecretFileMaxSize, "max size of the file, in bytes")
+	secretsSetFileCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
+	secretsSetFileCmd.Flags().Bool("force", false, "set the secret even if the config is locked (with the protect-locked-configs flag)")
+	secretsCmd.AddCommand(secretsSetFileCmd)
+
+	secretsReplaceCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := secretsReplaceCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsReplaceCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := secretsReplaceCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsReplaceCmd.Flags().String("find", "", "the text to find in secret values")
+	secretsReplaceCmd.Flags().String("replace", "", "the text to replace it with")
+	secretsReplaceCmd.Flags().Bool("regex", false, "treat --find as a regular expression. the replacement may reference its capture groups (e.g. '$1')")
+	secretsReplaceCmd.Flags().Bool("dry-run", false, "print the changes without applying them (default)")
+	secretsReplaceCmd.Flags().Bool("apply", false, "set the changed secrets")
+	secretsReplaceCmd.Flags().Bool("force", false, "set the changed secrets even if the config is locked (with the protect-locked-configs flag)")
+	secretsCmd.AddCommand(secretsReplaceCmd)
+
+	secretsHistoryCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := secretsHistoryCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsHistoryCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := secretsHistoryCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsHistoryCmd.Flags().IntP("number", "n", 20, "max number of changes to display")
+	secretsCmd.AddCommand(secretsHistoryCmd)
+
+	secretsDiffCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := secretsDiffCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsDiffCmd.Flags().Bool("names-only", false, "only list the names of the secrets that differ, hiding their values (e.g. for sharing in reviews)")
+	secretsCmd.AddCommand(secretsDiffCmd)
+
+	secretsHashCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := secretsHashCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsHashCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := secretsHashCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsHashCmd.Flags().Bool("raw", false, "hash the raw secret values without processing variables")
+	secretsCmd.AddCommand(secretsHashCmd)
+
 	secretsUploadCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
 	if err := secretsUploadCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
 		utils.HandleError(err)
@@ -637,6 +2075,9 @@ func init() {
 		utils.HandleError(err)
 	}
 	secretsUploadCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
+	secretsUploadCmd.Flags().Bool("strict", false, "fail if a json or yaml file contains non-string values, rather than storing them as strings")
+	secretsUploadCmd.Flags().Bool("force", false, "upload the secrets even if the config is locked (with the protect-locked-configs flag)")
+	secretsUploadCmd.Flags().String("decode", "", fmt.Sprintf("decode the value of each secret in a json or yaml file before uploading it. fails if any value isn't validly encoded. one of %v", utils.ValueEncodings))
 	secretsCmd.AddCommand(secretsUploadCmd)
 
 	secretsDeleteCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
@@ -649,6 +2090,7 @@ func init() {
 	}
 	secretsDeleteCmd.Flags().Bool("raw", false, "print the raw secret value without processing variables")
 	secretsDeleteCmd.Flags().BoolP("yes", "y", false, "proceed without confirmation")
+	secretsDeleteCmd.Flags().Bool("force", false, "delete secrets even if other secrets reference them (e.g. '${SECRET}'), or the config is locked (with the protect-locked-configs flag)")
 	secretsCmd.AddCommand(secretsDeleteCmd)
 
 	secretsDownloadCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
@@ -659,7 +2101,8 @@ func init() {
 	if err := secretsDownloadCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
 		utils.HandleError(err)
 	}
-	secretsDownloadCmd.Flags().String("format", models.JSON.String(), fmt.Sprintf("output format. one of %s", validFormatList))
+	secretsDownloadCmd.Flags().StringArray("format", []string{models.JSON.String()}, fmt.Sprintf("output format. one of %s. may be specified multiple times, with a corresponding --output for each", validFormatList))
+	secretsDownloadCmd.Flags().StringArray("output", []string{}, "path to write the secrets file to. may be specified multiple times, with a corresponding --format for each. unlike the global --output, '-' isn't supported; use --no-file to write to stdout")
 	secretsDownloadCmd.Flags().String("name-transformer", "", fmt.Sprintf("output name transformer. one of %v", validNameTransformersList))
 	err := secretsDownloadCmd.RegisterFlagCompletionFunc("name-transformer", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return models.SecretsNameTransformerTypes, cobra.ShellCompDirectiveDefault
@@ -669,15 +2112,30 @@ func init() {
 	}
 	secretsDownloadCmd.Flags().String("passphrase", "", "passphrase to use for encrypting the secrets file. the default passphrase is computed using your current configuration.")
 	secretsDownloadCmd.Flags().Bool("no-file", false, "print the response to stdout")
+	secretsDownloadCmd.Flags().Bool("toml-section", false, "nest secrets under a [project.config] table when using TOML format")
+	secretsDownloadCmd.Flags().String("cloudinit-path", "/etc/doppler/secrets.env", "path of the env file written on the instance when using cloudinit format")
+	secretsDownloadCmd.Flags().String("cloudinit-owner", "root:root", "owner (user:group) of the env file written on the instance when using cloudinit format")
+	secretsDownloadCmd.Flags().String("cloudinit-permissions", "0600", "octal permissions of the env file written on the instance when using cloudinit format")
+	secretsDownloadCmd.Flags().Bool("strict", false, "when using systemd or xml format, fail on unsupported values and invalid names rather than escaping, replacing, or skipping them")
+	secretsDownloadCmd.Flags().Bool("both", false, "output both the raw and computed value of each secret (e.g. {\"KEY\":{\"raw\":\"${X}\",\"computed\":\"value\"}}). only supported with JSON format")
+	secretsDownloadCmd.Flags().String("values", "computed", fmt.Sprintf("which value of each secret to download. 'raw' keeps secret references (e.g. '${OTHER_SECRET}') unresolved. one of %v", secretValueTypes))
+	secretsDownloadCmd.Flags().String("line-prefix", "", fmt.Sprintf("prepend this string to each line of output (e.g. 'export '). only supported by formats %v", linePrefixFormats))
+	secretsDownloadCmd.Flags().String("encode", "", fmt.Sprintf("encode the value of each secret before rendering the output. this changes the values, so consumers must decode them. one of %v", utils.ValueEncodings))
+	secretsDownloadCmd.Flags().Bool("json-array", false, "output JSON as an array of {\"name\":\"KEY\",\"value\":\"value\"} objects sorted by name, rather than an object. only supported with JSON format")
+	secretsDownloadCmd.Flags().StringArray("gpg-recipient", []string{}, "encrypt the secrets to the public key of this recipient (e.g. an email address or key ID) in your GPG keyring, rather than with a passphrase. may be specified multiple times")
+	secretsDownloadCmd.Flags().Bool("gpg-armor", false, "write the GPG encrypted file as ASCII armored text. always enabled with --no-file")
 	secretsDownloadCmd.Flags().Duration("dynamic-ttl", 0, "(BETA) dynamic secrets will expire after specified duration, (e.g. '3h', '15m')")
 	// fallback flags
-	secretsDownloadCmd.Flags().String("fallback", "", "path to the fallback file. encrypted secrets are written to this file after each successful fetch. secrets will be read from this file if subsequent connections are unsuccessful.")
+	secretsDownloadCmd.Flags().String("fallback", "", "path to the fallback file. encrypted secrets are written to this file after each successful fetch. secrets will be read from this file if subsequent connections are unsuccessful. '${VAR}' is expanded using DOPPLER_PROJECT, DOPPLER_CONFIG, and the environment (e.g. '${HOME}/.doppler/${DOPPLER_CONFIG}.json')")
 	secretsDownloadCmd.Flags().Bool("no-cache", false, "disable using the fallback file to speed up fetches. the fallback file is only used when the API indicates that it's still current.")
 	secretsDownloadCmd.Flags().Bool("no-fallback", false, "disable reading and writing the fallback file")
 	secretsDownloadCmd.Flags().String("fallback-passphrase", "", "passphrase to use for encrypting the fallback file. by default the passphrase is computed using your current configuration.")
 	secretsDownloadCmd.Flags().Bool("fallback-readonly", false, "disable modifying the fallback file. secrets can still be read from the file.")
 	secretsDownloadCmd.Flags().Bool("fallback-only", false, "read all secrets directly from the fallback file, without contacting Doppler. secrets will not be updated. (implies --fallback-readonly)")
 	secretsDownloadCmd.Flags().Bool("no-exit-on-write-failure", false, "do not exit if unable to write the fallback file")
+	secretsDownloadCmd.Flags().Duration("fallback-max-age", 0, "refuse to use a fallback file whose secrets were fetched longer ago than this duration (e.g. '24h'), as recorded in its metadata file. 0 for no limit")
+	secretsDownloadCmd.Flags().String("fallback-format", "json", fmt.Sprintf("format to write the fallback file in. one of %s. env writes an unencrypted dotenv file that can be read by other tools. either format can be read", controllers.FallbackFormats))
+	secretsDownloadCmd.Flags().String("fallback-stale", "error", fmt.Sprintf("behavior when the fallback file exceeds --fallback-max-age. one of %s", controllers.FallbackStaleActions))
 	secretsCmd.AddCommand(secretsDownloadCmd)
 
 	secretsSubstituteCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
@@ -688,9 +2146,21 @@ func init() {
 	if err := secretsSubstituteCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
 		utils.HandleError(err)
 	}
-	secretsSubstituteCmd.Flags().String("output", "", "path to the output file. by default the rendered text will be written to stdout.")
+	secretsSubstituteCmd.Flags().String("output", "", "path to the output file. by default, or with '-', the rendered text will be written to stdout.")
 	secretsSubstituteCmd.Flags().Duration("dynamic-ttl", 0, "(BETA) dynamic secrets will expire after specified duration, (e.g. '3h', '15m')")
 	secretsCmd.AddCommand(secretsSubstituteCmd)
 
+	secretsPullCmd.Flags().StringP("project", "p", "", "project (e.g. backend)")
+	if err := secretsPullCmd.RegisterFlagCompletionFunc("project", projectIDsValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsPullCmd.Flags().StringP("config", "c", "", "config (e.g. dev)")
+	if err := secretsPullCmd.RegisterFlagCompletionFunc("config", configNamesValidArgs); err != nil {
+		utils.HandleError(err)
+	}
+	secretsPullCmd.Flags().String("into", ".env", "path of the dotenv file to write")
+	secretsPullCmd.Flags().Bool("merge", false, "update the Doppler-managed secrets in an existing file, preserving comments and keys outside of the managed block")
+	secretsCmd.AddCommand(secretsPullCmd)
+
 	rootCmd.AddCommand(secretsCmd)
 }
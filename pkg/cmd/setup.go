@@ -208,6 +208,19 @@ func setup(cmd *cobra.Command, args []string) {
 		flag := models.FlagUpdateCheck
 		value := *repoConfig.Flags.UpdateCheck
 
+		if utils.CanLogInfo() {
+			verb := "Enabling"
+			if !value {
+				verb = "Disabling"
+			}
+			utils.Log(fmt.Sprintf("%s %s", verb, flag))
+		}
+		configuration.SetFlag(flag, value)
+	}
+	if repoConfig.Flags.ProtectLockedConfigs != nil {
+		flag := models.FlagProtectLockedConfigs
+		value := *repoConfig.Flags.ProtectLockedConfigs
+
 		if utils.CanLogInfo() {
 			verb := "Enabling"
 			if !value {
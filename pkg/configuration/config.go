@@ -120,21 +120,36 @@ func SetVersionCheck(version models.VersionCheck) {
 
 // Get the config at the specified scope
 func Get(scope string) models.ScopedOptions {
-	var normalizedScope string
-	var err error
-	if normalizedScope, err = NormalizeScope(scope); err != nil {
-		utils.HandleError(err, fmt.Sprintf("Invalid scope: %s", scope))
+	scopedConfig := fileScopedConfig(scope)
+
+	if IsKeyringSecret(scopedConfig.Token.Value) {
+		utils.LogDebug(fmt.Sprintf("Retrieving %s from system keyring", models.ConfigToken.String()))
+		token, err := GetKeyring(scopedConfig.Token.Value)
+		if !err.IsNil() {
+			utils.HandleError(err.Unwrap(), err.Message)
+		}
+
+		scopedConfig.Token.Value = token
 	}
-	if !strings.HasSuffix(normalizedScope, string(filepath.Separator)) {
-		normalizedScope = normalizedScope + string(filepath.Separator)
+
+	return scopedConfig
+}
+
+// fileScopedConfig the options from the config file that apply to the specified scope
+func fileScopedConfig(scope string) models.ScopedOptions {
+	normalizedScope, err := resolveScope(scope)
+	if err != nil {
+		utils.HandleError(err, fmt.Sprintf("Invalid scope: %s", scope))
 	}
 	var scopedConfig models.ScopedOptions
+	// length of the resolved config scope that set each option, so the most specific scope wins
+	matchedScopeLengths := map[string]int{}
 
 	for confScope, conf := range configContents.Scoped {
-		confScopePath := confScope
-		// both paths must end in / to prevent partial match (e.g. /test matching /test123)
-		if !strings.HasSuffix(confScopePath, string(filepath.Separator)) {
-			confScopePath = confScopePath + string(filepath.Separator)
+		confScopePath, err := resolveScope(confScope)
+		if err != nil {
+			utils.LogDebug(fmt.Sprintf("Ignoring invalid scope %s in config file", confScope))
+			continue
 		}
 
 		if !strings.HasPrefix(normalizedScope, confScopePath) {
@@ -146,25 +161,16 @@ func Get(scope string) models.ScopedOptions {
 		for name, pair := range pairs {
 			if pair != "" {
 				scopedPair := scopedPairs[name]
-				if *scopedPair == (models.ScopedOption{}) || len(confScope) > len(scopedPair.Scope) {
+				if *scopedPair == (models.ScopedOption{}) || len(confScopePath) > matchedScopeLengths[name] {
 					scopedPair.Value = pair
 					scopedPair.Scope = confScope
 					scopedPair.Source = models.ConfigFileSource.String()
+					matchedScopeLengths[name] = len(confScopePath)
 				}
 			}
 		}
 	}
 
-	if IsKeyringSecret(scopedConfig.Token.Value) {
-		utils.LogDebug(fmt.Sprintf("Retrieving %s from system keyring", models.ConfigToken.String()))
-		token, err := GetKeyring(scopedConfig.Token.Value)
-		if !err.IsNil() {
-			utils.HandleError(err.Unwrap(), err.Message)
-		}
-
-		scopedConfig.Token.Value = token
-	}
-
 	return scopedConfig
 }
 
@@ -199,7 +205,20 @@ func LocalConfig(cmd *cobra.Command) models.ScopedOptions {
 
 	// individual flags (highest priority)
 	flagSet := cmd.Flags().Changed("token")
-	if flagSet || localConfig.Token.Value == "" {
+	tokenFileSet := cmd.Flags().Changed("token-file")
+	if tokenFileSet || (flagSet && cmd.Flag("token").Value.String() == "-") {
+		if flagSet && tokenFileSet {
+			utils.HandleError(errors.New("--token cannot be used with --token-file"))
+		}
+
+		token, err := flagToken(cmd)
+		if err != nil {
+			utils.HandleError(err, "Unable to read token")
+		}
+		localConfig.Token.Value = token
+		localConfig.Token.Scope = "/"
+		localConfig.Token.Source = models.FlagSource.String()
+	} else if flagSet || localConfig.Token.Value == "" {
 		localConfig.Token.Value = cmd.Flag("token").Value.String()
 		localConfig.Token.Scope = "/"
 
@@ -210,6 +229,14 @@ func LocalConfig(cmd *cobra.Command) models.ScopedOptions {
 		}
 	}
 
+	// validate tokens the user provided for this invocation; saved tokens were validated when they were saved
+	source := localConfig.Token.Source
+	if localConfig.Token.Value != "" && (source == models.FlagSource.String() || source == models.EnvironmentSource.String()) {
+		if err := ValidateToken(localConfig.Token.Value); err != nil {
+			utils.HandleError(err, fmt.Sprintf("Invalid token (source: %s)", source))
+		}
+	}
+
 	flagSet = cmd.Flags().Changed("api-host")
 	if flagSet || localConfig.APIHost.Value == "" {
 		localConfig.APIHost.Value = cmd.Flag("api-host").Value.String()
@@ -234,6 +261,28 @@ func LocalConfig(cmd *cobra.Command) models.ScopedOptions {
 		}
 	}
 
+	if cmd.Flags().Changed("api-env") {
+		if cmd.Flags().Changed("api-host") {
+			utils.HandleError(errors.New("--api-env cannot be used with --api-host"))
+		}
+
+		name := cmd.Flag("api-env").Value.String()
+		environment, ok := models.APIEnvironments[name]
+		if !ok {
+			utils.HandleError(fmt.Errorf("invalid --api-env %q. Valid environments are %v", name, models.APIEnvironmentNames()))
+		}
+
+		localConfig.APIHost.Value = environment.APIHost
+		localConfig.APIHost.Scope = "/"
+		localConfig.APIHost.Source = models.FlagSource.String()
+		// an explicit --dashboard-host still takes precedence
+		if !cmd.Flags().Changed("dashboard-host") {
+			localConfig.DashboardHost.Value = environment.DashboardHost
+			localConfig.DashboardHost.Scope = "/"
+			localConfig.DashboardHost.Source = models.FlagSource.String()
+		}
+	}
+
 	flagSet = cmd.Flags().Changed("no-verify-tls")
 	if flagSet || localConfig.VerifyTLS.Value == "" {
 		noVerifyTLS := cmd.Flag("no-verify-tls").Value.String()
@@ -272,9 +321,33 @@ func LocalConfig(cmd *cobra.Command) models.ScopedOptions {
 		}
 	}
 
+	if cmd.Flags().Changed("ca-cert") {
+		localConfig.CACert.Value = cmd.Flag("ca-cert").Value.String()
+		localConfig.CACert.Scope = "/"
+		localConfig.CACert.Source = models.FlagSource.String()
+	}
+
 	return localConfig
 }
 
+// CACert resolves the ca-cert option for the scoped directory. Unlike LocalConfig, this doesn't read the token from the system keyring,
+// so it's safe to call before every command
+func CACert(cmd *cobra.Command) models.ScopedOption {
+	option := fileScopedConfig(Scope).CACert
+
+	if CanReadEnv {
+		if value := os.Getenv("DOPPLER_CA_CERT"); value != "" {
+			option = models.ScopedOption{Value: value, Scope: "/", Source: models.EnvironmentSource.String()}
+		}
+	}
+
+	if cmd.Flags().Changed("ca-cert") {
+		option = models.ScopedOption{Value: cmd.Flag("ca-cert").Value.String(), Scope: "/", Source: models.FlagSource.String()}
+	}
+
+	return option
+}
+
 // AllConfigs get all configs we know about
 func AllConfigs() map[string]models.FileScopedOptions {
 	all := map[string]models.FileScopedOptions{}
@@ -304,13 +377,15 @@ func Set(scope string, options map[string]string) {
 		utils.HandleError(err, fmt.Sprintf("Invalid scope: %s", scope))
 	}
 
+	// validate every option before saving any of them
+	if err := validateOptionValues(options); err != nil {
+		utils.HandleError(err)
+	}
+
 	config := configContents.Scoped[normalizedScope]
 	previousToken := config.Token
 
 	for key, value := range options {
-		if !IsValidConfigOption(key) {
-			utils.HandleError(errors.New("invalid option "+key), "")
-		}
 
 		if key == models.ConfigToken.String() {
 			utils.LogDebug(fmt.Sprintf("Saving %s to system keyring", key))
@@ -481,6 +556,9 @@ func readConfig() (models.ConfigFile, int, int) {
 		if options.VerifyTLS != "" {
 			scopedOption.VerifyTLS = options.VerifyTLS
 		}
+		if options.CACert != "" {
+			scopedOption.CACert = options.CACert
+		}
 
 		normalizedOptions[normalizedScope] = scopedOption
 	}
@@ -505,6 +583,7 @@ func IsValidConfigOption(key string) bool {
 		models.ConfigVerifyTLS.String():      nil,
 		models.ConfigEnclaveProject.String(): nil,
 		models.ConfigEnclaveConfig.String():  nil,
+		models.ConfigCACert.String():         nil,
 	}
 
 	_, exists := configOptions[key]
@@ -559,6 +638,8 @@ func SetConfigValue(conf *models.FileScopedOptions, key string, value string) {
 		(*conf).EnclaveProject = value
 	} else if key == models.ConfigEnclaveConfig.String() {
 		(*conf).EnclaveConfig = value
+	} else if key == models.ConfigCACert.String() {
+		(*conf).CACert = value
 	}
 }
 
@@ -570,3 +651,22 @@ func NormalizeScope(scope string) (string, error) {
 
 	return utils.ParsePath(scope)
 }
+
+// resolveScope normalizes the scope and resolves any symlinks in it, so that scopes can be compared by prefix.
+// The result always ends in a path separator to prevent partial matches (e.g. /test matching /test123)
+func resolveScope(scope string) (string, error) {
+	normalizedScope, err := NormalizeScope(scope)
+	if err != nil {
+		return "", err
+	}
+
+	// the directory may not exist (e.g. a scope for a deleted directory), in which case it's used as-is
+	if resolved, err := filepath.EvalSymlinks(normalizedScope); err == nil {
+		normalizedScope = resolved
+	}
+
+	if !strings.HasSuffix(normalizedScope, string(filepath.Separator)) {
+		normalizedScope = normalizedScope + string(filepath.Separator)
+	}
+	return normalizedScope, nil
+}
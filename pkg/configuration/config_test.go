@@ -0,0 +1,112 @@
+/*
+Copyright © 2021 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileScopedConfig(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+	app := filepath.Join(dir, "app")
+	nested := filepath.Join(app, "nested")
+	assert.NoError(t, os.MkdirAll(nested, 0700))
+	link := filepath.Join(dir, "link")
+	assert.NoError(t, os.Symlink(app, link))
+
+	contents := configContents
+	t.Cleanup(func() { configContents = contents })
+	configContents = models.ConfigFile{Scoped: map[string]models.FileScopedOptions{
+		"/":         {Token: "root-token", EnclaveProject: "root-project"},
+		app:         {EnclaveProject: "app-project"},
+		app + "123": {EnclaveConfig: "partial-match"},
+		// scopes saved through a symlink match lookups of the real path
+		filepath.Join(link, "nested"): {EnclaveConfig: "nested-config"},
+	}}
+
+	// a scope of / matches any nested directory
+	config := fileScopedConfig(nested)
+	assert.Equal(t, "root-token", config.Token.Value)
+	assert.Equal(t, "/", config.Token.Scope)
+
+	// the most specific scope wins
+	assert.Equal(t, "app-project", config.EnclaveProject.Value)
+	assert.Equal(t, app, config.EnclaveProject.Scope)
+	assert.Equal(t, "nested-config", config.EnclaveConfig.Value)
+
+	// lookups through a symlink resolve to the real path
+	config = fileScopedConfig(filepath.Join(link, "nested", "."))
+	assert.Equal(t, "app-project", config.EnclaveProject.Value)
+	assert.Equal(t, "nested-config", config.EnclaveConfig.Value)
+
+	config = fileScopedConfig(dir)
+	assert.Equal(t, "root-project", config.EnclaveProject.Value)
+	assert.Empty(t, config.EnclaveConfig.Value)
+}
+
+func TestLocalConfigPrecedence(t *testing.T) {
+	contents := configContents
+	t.Cleanup(func() { configContents = contents })
+	configContents = models.ConfigFile{Scoped: map[string]models.FileScopedOptions{
+		"/": {EnclaveProject: "file-project", EnclaveConfig: "file-config"},
+	}}
+
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("token", "", "")
+		cmd.Flags().String("api-host", "https://api.doppler.com", "")
+		cmd.Flags().String("dashboard-host", "https://dashboard.doppler.com", "")
+		cmd.Flags().Bool("no-verify-tls", false, "")
+		cmd.Flags().StringP("project", "p", "", "")
+		cmd.Flags().StringP("config", "c", "", "")
+		return cmd
+	}
+
+	// config file
+	config := LocalConfig(newCmd())
+	assert.Equal(t, "file-project", config.EnclaveProject.Value)
+	assert.Equal(t, models.ConfigFileSource.String(), config.EnclaveProject.Source)
+
+	// environment variables override the config file
+	t.Setenv("DOPPLER_PROJECT", "env-project")
+	t.Setenv("DOPPLER_CONFIG", "env-config")
+	config = LocalConfig(newCmd())
+	assert.Equal(t, "env-project", config.EnclaveProject.Value)
+	assert.Equal(t, "env-config", config.EnclaveConfig.Value)
+	assert.Equal(t, models.EnvironmentSource.String(), config.EnclaveConfig.Source)
+
+	// flags override environment variables
+	cmd := newCmd()
+	assert.NoError(t, cmd.ParseFlags([]string{"-p", "flag-project", "--config", "flag-config"}))
+	config = LocalConfig(cmd)
+	assert.Equal(t, "flag-project", config.EnclaveProject.Value)
+	assert.Equal(t, "flag-config", config.EnclaveConfig.Value)
+	assert.Equal(t, models.FlagSource.String(), config.EnclaveProject.Source)
+	assert.Equal(t, models.FlagSource.String(), config.EnclaveConfig.Source)
+
+	// environment variables are ignored with --no-read-env
+	CanReadEnv = false
+	t.Cleanup(func() { CanReadEnv = true })
+	config = LocalConfig(newCmd())
+	assert.Equal(t, "file-project", config.EnclaveProject.Value)
+}
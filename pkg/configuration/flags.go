@@ -38,6 +38,11 @@ func GetFlag(flag string) bool {
 			return *flags.UpdateCheck
 		}
 		return GetFlagDefault(models.FlagUpdateCheck)
+	case models.FlagProtectLockedConfigs:
+		if flags.ProtectLockedConfigs != nil {
+			return *flags.ProtectLockedConfigs
+		}
+		return GetFlagDefault(models.FlagProtectLockedConfigs)
 	}
 
 	return false
@@ -51,6 +56,8 @@ func SetFlag(flag string, enable bool) {
 		configContents.Flags.EnvWarning = &enable
 	case models.FlagUpdateCheck:
 		configContents.Flags.UpdateCheck = &enable
+	case models.FlagProtectLockedConfigs:
+		configContents.Flags.ProtectLockedConfigs = &enable
 	}
 	writeConfig(configContents)
 }
@@ -63,6 +70,9 @@ func GetFlagDefault(flag string) bool {
 		return true
 	case models.FlagUpdateCheck:
 		return true
+	case models.FlagProtectLockedConfigs:
+		// opt-in, as a Doppler lock otherwise only prevents renaming and deleting a config
+		return false
 	}
 
 	return false
@@ -0,0 +1,148 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// TokenTypes the known token prefixes and the type of token each identifies
+var TokenTypes = map[string]string{
+	"dp.pt.":    "personal token",
+	"dp.ct.":    "CLI token",
+	"dp.st.":    "service token",
+	"dp.sa.":    "service account token",
+	"dp.scim.":  "SCIM token",
+	"dp.audit.": "audit token",
+}
+
+// minTokenSecretLength the minimum length of the random portion of a token, used to detect truncated tokens
+const minTokenSecretLength = 40
+
+// ValidateToken checks the token's format without contacting the API, to catch tokens that were truncated or mistyped.
+// Tokens without a 'dp.' prefix predate typed tokens and are only checked for whitespace, while tokens with an unknown
+// 'dp.' prefix log a warning.
+// Doppler tokens don't encode an expiration, so expired tokens are still only detected by the API
+func ValidateToken(token string) error {
+	for _, r := range token {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("token contains whitespace or control characters. Ensure it was copied correctly")
+		}
+	}
+
+	if !strings.HasPrefix(token, "dp.") {
+		return nil
+	}
+
+	var prefix string
+	for knownPrefix := range TokenTypes {
+		if strings.HasPrefix(token, knownPrefix) {
+			prefix = knownPrefix
+			break
+		}
+	}
+	// new token types may be introduced after this version of the CLI, so their tokens are only checked by the API
+	if prefix == "" {
+		utils.LogWarning(fmt.Sprintf("Token has an unrecognized prefix and may require a newer version of the CLI. Known Doppler tokens begin with one of %s", strings.Join(tokenPrefixes(), ", ")))
+		return nil
+	}
+
+	// service tokens include the name of their config (e.g. 'dp.st.dev.xxxx'), so the random portion follows the last '.'
+	body := strings.TrimPrefix(token, prefix)
+	secret := body[strings.LastIndex(body, ".")+1:]
+	if len(secret) < minTokenSecretLength {
+		return fmt.Errorf("%s is too short and may have been truncated. Ensure it was copied correctly", TokenTypes[prefix])
+	}
+
+	return nil
+}
+
+// tokenPrefixes the known token prefixes, sorted
+func tokenPrefixes() []string {
+	var prefixes []string
+	for prefix := range TokenTypes {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// tokenFromFlags the token read via --token-file or '--token -', cached as stdin can only be read once
+var tokenFromFlags *string
+
+// flagToken reads the token from the file specified via --token-file, or from stdin when '--token -' is specified
+func flagToken(cmd *cobra.Command) (string, error) {
+	if tokenFromFlags != nil {
+		return *tokenFromFlags, nil
+	}
+
+	var token string
+	var err error
+	if cmd.Flags().Changed("token-file") {
+		token, err = readTokenFile(utils.GetPathFlagIfChanged(cmd, "token-file", ""))
+	} else {
+		token, err = readToken(os.Stdin, "stdin")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	tokenFromFlags = &token
+	return token, nil
+}
+
+// readTokenFile reads a token from the file, refusing files that are readable by all users
+func readTokenFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	// file permissions aren't meaningful on Windows
+	if !utils.IsWindows() && info.Mode().Perm()&0004 != 0 {
+		return "", fmt.Errorf("refusing to read token from world-readable file %s (mode %04o). restrict its permissions (e.g. chmod 600, or a Kubernetes secret defaultMode of 0400)", path, info.Mode().Perm())
+	}
+
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return readToken(file, path)
+}
+
+// readToken reads a token, trimming the trailing newline
+func readToken(reader io.Reader, source string) (string, error) {
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSuffix(strings.TrimSuffix(string(contents), "\n"), "\r")
+	if token == "" {
+		return "", fmt.Errorf("no token found in %s", source)
+	}
+	return token, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateToken(t *testing.T) {
+	secret := strings.Repeat("a1B2", 11)
+
+	for _, token := range []string{
+		"dp.pt." + secret,
+		"dp.ct." + secret,
+		"dp.st." + secret,
+		"dp.st.dev_personal." + secret,
+		"dp.sa." + secret,
+		"dp.scim." + secret,
+		"dp.audit." + secret,
+		// tokens without a prefix predate typed tokens
+		"0123456789abcdef",
+	} {
+		assert.NoError(t, ValidateToken(token), token)
+	}
+
+	err := ValidateToken("dp.st.dev." + secret[:20])
+	assert.EqualError(t, err, "service token is too short and may have been truncated. Ensure it was copied correctly")
+	assert.Error(t, ValidateToken("dp.pt."))
+	// unknown token types are left to the API
+	assert.NoError(t, ValidateToken("dp.xx."+secret))
+	assert.Error(t, ValidateToken("dp.pt."+secret+"\n"))
+	assert.Error(t, ValidateToken(" dp.pt."+secret))
+	assert.Error(t, ValidateToken("dp.pt.abc def"+secret))
+}
+
+func TestReadTokenFile(t *testing.T) {
+	token := "dp.st.dev." + strings.Repeat("a1B2", 11)
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "token")
+	assert.NoError(t, os.WriteFile(path, []byte(token+"\r\n"), 0600))
+	value, err := readTokenFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, token, value)
+
+	empty := filepath.Join(dir, "empty")
+	assert.NoError(t, os.WriteFile(empty, []byte("\n"), 0600))
+	_, err = readTokenFile(empty)
+	assert.Error(t, err)
+
+	_, err = readTokenFile(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+
+	if !utils.IsWindows() {
+		assert.NoError(t, os.Chmod(path, 0644))
+		_, err = readTokenFile(path)
+		assert.ErrorContains(t, err, "world-readable")
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// ConfigOptionValidators validate the value of each config option, keyed by option name
+var ConfigOptionValidators = map[string]func(value string) error{
+	models.ConfigToken.String():          ValidateToken,
+	models.ConfigAPIHost.String():        validateHostOption,
+	models.ConfigDashboardHost.String():  validateHostOption,
+	models.ConfigVerifyTLS.String():      validateBoolOption,
+	models.ConfigEnclaveProject.String(): validateNameOption,
+	models.ConfigEnclaveConfig.String():  validateNameOption,
+	models.ConfigCACert.String():         validateFileOption,
+}
+
+// namePattern the names of projects and configs (e.g. 'backend' or 'dev_personal')
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidateConfigOptionValue checks that the value is valid for the option. An empty value is always valid, as it unsets the option
+func ValidateConfigOptionValue(key string, value string) error {
+	validator, ok := ConfigOptionValidators[key]
+	if !ok {
+		return fmt.Errorf("invalid option %s", key)
+	}
+	if value == "" {
+		return nil
+	}
+	if err := validator(value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return nil
+}
+
+// freeFormOptions options whose values aren't validated when saved, as their format isn't guaranteed (e.g. legacy tokens)
+var freeFormOptions = []string{
+	models.ConfigToken.String(),
+	models.ConfigEnclaveProject.String(),
+	models.ConfigEnclaveConfig.String(),
+}
+
+// validateOptionValues checks that each option is known and, unless it's free-form, that its value is valid
+func validateOptionValues(options map[string]string) error {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	// report errors in a deterministic order
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !IsValidConfigOption(key) {
+			return fmt.Errorf("invalid option %s", key)
+		}
+		if utils.Contains(freeFormOptions, key) {
+			continue
+		}
+		if err := ValidateConfigOptionValue(key, options[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckRequiredOptions reports whether each of the named options (e.g. 'token' or 'project') resolves to a value in the config
+func CheckRequiredOptions(config models.ScopedOptions, names []string) ([]models.RequiredOption, error) {
+	options := models.ScopedOptionsMap(&config)
+
+	var required []models.RequiredOption
+	for _, name := range names {
+		option, ok := options[TranslateFriendlyOption(name)]
+		if !ok {
+			return nil, fmt.Errorf("invalid option %s", name)
+		}
+
+		requiredOption := models.RequiredOption{Name: name}
+		if strings.TrimSpace(option.Value) != "" {
+			requiredOption.Satisfied = true
+			requiredOption.Source = option.Source
+			requiredOption.Scope = option.Scope
+		}
+		required = append(required, requiredOption)
+	}
+	return required, nil
+}
+
+func validateHostOption(value string) error {
+	host, err := url.Parse(utils.BracketIPv6Host(value))
+	if err != nil {
+		return errors.New("must be a URL (e.g. https://api.doppler.com)")
+	}
+	if host.Scheme != "https" && host.Scheme != "http" {
+		return errors.New("must be a URL beginning with https:// (e.g. https://api.doppler.com)")
+	}
+	if host.Host == "" {
+		return errors.New("must be a URL that includes a host (e.g. https://api.doppler.com)")
+	}
+	if host.RawQuery != "" || host.Fragment != "" {
+		return errors.New("must be a URL without a query string or fragment")
+	}
+	return nil
+}
+
+func validateBoolOption(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return errors.New("must be true or false")
+	}
+	return nil
+}
+
+func validateNameOption(value string) error {
+	if !namePattern.MatchString(value) {
+		return errors.New("must contain only letters, numbers, '.', '-', and '_', and begin with a letter or number")
+	}
+	return nil
+}
+
+func validateFileOption(value string) error {
+	info, err := os.Stat(value)
+	if err != nil {
+		return errors.New("must be the path to an existing file")
+	}
+	if info.IsDir() {
+		return errors.New("must be the path to a file, not a directory")
+	}
+	return nil
+}
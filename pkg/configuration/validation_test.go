@@ -0,0 +1,88 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configuration
+
+import (
+	"testing"
+
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfigOptionValue(t *testing.T) {
+	valid := map[string][]string{
+		"token":           {"dp.st.dev." + "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "dp.xx." + "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "123", ""},
+		"api-host":        {"https://api.doppler.com", "http://localhost:8080", "https://[::1]:8443", "https://::1"},
+		"dashboard-host":  {"https://dashboard.doppler.com"},
+		"verify-tls":      {"true", "false", "0"},
+		"enclave.project": {"backend", "123", "my-project"},
+		"enclave.config":  {"dev", "dev_personal", "prd.aws"},
+		"ca-cert":         {"validation_test.go"},
+	}
+	for key, values := range valid {
+		for _, value := range values {
+			assert.NoError(t, ValidateConfigOptionValue(key, value), "%s=%s", key, value)
+		}
+	}
+
+	invalid := map[string][]string{
+		"token":           {"dp.st.short", "has space"},
+		"api-host":        {"not-a-url", "api.doppler.com", "ftp://api.doppler.com", "https://", "https://api.doppler.com?x=1"},
+		"dashboard-host":  {"dashboard.doppler.com"},
+		"verify-tls":      {"yes", "maybe"},
+		"enclave.project": {"my project", "-backend", "a/b"},
+		"enclave.config":  {"dev;rm"},
+		"ca-cert":         {"does-not-exist.pem", "."},
+	}
+	for key, values := range invalid {
+		for _, value := range values {
+			assert.Error(t, ValidateConfigOptionValue(key, value), "%s=%s", key, value)
+		}
+	}
+
+	assert.EqualError(t, ValidateConfigOptionValue("api-host", "not-a-url"), "invalid value for api-host: must be a URL beginning with https:// (e.g. https://api.doppler.com)")
+	assert.EqualError(t, ValidateConfigOptionValue("unknown", "value"), "invalid option unknown")
+}
+
+func TestValidateOptionValues(t *testing.T) {
+	assert.NoError(t, validateOptionValues(map[string]string{"api-host": "https://api.doppler.com", "verify-tls": "false"}))
+	// free-form options aren't validated
+	assert.NoError(t, validateOptionValues(map[string]string{"token": "has space", "enclave.project": "my project", "enclave.config": "dev;rm"}))
+
+	assert.EqualError(t, validateOptionValues(map[string]string{"enclave.project": "backend", "api-host": "api.doppler.com"}), "invalid value for api-host: must be a URL beginning with https:// (e.g. https://api.doppler.com)")
+	assert.ErrorContains(t, validateOptionValues(map[string]string{"verify-tls": "maybe"}), "invalid value for verify-tls")
+	assert.EqualError(t, validateOptionValues(map[string]string{"unknown": "value"}), "invalid option unknown")
+}
+
+func TestCheckRequiredOptions(t *testing.T) {
+	config := models.ScopedOptions{
+		Token:          models.ScopedOption{Value: "dp.st.xxxx", Scope: "/", Source: models.EnvironmentSource.String()},
+		EnclaveProject: models.ScopedOption{Value: "backend", Scope: "/app", Source: models.ConfigFileSource.String()},
+		EnclaveConfig:  models.ScopedOption{Value: " ", Scope: "/", Source: models.FlagSource.String()},
+	}
+
+	required, err := CheckRequiredOptions(config, []string{"token", "project", "config", "enclave.project"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RequiredOption{
+		{Name: "token", Satisfied: true, Source: models.EnvironmentSource.String(), Scope: "/"},
+		{Name: "project", Satisfied: true, Source: models.ConfigFileSource.String(), Scope: "/app"},
+		{Name: "config", Satisfied: false},
+		{Name: "enclave.project", Satisfied: true, Source: models.ConfigFileSource.String(), Scope: "/app"},
+	}, required)
+
+	_, err = CheckRequiredOptions(config, []string{"token", "tokn"})
+	assert.EqualError(t, err, "invalid option tokn")
+}
@@ -16,6 +16,12 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"time"
+
 	"github.com/DopplerHQ/cli/pkg/http"
 	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/utils"
@@ -35,3 +41,122 @@ func GetActivityLogIDs(config models.ScopedOptions) ([]string, Error) {
 	}
 	return ids, Error{}
 }
+
+// maxActivityLogPages bounds GetAllActivityLogs in case the API keeps returning pages
+const maxActivityLogPages = 1000
+
+// GetAllActivityLogs fetches every page of activity logs, starting at page 1, until an empty or short page is returned
+func GetAllActivityLogs(config models.ScopedOptions, number int) ([]models.ActivityLog, Error) {
+	utils.RequireValue("token", config.Token.Value)
+
+	var logs []models.ActivityLog
+	for page := 1; page <= maxActivityLogPages; page++ {
+		// each request is already retried by the http package, so any error here is final
+		pageLogs, err := http.GetActivityLogs(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, page, number)
+		if !err.IsNil() {
+			return nil, Error{Err: err.Unwrap(), Message: fmt.Sprintf("%s (page %d)", err.Message, page)}
+		}
+
+		logs = append(logs, pageLogs...)
+		if len(pageLogs) == 0 || (number > 0 && len(pageLogs) < number) {
+			return logs, Error{}
+		}
+	}
+
+	return nil, Error{Err: fmt.Errorf("activity logs exceeded %d pages", maxActivityLogPages), Message: "Unable to fetch all activity logs"}
+}
+
+// maxFollowActivityLogPages caps the pages fetched per poll when following activity logs
+const maxFollowActivityLogPages = 10
+
+// FollowActivityLogs polls for activity logs every interval until the context is cancelled, calling the handler with the logs created
+// since the previous poll, oldest first. The first poll returns the latest page of logs. Failed polls are retried with an exponential backoff
+func FollowActivityLogs(ctx context.Context, config models.ScopedOptions, number int, interval time.Duration, handler func([]models.ActivityLog)) {
+	utils.RequireValue("token", config.Token.Value)
+
+	lastSeenID := ""
+	failures := 0
+	for {
+		logs, err := newActivityLogs(config, number, lastSeenID)
+		if ctx.Err() != nil {
+			return
+		}
+
+		delay := interval
+		if !err.IsNil() {
+			if backoff := utils.ExponentialBackoff(interval, failures); backoff > delay {
+				delay = backoff
+			}
+			failures++
+			utils.LogWarning(fmt.Sprintf("%s: %s. Retrying in %s", err.Message, err.Unwrap(), delay))
+		} else {
+			failures = 0
+			if len(logs) > 0 {
+				lastSeenID = logs[0].ID
+				// the API returns the newest logs first
+				for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+					logs[i], logs[j] = logs[j], logs[i]
+				}
+				handler(logs)
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// newActivityLogs fetches the logs newer than the last seen log, newest first. Without a last seen log, the latest page is returned
+func newActivityLogs(config models.ScopedOptions, number int, lastSeenID string) ([]models.ActivityLog, Error) {
+	var logs []models.ActivityLog
+	for page := 1; page <= maxFollowActivityLogPages; page++ {
+		pageLogs, err := http.GetActivityLogs(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, page, number)
+		if !err.IsNil() {
+			return nil, Error{Err: err.Unwrap(), Message: err.Message}
+		}
+
+		if lastSeenID == "" {
+			return pageLogs, Error{}
+		}
+
+		for _, log := range pageLogs {
+			if log.ID == lastSeenID {
+				return logs, Error{}
+			}
+			logs = append(logs, log)
+		}
+		if len(pageLogs) == 0 || (number > 0 && len(pageLogs) < number) {
+			return logs, Error{}
+		}
+	}
+
+	utils.LogWarning(fmt.Sprintf("More than %d pages of activity logs were created since the last poll; older logs were skipped", maxFollowActivityLogPages))
+	return logs, Error{}
+}
+
+// ActivityLogsCSV renders activity logs as RFC 4180 CSV, with a header row
+func ActivityLogsCSV(logs []models.ActivityLog) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.UseCRLF = true
+
+	if err := writer.Write([]string{"id", "created_at", "user_email", "project", "config", "text"}); err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		if err := writer.Write([]string{log.ID, log.CreatedAt, log.User.Email, log.EnclaveProject, log.EnclaveConfig, log.Text}); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
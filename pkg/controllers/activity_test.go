@@ -0,0 +1,180 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	dopplerHTTP "github.com/DopplerHQ/cli/pkg/http"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityLogsCSV(t *testing.T) {
+	logs := []models.ActivityLog{
+		{ID: "1", CreatedAt: "2026-01-02T03:04:05.000Z", User: models.User{Email: "a@example.com"}, EnclaveProject: "backend", EnclaveConfig: "dev", Text: "Added secret \"API_KEY\", FOO"},
+		{ID: "2", CreatedAt: "2026-01-03T03:04:05.000Z", Text: "multi\nline"},
+	}
+
+	body, err := ActivityLogsCSV(logs)
+	assert.NoError(t, err)
+	assert.Equal(t, "id,created_at,user_email,project,config,text\r\n"+
+		"1,2026-01-02T03:04:05.000Z,a@example.com,backend,dev,\"Added secret \"\"API_KEY\"\", FOO\"\r\n"+
+		"2,2026-01-03T03:04:05.000Z,,,,\"multi\r\nline\"\r\n", string(body))
+}
+
+func TestActivityLogOutputJSON(t *testing.T) {
+	logs := []models.ActivityLog{
+		{ID: "1", CreatedAt: "2026-01-02T03:04:05.000Z", User: models.User{Email: "a@example.com"}, EnclaveProject: "backend", EnclaveEnvironment: "dev", EnclaveConfig: "dev_personal", Text: "Added secret"},
+	}
+
+	body, err := json.Marshal(models.ConvertActivityLogsToOutput(logs))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"id":"1","text":"Added secret","html":"","created_at":"2026-01-02T03:04:05.000Z","project":"backend","environment":"dev","config":"dev_personal","user":{"email":"a@example.com","name":"","username":"","profile_image_url":""}}]`, string(body))
+
+	body, err = json.Marshal(models.ConvertActivityLogsToOutput(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(body))
+}
+
+func TestGetAllActivityLogs(t *testing.T) {
+	attempts := dopplerHTTP.RequestAttempts
+	dopplerHTTP.RequestAttempts = 1
+	t.Cleanup(func() { dopplerHTTP.RequestAttempts = attempts })
+
+	// 5 logs served per_page at a time, with an error from failPage onward
+	failPage := 0
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		requested = append(requested, r.URL.Query().Get("page")+"/"+r.URL.Query().Get("per_page"))
+		w.Header().Set("content-type", "application/json")
+		if failPage != 0 && page >= failPage {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"messages":["Internal error"],"success":false}`))
+			return
+		}
+		logs := []map[string]interface{}{}
+		for i := (page - 1) * perPage; i < page*perPage && i < 5; i++ {
+			logs = append(logs, map[string]interface{}{"id": fmt.Sprint(i), "text": "log"})
+		}
+		body, _ := json.Marshal(map[string]interface{}{"logs": logs})
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	config := models.ScopedOptions{
+		APIHost: models.ScopedOption{Value: server.URL},
+		Token:   models.ScopedOption{Value: "dp.st.valid"},
+	}
+
+	logs, err := GetAllActivityLogs(config, 2)
+	assert.True(t, err.IsNil())
+	assert.Len(t, logs, 5)
+	assert.Equal(t, "4", logs[4].ID)
+	assert.Equal(t, []string{"1/2", "2/2", "3/2"}, requested)
+
+	// an exact multiple of the page size ends on the empty page
+	requested = nil
+	logs, err = GetAllActivityLogs(config, 5)
+	assert.True(t, err.IsNil())
+	assert.Len(t, logs, 5)
+	assert.Equal(t, []string{"1/5", "2/5"}, requested)
+
+	// a failed page stops pagination rather than being retried
+	requested = nil
+	failPage = 2
+	logs, err = GetAllActivityLogs(config, 2)
+	assert.False(t, err.IsNil())
+	assert.Nil(t, logs)
+	assert.Contains(t, err.Message, "(page 2)")
+	assert.Equal(t, []string{"1/2", "2/2"}, requested)
+}
+
+func TestFollowActivityLogs(t *testing.T) {
+	attempts := dopplerHTTP.RequestAttempts
+	dopplerHTTP.RequestAttempts = 1
+	t.Cleanup(func() { dopplerHTTP.RequestAttempts = attempts })
+
+	// logs are served newest first, per_page at a time. the poll after a log is added fails once
+	var mutex sync.Mutex
+	count := 3
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		polls++
+		w.Header().Set("content-type", "application/json")
+		if polls == 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"messages":["Internal error"],"success":false}`))
+			return
+		}
+		if polls == 2 {
+			// more new logs than fit on a page
+			count += 3
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		logs := []map[string]interface{}{}
+		for i := count - 1 - (page-1)*perPage; i >= 0 && i > count-1-page*perPage; i-- {
+			logs = append(logs, map[string]interface{}{"id": fmt.Sprint(i), "text": "log"})
+		}
+		body, _ := json.Marshal(map[string]interface{}{"logs": logs})
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	config := models.ScopedOptions{
+		APIHost: models.ScopedOption{Value: server.URL},
+		Token:   models.ScopedOption{Value: "dp.st.valid"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var ids []string
+	done := make(chan struct{})
+	go func() {
+		FollowActivityLogs(ctx, config, 2, 10*time.Millisecond, func(logs []models.ActivityLog) {
+			for _, log := range logs {
+				ids = append(ids, log.ID)
+			}
+			if len(ids) >= 5 {
+				cancel()
+			}
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		cancel()
+		t.Fatal("FollowActivityLogs didn't stop when cancelled")
+	}
+
+	// the latest page, then each new log once, oldest first
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, ids)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/configuration"
+	"github.com/DopplerHQ/cli/pkg/crypto"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// CompletionCacheTTL how long cached completion values are used before being refetched
+var CompletionCacheTTL = 60 * time.Second
+
+const completionCacheFileName = "completion-cache.json"
+
+type completionCacheEntry struct {
+	Values    []string  `json:"values"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CompletionCachePath the path of the completion cache file
+func CompletionCachePath() string {
+	return filepath.Join(configuration.UserConfigDir, completionCacheFileName)
+}
+
+// CachedProjectIDs the project IDs used by shell completion, cached to reduce completion latency
+func CachedProjectIDs(config models.ScopedOptions) ([]string, Error) {
+	return cachedCompletionValues(config, "projects", func() ([]string, Error) {
+		return GetProjectIDs(config)
+	})
+}
+
+// CachedConfigNames the config names used by shell completion, cached to reduce completion latency
+func CachedConfigNames(config models.ScopedOptions) ([]string, Error) {
+	return cachedCompletionValues(config, "configs", func() ([]string, Error) {
+		return GetConfigNames(config)
+	})
+}
+
+// ClearCompletionCache deletes all cached completion values. this should be called when projects or configs are created or deleted
+func ClearCompletionCache() {
+	path := CompletionCachePath()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		utils.LogDebug(fmt.Sprintf("Unable to delete completion cache %s", path))
+		utils.LogDebugError(err)
+	}
+}
+
+func cachedCompletionValues(config models.ScopedOptions, kind string, fetch func() ([]string, Error)) ([]string, Error) {
+	// the token is hashed so it's never written to disk
+	key := crypto.Hash(strings.Join([]string{config.APIHost.Value, config.Token.Value, kind, config.EnclaveProject.Value}, ":"))
+
+	path := CompletionCachePath()
+	cache := map[string]completionCacheEntry{}
+	if contents, err := ioutil.ReadFile(path); err == nil { // #nosec G304
+		if err := json.Unmarshal(contents, &cache); err != nil {
+			utils.LogDebug("Unable to parse completion cache")
+			cache = map[string]completionCacheEntry{}
+		}
+	}
+
+	now := time.Now()
+	if entry, ok := cache[key]; ok && now.Before(entry.ExpiresAt) {
+		utils.LogDebug(fmt.Sprintf("Using cached completion values for %s", kind))
+		return entry.Values, Error{}
+	}
+
+	values, err := fetch()
+	if !err.IsNil() {
+		return nil, err
+	}
+
+	// drop expired entries so the file doesn't grow unbounded
+	for k, entry := range cache {
+		if !now.Before(entry.ExpiresAt) {
+			delete(cache, k)
+		}
+	}
+	cache[key] = completionCacheEntry{Values: values, ExpiresAt: now.Add(CompletionCacheTTL)}
+
+	// failing to write the cache shouldn't prevent completion
+	if contents, e := json.Marshal(cache); e == nil {
+		if e := utils.WriteFile(path, contents, utils.RestrictedFilePerms()); e != nil {
+			utils.LogDebug("Unable to write completion cache")
+			utils.LogDebugError(e)
+		}
+	}
+
+	return values, Error{}
+}
@@ -16,6 +16,11 @@ limitations under the License.
 package controllers
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/DopplerHQ/cli/pkg/http"
 	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/utils"
@@ -89,3 +94,52 @@ func GetEnvironmentIDs(config models.ScopedOptions) ([]string, Error) {
 	}
 	return ids, Error{}
 }
+
+// ConfigReadyPollInterval how often WaitForConfigReady checks the config
+var ConfigReadyPollInterval = 2 * time.Second
+
+// WaitForConfigReady polls the config until it exists and has no missing variables, returning its final state
+func WaitForConfigReady(opts models.ScopedOptions, config string, timeout time.Duration) (models.ConfigInfo, Error) {
+	utils.RequireValue("token", opts.Token.Value)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		info, err := http.GetConfig(opts.APIHost.Value, utils.GetBool(opts.VerifyTLS.Value, true), opts.Token.Value, opts.EnclaveProject.Value, config)
+		// a newly created config may not be found yet
+		if !err.IsNil() && err.Code != 404 {
+			return models.ConfigInfo{}, Error{Err: err.Unwrap(), Message: err.Message}
+		}
+
+		var pending string
+		if !err.IsNil() {
+			pending = "config not found"
+		} else if len(info.MissingVariables) > 0 {
+			pending = fmt.Sprintf("missing secrets: %s", strings.Join(info.MissingVariables, ", "))
+		} else {
+			return info, Error{}
+		}
+
+		if !time.Now().Add(ConfigReadyPollInterval).Before(deadline) {
+			return models.ConfigInfo{}, Error{Err: fmt.Errorf("config %s is not ready (%s)", config, pending), Message: fmt.Sprintf("Timed out after %s waiting for config to be ready", timeout)}
+		}
+		utils.LogDebug(fmt.Sprintf("Config %s is not ready (%s), checking again in %s", config, pending, ConfigReadyPollInterval))
+		time.Sleep(ConfigReadyPollInterval)
+	}
+}
+
+// ErrConfigLocked is returned when secrets would be written to a locked config
+var ErrConfigLocked = errors.New("config is locked")
+
+// CheckConfigUnlocked returns ErrConfigLocked if the config is locked
+func CheckConfigUnlocked(config models.ScopedOptions) Error {
+	utils.RequireValue("token", config.Token.Value)
+
+	info, err := http.GetConfig(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value)
+	if !err.IsNil() {
+		return Error{Err: err.Unwrap(), Message: err.Message}
+	}
+	if info.Locked {
+		return Error{Err: ErrConfigLocked, Message: fmt.Sprintf("Refusing to modify secrets in locked config %s", info.Name)}
+	}
+	return Error{}
+}
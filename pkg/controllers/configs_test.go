@@ -0,0 +1,97 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"io"
+	nethttp "net/http"
+	"testing"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/http"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(req *nethttp.Request) (*nethttp.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	return f(req)
+}
+
+// mockResponses responds to successive requests with the specified statuses and bodies, repeating the last one
+func mockResponses(t *testing.T, statusCodes []int, bodies []string) *int {
+	count := 0
+	original := http.Transport
+	http.Transport = roundTripFunc(func(req *nethttp.Request) (*nethttp.Response, error) {
+		i := count
+		if i >= len(bodies) {
+			i = len(bodies) - 1
+		}
+		count++
+		return &nethttp.Response{
+			StatusCode: statusCodes[i],
+			Header:     nethttp.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(bodies[i])),
+			Request:    req,
+		}, nil
+	})
+	t.Cleanup(func() { http.Transport = original })
+	return &count
+}
+
+func TestWaitForConfigReady(t *testing.T) {
+	originalInterval := ConfigReadyPollInterval
+	ConfigReadyPollInterval = time.Millisecond
+	t.Cleanup(func() { ConfigReadyPollInterval = originalInterval })
+
+	opts := models.ScopedOptions{Token: models.ScopedOption{Value: "dp.st.token"}, APIHost: models.ScopedOption{Value: "https://api.example.com"}, EnclaveProject: models.ScopedOption{Value: "backend"}}
+
+	count := mockResponses(t, []int{404, 200, 200}, []string{
+		`{"messages":["Could not find requested config"],"success":false}`,
+		`{"config":{"name":"dev","missing_variables":["PORT"]}}`,
+		`{"config":{"name":"dev"}}`,
+	})
+	info, err := WaitForConfigReady(opts, "dev", time.Second)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, "dev", info.Name)
+	assert.Equal(t, 3, *count)
+
+	mockResponses(t, []int{200}, []string{`{"config":{"name":"dev","missing_variables":["HOST","PORT"]}}`})
+	_, err = WaitForConfigReady(opts, "dev", 10*time.Millisecond)
+	assert.False(t, err.IsNil())
+	assert.EqualError(t, err.Unwrap(), "config dev is not ready (missing secrets: HOST, PORT)")
+
+	mockResponses(t, []int{403}, []string{`{"messages":["Forbidden"],"success":false}`})
+	_, err = WaitForConfigReady(opts, "dev", time.Second)
+	assert.False(t, err.IsNil())
+	assert.Equal(t, "Unable to fetch configs", err.Message)
+}
+
+func TestCheckConfigUnlocked(t *testing.T) {
+	opts := models.ScopedOptions{Token: models.ScopedOption{Value: "dp.st.token"}, APIHost: models.ScopedOption{Value: "https://api.example.com"}, EnclaveProject: models.ScopedOption{Value: "backend"}, EnclaveConfig: models.ScopedOption{Value: "prd"}}
+
+	mockResponses(t, []int{200}, []string{`{"config":{"name":"prd","locked":false}}`})
+	err := CheckConfigUnlocked(opts)
+	assert.True(t, err.IsNil())
+
+	mockResponses(t, []int{200}, []string{`{"config":{"name":"prd","locked":true}}`})
+	err = CheckConfigUnlocked(opts)
+	assert.ErrorIs(t, err.Unwrap(), ErrConfigLocked)
+	assert.Equal(t, "Refusing to modify secrets in locked config prd", err.Message)
+}
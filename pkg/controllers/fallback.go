@@ -21,8 +21,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/DopplerHQ/cli/pkg/crypto"
 	"github.com/DopplerHQ/cli/pkg/models"
@@ -33,6 +35,29 @@ import (
 // DefaultMetadataDir the directory containing metadata files
 var DefaultMetadataDir string
 
+// envFallbackLine matches a dotenv assignment (e.g. 'KEY="value"'). an encrypted fallback file never starts with one,
+// as it begins with its version number or its encoding followed by a colon
+var envFallbackLine = regexp.MustCompile(`^(export\s+)?[A-Za-z_][A-Za-z0-9_.-]*\s*=`)
+
+// EnvFallbackFileHeader the first line of a dotenv fallback file, which identifies it when the config has no secrets
+const EnvFallbackFileHeader = "# Doppler fallback file. Its secrets are not encrypted"
+
+// IsEnvFallbackFile whether the fallback file was written in the dotenv format, rather than as the encrypted API response.
+// an empty file is neither, and fails to decrypt rather than being read as a config without secrets
+func IsEnvFallbackFile(contents []byte) bool {
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == EnvFallbackFileHeader {
+			return true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return envFallbackLine.MatchString(line)
+	}
+	return false
+}
+
 func GenerateFallbackFileHash(token string, project string, config string, format models.SecretsFormat, nameTransformer *models.SecretsNameTransformer, secretNames []string) string {
 	parts := []string{token}
 	if project != "" && config != "" {
@@ -100,13 +125,14 @@ func MetadataFile(path string) (models.SecretsFileMetadata, Error) {
 }
 
 // WriteMetadataFile writes the contents of the metadata file
-func WriteMetadataFile(path string, etag string, hash string) Error {
-	utils.LogDebug(fmt.Sprintf("Writing ETag to metadata file %s", path))
+func WriteMetadataFile(path string, etag string, hash string, fetchedAt time.Time) Error {
+	utils.LogDebug(fmt.Sprintf("Writing metadata file %s", path))
 
 	metadata := models.SecretsFileMetadata{
-		Version: "1",
-		ETag:    etag,
-		Hash:    hash,
+		Version:   "1",
+		ETag:      etag,
+		Hash:      hash,
+		FetchedAt: fetchedAt.UTC(),
 	}
 
 	metadataBytes, err := yaml.Marshal(metadata)
@@ -134,6 +160,14 @@ func SecretsCacheFile(path string, passphrase string) (map[string]string, Error)
 		return nil, Error{Err: err, Message: "Unable to read cache file"}
 	}
 
+	if IsEnvFallbackFile(response) {
+		secrets, err := utils.ParseDotenv(string(response))
+		if err != nil {
+			return nil, Error{Err: err, Message: "Unable to parse cache file"}
+		}
+		return secrets, Error{}
+	}
+
 	utils.LogDebug("Decrypting cache file")
 	decryptedSecrets, err := crypto.Decrypt(passphrase, response)
 	if err != nil {
@@ -67,3 +67,14 @@ func TestGenerateFallbackFileHash(t *testing.T) {
 	}
 
 }
+
+func TestIsEnvFallbackFile(t *testing.T) {
+	assert.True(t, IsEnvFallbackFile([]byte(EnvFallbackFileHeader+"\nA=\"1\"\n")))
+	// a config without secrets
+	assert.True(t, IsEnvFallbackFile([]byte(EnvFallbackFileHeader+"\n")))
+	assert.True(t, IsEnvFallbackFile([]byte("# comment\nexport A=1\n")))
+
+	assert.False(t, IsEnvFallbackFile([]byte("")))
+	assert.False(t, IsEnvFallbackFile([]byte("\n# comment\n")))
+	assert.False(t, IsEnvFallbackFile([]byte("2:base64:abc")))
+}
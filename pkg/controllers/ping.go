@@ -0,0 +1,58 @@
+/*
+Copyright © 2021 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/http"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// Ping makes a lightweight authenticated request to the API, reporting whether the API is reachable and the token is valid
+func Ping(config models.ScopedOptions) models.PingResult {
+	result := models.PingResult{
+		APIHost:     config.APIHost.Value,
+		Scope:       config.Token.Scope,
+		TokenSource: config.Token.Source,
+	}
+
+	start := time.Now()
+	info, err := http.GetActorInfo(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value)
+	latency := time.Since(start)
+
+	if !err.IsNil() {
+		result.Error = err.Unwrap().Error()
+	}
+
+	// a request that failed without a response never reached the API
+	if err.IsNil() || err.Code != 0 {
+		result.Reachable = true
+		result.LatencyMs = latency.Milliseconds()
+	}
+
+	if err.IsNil() {
+		result.Authenticated = true
+		result.Workplace = info.Workplace.Name
+	} else if config.Token.Value == "" {
+		result.Error = "no token is configured. Run 'doppler login' or specify a token via --token or DOPPLER_TOKEN"
+	} else if err.Code == 401 || err.Code == 403 {
+		result.Error = "the token is invalid, expired, or revoked: " + result.Error
+	}
+
+	return result
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2021 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dopplerHTTP "github.com/DopplerHQ/cli/pkg/http"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer dp.st.valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"messages":["Invalid Service token"],"success":false}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"workplace":{"name":"Acme","slug":"acme"},"type":"service_token"}`))
+	}))
+	defer server.Close()
+
+	config := models.ScopedOptions{
+		APIHost: models.ScopedOption{Value: server.URL},
+		Token:   models.ScopedOption{Value: "dp.st.valid", Scope: "/app", Source: models.ConfigFileSource.String()},
+	}
+	result := Ping(config)
+	assert.True(t, result.Reachable)
+	assert.True(t, result.Authenticated)
+	assert.Equal(t, "Acme", result.Workplace)
+	assert.Equal(t, "/app", result.Scope)
+	assert.Empty(t, result.Error)
+
+	config.Token.Value = "dp.st.revoked"
+	result = Ping(config)
+	assert.True(t, result.Reachable)
+	assert.False(t, result.Authenticated)
+	assert.Contains(t, result.Error, "the token is invalid, expired, or revoked: Invalid Service token")
+
+	config.Token.Value = ""
+	result = Ping(config)
+	assert.True(t, result.Reachable)
+	assert.False(t, result.Authenticated)
+	assert.Contains(t, result.Error, "no token is configured")
+
+	// don't retry the unreachable API
+	attempts := dopplerHTTP.RequestAttempts
+	dopplerHTTP.RequestAttempts = 1
+	t.Cleanup(func() { dopplerHTTP.RequestAttempts = attempts })
+
+	server.Close()
+	config.Token.Value = "dp.st.valid"
+	result = Ping(config)
+	assert.False(t, result.Reachable)
+	assert.False(t, result.Authenticated)
+	assert.Zero(t, result.LatencyMs)
+	assert.NotEmpty(t, result.Error)
+}
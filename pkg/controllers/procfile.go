@@ -0,0 +1,225 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"gopkg.in/gookit/color.v1"
+)
+
+// ProcfileProcess a process type defined in a Procfile (e.g. 'web: bundle exec rails server')
+type ProcfileProcess struct {
+	Name    string
+	Command string
+}
+
+var procfileLineRegex = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.*)$`)
+
+// procfileColors the colors used to prefix each process's output, in order
+var procfileColors = []color.Color{color.Cyan, color.Yellow, color.Green, color.Magenta, color.Blue, color.Red}
+
+// procfileStopTimeout how long processes have to exit after being asked to stop before they're killed
+const procfileStopTimeout = 10 * time.Second
+
+// ParseProcfile parses the process types defined in a Procfile, in the order they're defined. Blank lines and comments are ignored
+func ParseProcfile(data []byte) ([]ProcfileProcess, error) {
+	var processes []ProcfileProcess
+	names := map[string]bool{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		matches := procfileLineRegex.FindStringSubmatch(line)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid Procfile entry on line %d. Entries must be in the format 'name: command'", lineNumber)
+		}
+		name, command := matches[1], strings.TrimSpace(matches[2])
+		if command == "" {
+			return nil, fmt.Errorf("process %s on line %d has no command", name, lineNumber)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("process %s is defined more than once", name)
+		}
+		names[name] = true
+
+		processes = append(processes, ProcfileProcess{Name: name, Command: command})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(processes) == 0 {
+		return nil, fmt.Errorf("the Procfile doesn't define any processes")
+	}
+	return processes, nil
+}
+
+// RunProcfile runs each process with the environment, prefixing each line of its output with the process's name.
+// When a process exits, the others are stopped and its exit code is returned. With keepAlive, the others keep running
+// and the first non-zero exit code is returned once all have exited. An error is only returned when a process can't be started
+func RunProcfile(processes []ProcfileProcess, env []string, keepAlive bool, forwardSignals bool, opts utils.CommandOptions) (int, Error) {
+	width := 0
+	for _, process := range processes {
+		width = max(width, len(process.Name))
+	}
+
+	type processExit struct {
+		name     string
+		exitCode int
+	}
+	exits := make(chan processExit, len(processes))
+
+	// a single mutex ensures lines from different processes aren't interleaved
+	var outputMutex sync.Mutex
+	var cmds []*exec.Cmd
+	for i, process := range processes {
+		prefix := procfileColors[i%len(procfileColors)].Render(fmt.Sprintf("%-*s | ", width, process.Name))
+		stdout := &prefixWriter{prefix: prefix, out: os.Stdout, mutex: &outputMutex}
+		stderr := &prefixWriter{prefix: prefix, out: os.Stderr, mutex: &outputMutex}
+
+		cmd, err := utils.RunCommandString(process.Command, env, nil, stdout, stderr, forwardSignals, opts)
+		if err != nil {
+			stopProcesses(cmds)
+			return 1, Error{Err: err, Message: fmt.Sprintf("Unable to start process %s", process.Name)}
+		}
+		utils.LogDebug(fmt.Sprintf("Started process %s (%d)", process.Name, cmd.Process.Pid))
+		cmds = append(cmds, cmd)
+
+		name := process.Name
+		go func() {
+			exitCode, err := utils.WaitCommand(cmd)
+			if err != nil {
+				utils.LogDebugError(err)
+			}
+			stdout.Flush()
+			stderr.Flush()
+			exits <- processExit{name: name, exitCode: exitCode}
+		}()
+	}
+
+	exitCode := 0
+	stopping := false
+	for remaining := len(cmds); remaining > 0; remaining-- {
+		exit := <-exits
+		if stopping {
+			utils.LogDebug(fmt.Sprintf("Process %s stopped with code %d", exit.name, exit.exitCode))
+		} else {
+			utils.Log(fmt.Sprintf("Process %s exited with code %d", exit.name, exit.exitCode))
+		}
+
+		if keepAlive {
+			if exitCode == 0 {
+				exitCode = exit.exitCode
+			}
+			continue
+		}
+
+		// the remaining processes are stopped, so their exit codes don't reflect the failure
+		if !stopping {
+			stopping = true
+			exitCode = exit.exitCode
+			if remaining > 1 {
+				utils.Log("Stopping all processes")
+				stopProcesses(cmds)
+			}
+		}
+	}
+
+	return exitCode, Error{}
+}
+
+// stopProcesses asks each running process to exit, killing any that haven't after a timeout
+func stopProcesses(cmds []*exec.Cmd) {
+	for _, cmd := range cmds {
+		if !utils.IsProcessRunning(cmd.Process) {
+			continue
+		}
+
+		utils.LogDebug(fmt.Sprintf("Sending SIGTERM to process %d", cmd.Process.Pid))
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			// SIGTERM isn't supported on Windows
+			utils.LogDebugError(err)
+			if err := cmd.Process.Kill(); err != nil {
+				utils.LogDebugError(err)
+			}
+			continue
+		}
+
+		process := cmd.Process
+		time.AfterFunc(procfileStopTimeout, func() {
+			if utils.IsProcessRunning(process) {
+				utils.LogDebug(fmt.Sprintf("Process %d has not exited; sending SIGKILL", process.Pid))
+				if err := process.Kill(); err != nil {
+					utils.LogDebugError(err)
+				}
+			}
+		})
+	}
+}
+
+// prefixWriter writes each complete line with a prefix, buffering partial lines until they're completed or flushed
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	mutex  *sync.Mutex
+	buffer []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buffer = append(w.buffer, p...)
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.buffer[:i+1])
+		w.buffer = w.buffer[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any partial line that remains
+func (w *prefixWriter) Flush() {
+	if len(w.buffer) > 0 {
+		w.writeLine(append(w.buffer, '\n'))
+		w.buffer = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	// #nosec G104
+	w.out.Write(append([]byte(w.prefix), line...))
+}
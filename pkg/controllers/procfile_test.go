@@ -0,0 +1,77 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProcfile(t *testing.T) {
+	processes, err := ParseProcfile([]byte("# local dev\nweb: bundle exec rails server -p $PORT\n\nworker:   node worker.js  \nclock_1: ./clock\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []ProcfileProcess{
+		{Name: "web", Command: "bundle exec rails server -p $PORT"},
+		{Name: "worker", Command: "node worker.js"},
+		{Name: "clock_1", Command: "./clock"},
+	}, processes)
+
+	for data, expected := range map[string]string{
+		"web bundle exec rails":     "invalid Procfile entry on line 1. Entries must be in the format 'name: command'",
+		"web: a\nweb: b":            "process web is defined more than once",
+		"web:":                      "process web on line 1 has no command",
+		"# nothing to run\n\n":      "the Procfile doesn't define any processes",
+		"web: a\nmy worker: node x": "invalid Procfile entry on line 2. Entries must be in the format 'name: command'",
+	} {
+		_, err := ParseProcfile([]byte(data))
+		assert.EqualError(t, err, expected, data)
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out strings.Builder
+	w := &prefixWriter{prefix: "web | ", out: &out, mutex: &sync.Mutex{}}
+
+	_, _ = w.Write([]byte("one\ntw"))
+	_, _ = w.Write([]byte("o\nthree"))
+	assert.Equal(t, "web | one\nweb | two\n", out.String())
+
+	w.Flush()
+	assert.Equal(t, "web | one\nweb | two\nweb | three\n", out.String())
+}
+
+func TestRunProcfile(t *testing.T) {
+	if utils.IsWindows() {
+		t.Skip("processes are run via sh")
+	}
+
+	// the other processes are stopped when one exits
+	start := time.Now()
+	exitCode, err := RunProcfile([]ProcfileProcess{{Name: "web", Command: "sleep 30"}, {Name: "worker", Command: "exit 3"}}, nil, false, true, utils.CommandOptions{})
+	assert.True(t, err.IsNil())
+	assert.Equal(t, 3, exitCode)
+	assert.Less(t, time.Since(start), procfileStopTimeout)
+
+	// with keepAlive, all processes run to completion
+	exitCode, err = RunProcfile([]ProcfileProcess{{Name: "web", Command: "exit 0"}, {Name: "worker", Command: "sleep 0.2; exit 4"}}, nil, true, true, utils.CommandOptions{})
+	assert.True(t, err.IsNil())
+	assert.Equal(t, 4, exitCode)
+}
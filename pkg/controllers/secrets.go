@@ -16,18 +16,25 @@ limitations under the License.
 package controllers
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"github.com/DopplerHQ/cli/pkg/crypto"
 	"github.com/DopplerHQ/cli/pkg/http"
@@ -35,6 +42,7 @@ import (
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/spf13/cobra"
 	"gopkg.in/gookit/color.v1"
+	"gopkg.in/yaml.v3"
 )
 
 // Documentation about potentially dangerous secret names can be found here: https://docs.doppler.com/docs/accessing-secrets#injection
@@ -72,8 +80,22 @@ type FallbackOptions struct {
 	Exclusive          bool
 	ExitOnWriteFailure bool
 	Passphrase         string
+	// MaxAge the max age of a fallback file that may be used. 0 for no limit
+	MaxAge time.Duration
+	// WarnOnStale whether to warn rather than fail when the fallback file exceeds MaxAge
+	WarnOnStale bool
+	// Format the format the fallback file is written in, one of FallbackFormats. empty is json.
+	// Either format is detected when reading the file
+	Format string
 }
 
+// FallbackStaleActions the supported behaviors when a fallback file exceeds its max age
+var FallbackStaleActions = []string{"error", "warn"}
+
+// FallbackFormats the formats the fallback file can be written in. json encrypts the API response, while env
+// writes an unencrypted dotenv file that can be read by other tools
+var FallbackFormats = []string{"json", "env"}
+
 type MountOptions struct {
 	Enable   bool
 	Format   string
@@ -82,6 +104,20 @@ type MountOptions struct {
 	MaxReads int
 }
 
+// SecretsFDEnvVar the environment variable containing the file descriptor secrets are passed on
+const SecretsFDEnvVar = "DOPPLER_CLI_SECRETS_FD"
+
+// MaxSecretsFD the highest file descriptor secrets can be passed on. each lower descriptor is allocated (and closed) in
+// the process, and descriptors beyond the usual open file limit can't be inherited anyway
+const MaxSecretsFD = 1024
+
+// FDOptions passing secrets to the process via an inherited file descriptor
+type FDOptions struct {
+	Enable bool
+	FD     int
+	Format string
+}
+
 func GetSecrets(config models.ScopedOptions) (map[string]models.ComputedSecret, Error) {
 	utils.RequireValue("token", config.Token.Value)
 
@@ -100,7 +136,7 @@ func GetSecrets(config models.ScopedOptions) (map[string]models.ComputedSecret,
 func SetSecrets(config models.ScopedOptions, changeRequests []models.ChangeRequest) (map[string]models.ComputedSecret, Error) {
 	utils.RequireValue("token", config.Token.Value)
 
-	secrets, err := http.SetSecrets(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value, nil, changeRequests)
+	secrets, err := http.SetSecrets(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value, nil, changeRequests, "")
 	if !err.IsNil() {
 		return nil, Error{Err: err.Unwrap(), Message: err.Message}
 	}
@@ -108,6 +144,327 @@ func SetSecrets(config models.ScopedOptions, changeRequests []models.ChangeReque
 	return secrets, Error{}
 }
 
+// ErrConfigChanged is returned when a conditional write fails because the config has changed since it was read
+var ErrConfigChanged = errors.New("config has changed since it was read")
+
+// SetSecretsInBatches sets secrets via sequential requests of at most batchSize secrets each.
+// Batches are not atomic; if a batch fails, all prior batches will have already been applied.
+// If ifMatch is specified, it's only sent with the first batch, as subsequent batches modify the config themselves.
+func SetSecretsInBatches(config models.ScopedOptions, secrets map[string]interface{}, batchSize int, ifMatch string) (map[string]models.ComputedSecret, Error) {
+	utils.RequireValue("token", config.Token.Value)
+
+	return setInBatches(config, sortedSecretNames(secrets), batchSize, ifMatch, func(batch []string) (map[string]interface{}, []models.ChangeRequest) {
+		batchSecrets := map[string]interface{}{}
+		for _, name := range batch {
+			batchSecrets[name] = secrets[name]
+		}
+		return batchSecrets, nil
+	})
+}
+
+// sortedSecretNames the names of the secrets, sorted so that batches are deterministic
+func sortedSecretNames(secrets map[string]interface{}) []string {
+	var names []string
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setInBatches sets the secrets named in each batch of at most batchSize names via sequential requests, whose body is built by batchRequest
+func setInBatches(config models.ScopedOptions, names []string, batchSize int, ifMatch string, batchRequest func(batch []string) (map[string]interface{}, []models.ChangeRequest)) (map[string]models.ComputedSecret, Error) {
+	batches := BatchSecretNames(names, batchSize)
+	result := map[string]models.ComputedSecret{}
+	for i, batch := range batches {
+		if len(batches) > 1 {
+			utils.LogDebug(fmt.Sprintf("Setting batch %d of %d (%d secrets)", i+1, len(batches), len(batch)))
+		}
+
+		batchSecrets, changeRequests := batchRequest(batch)
+
+		batchIfMatch := ""
+		if i == 0 {
+			batchIfMatch = ifMatch
+		}
+
+		response, err := http.SetSecrets(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value, batchSecrets, changeRequests, batchIfMatch)
+		if !err.IsNil() {
+			e := err.Unwrap()
+			if err.Code == 412 {
+				e = fmt.Errorf("%w: %v", ErrConfigChanged, e)
+			}
+			message := err.Message
+			if len(batches) > 1 {
+				message = fmt.Sprintf("%s. Failed on batch %d of %d; %d prior batch(es) were applied.\nSecrets in the failed batch:\n- %s", err.Message, i+1, len(batches), i, strings.Join(batch, "\n- "))
+			}
+			return nil, Error{Err: e, Message: message}
+		}
+
+		// each response includes the config's full set of secrets, so later batches are the most current
+		for name, secret := range response {
+			result[name] = secret
+		}
+	}
+
+	return result, Error{}
+}
+
+// BatchSecretNames splits names into batches of at most batchSize names. A batchSize less than 1 results in a single batch
+func BatchSecretNames(names []string, batchSize int) [][]string {
+	if batchSize < 1 || len(names) <= batchSize {
+		return [][]string{names}
+	}
+
+	var batches [][]string
+	for start := 0; start < len(names); start += batchSize {
+		end := start + batchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		batches = append(batches, names[start:end])
+	}
+	return batches
+}
+
+// GetSecretsETag returns the ETag of the config's secrets, which changes whenever the config is modified.
+// The ETag is empty when the API (or a proxy in front of it) doesn't return one
+func GetSecretsETag(config models.ScopedOptions) (string, Error) {
+	utils.RequireValue("token", config.Token.Value)
+
+	_, headers, _, err := http.DownloadSecrets(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value, models.JSON, nil, "", 0, nil)
+	if !err.IsNil() {
+		return "", Error{Err: err.Unwrap(), Message: err.Message}
+	}
+
+	etag := headers.Get("etag")
+	if etag == "" {
+		utils.LogDebug("API response does not contain ETag")
+	}
+	return etag, Error{}
+}
+
+// GetSecretsByTags returns secrets with any of the specified tags. The secrets are filtered locally, as the API doesn't filter by tag
+func GetSecretsByTags(config models.ScopedOptions, tags []string) (map[string]models.ComputedSecret, Error) {
+	secrets, err := GetSecrets(config)
+	if !err.IsNil() {
+		return nil, err
+	}
+
+	return FilterSecretsByTags(secrets, tags), Error{}
+}
+
+// FilterSecretsByTags returns the secrets that have any of the specified tags
+func FilterSecretsByTags(secrets map[string]models.ComputedSecret, tags []string) map[string]models.ComputedSecret {
+	filtered := map[string]models.ComputedSecret{}
+	for name, secret := range secrets {
+		for _, tag := range tags {
+			if utils.Contains(secret.Tags, tag) {
+				filtered[name] = secret
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// FilterLocalSecrets returns the secrets that are overridden in the config rather than inherited from the root config.
+// It's an error if the API didn't report whether a secret is inherited
+func FilterLocalSecrets(secrets map[string]models.ComputedSecret) (map[string]models.ComputedSecret, Error) {
+	filtered := map[string]models.ComputedSecret{}
+	for name, secret := range secrets {
+		if secret.Inherited == nil {
+			return nil, Error{Err: fmt.Errorf("the API didn't report whether secret %s is inherited", name), Message: "Unable to determine which secrets are overridden locally"}
+		}
+		if !*secret.Inherited {
+			filtered[name] = secret
+		}
+	}
+	return filtered, Error{}
+}
+
+// SetSecretsWithTags sets secrets and assigns them the specified tags, replacing any existing tags.
+// Like SetSecretsInBatches, the secrets are set via sequential requests of at most batchSize secrets each
+func SetSecretsWithTags(config models.ScopedOptions, secrets map[string]interface{}, tags []string, batchSize int, ifMatch string) (map[string]models.ComputedSecret, Error) {
+	existingNames, err := GetSecretNames(config)
+	if !err.IsNil() {
+		return nil, err
+	}
+
+	return setInBatches(config, sortedSecretNames(secrets), batchSize, ifMatch, func(batch []string) (map[string]interface{}, []models.ChangeRequest) {
+		var changeRequests []models.ChangeRequest
+		for _, name := range batch {
+			changeRequest := models.ChangeRequest{Name: name, Tags: tags}
+			// new secrets don't have an original name
+			if utils.Contains(existingNames, name) {
+				changeRequest.OriginalName = name
+			}
+			if value, ok := secrets[name].(string); ok {
+				changeRequest.Value = value
+			}
+			changeRequests = append(changeRequests, changeRequest)
+		}
+		return nil, changeRequests
+	})
+}
+
+// ErrUnexpectedSecretValue is returned when a compare-and-set fails because the secret doesn't have the expected value
+var ErrUnexpectedSecretValue = errors.New("secret does not have the expected value")
+
+// CompareAndSetSecret sets the secret to value only if its current raw value equals expected. A nil expected value
+// requires that the secret doesn't exist. The write is conditioned on the config's version, so a concurrent change
+// made after the value was compared causes it to fail with ErrConfigChanged. If the API doesn't return a version,
+// the write is unconditional
+func CompareAndSetSecret(config models.ScopedOptions, name string, expected *string, value string) (map[string]models.ComputedSecret, Error) {
+	// the version is read before the secrets so that any change made after the comparison invalidates it
+	etag, err := GetSecretsETag(config)
+	if !err.IsNil() {
+		return nil, err
+	}
+
+	secrets, err := GetSecrets(config)
+	if !err.IsNil() {
+		return nil, err
+	}
+	if e := CheckExpectedSecretValue(secrets, name, expected); e != nil {
+		return nil, Error{Err: e, Message: fmt.Sprintf("Unable to set secret %s", name)}
+	}
+
+	response, httpErr := http.SetSecrets(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value, map[string]interface{}{name: value}, nil, etag)
+	if !httpErr.IsNil() {
+		e := httpErr.Unwrap()
+		if httpErr.Code == 412 {
+			e = fmt.Errorf("%w: %v", ErrConfigChanged, e)
+		}
+		return nil, Error{Err: e, Message: httpErr.Message}
+	}
+
+	return response, Error{}
+}
+
+// SecretReplacement a secret whose raw value changes when replacing text in it
+type SecretReplacement struct {
+	Name   string `json:"name"`
+	Before string `json:"-"`
+	After  string `json:"-"`
+	// the values with everything but the matched and replaced text masked
+	MaskedBefore string `json:"before"`
+	MaskedAfter  string `json:"after"`
+}
+
+// ReplaceSecretValues replaces each match of pattern in the raw values of the config's secrets. Nothing is written unless apply is set,
+// in which case all changed secrets are set in a single request that fails if the config changed after it was read
+// (when the API returns the config's version)
+func ReplaceSecretValues(config models.ScopedOptions, pattern *regexp.Regexp, replacement string, literal bool, apply bool) ([]SecretReplacement, Error) {
+	// the version is read before the secrets so that any change made after they're read invalidates it
+	etag, err := GetSecretsETag(config)
+	if !err.IsNil() {
+		return nil, err
+	}
+
+	secrets, err := GetSecrets(config)
+	if !err.IsNil() {
+		return nil, err
+	}
+
+	replacements := FindSecretReplacements(secrets, pattern, replacement, literal)
+	if !apply || len(replacements) == 0 {
+		return replacements, Error{}
+	}
+
+	changes := map[string]interface{}{}
+	for _, r := range replacements {
+		changes[r.Name] = r.After
+	}
+	_, httpErr := http.SetSecrets(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value, changes, nil, etag)
+	if !httpErr.IsNil() {
+		e := httpErr.Unwrap()
+		if httpErr.Code == 412 {
+			e = fmt.Errorf("%w: %v", ErrConfigChanged, e)
+		}
+		return nil, Error{Err: e, Message: httpErr.Message}
+	}
+
+	return replacements, Error{}
+}
+
+// FindSecretReplacements replaces each match of pattern in the secrets' raw values, sorted by name. Unless literal is set,
+// references to the pattern's capture groups (e.g. '$1') in the replacement are expanded. Unchanged and restricted secrets are omitted
+func FindSecretReplacements(secrets map[string]models.ComputedSecret, pattern *regexp.Regexp, replacement string, literal bool) []SecretReplacement {
+	var names []string
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var replacements []SecretReplacement
+	for _, name := range names {
+		value := secrets[name].RawValue
+		if value == nil {
+			continue
+		}
+
+		var after, maskedBefore, maskedAfter strings.Builder
+		end := 0
+		for _, match := range pattern.FindAllStringSubmatchIndex(*value, -1) {
+			replaced := replacement
+			if !literal {
+				replaced = string(pattern.ExpandString(nil, replacement, *value, match))
+			}
+			unchanged := (*value)[end:match[0]]
+			if unchanged != "" {
+				maskedBefore.WriteString("****")
+				maskedAfter.WriteString("****")
+			}
+			after.WriteString(unchanged)
+			after.WriteString(replaced)
+			maskedBefore.WriteString((*value)[match[0]:match[1]])
+			maskedAfter.WriteString(replaced)
+			end = match[1]
+		}
+		after.WriteString((*value)[end:])
+		if end < len(*value) {
+			maskedBefore.WriteString("****")
+			maskedAfter.WriteString("****")
+		}
+
+		if after.String() == *value {
+			continue
+		}
+		replacements = append(replacements, SecretReplacement{
+			Name:         name,
+			Before:       *value,
+			After:        after.String(),
+			MaskedBefore: maskedBefore.String(),
+			MaskedAfter:  maskedAfter.String(),
+		})
+	}
+	return replacements
+}
+
+// CheckExpectedSecretValue returns ErrUnexpectedSecretValue unless the secret's raw value equals expected.
+// A nil expected value requires that the secret doesn't exist
+func CheckExpectedSecretValue(secrets map[string]models.ComputedSecret, name string, expected *string) error {
+	secret, exists := secrets[name]
+	if expected == nil {
+		if exists {
+			return fmt.Errorf("%w: secret %s already exists", ErrUnexpectedSecretValue, name)
+		}
+		return nil
+	}
+
+	if !exists {
+		return fmt.Errorf("%w: secret %s does not exist", ErrUnexpectedSecretValue, name)
+	}
+	if secret.RawValue == nil {
+		return fmt.Errorf("unable to read the value of secret %s", name)
+	}
+	if *secret.RawValue != *expected {
+		return fmt.Errorf("%w: secret %s has a different value", ErrUnexpectedSecretValue, name)
+	}
+	return nil
+}
+
 func GetSecretNames(config models.ScopedOptions) ([]string, Error) {
 	utils.RequireValue("token", config.Token.Value)
 
@@ -121,6 +478,450 @@ func GetSecretNames(config models.ScopedOptions) ([]string, Error) {
 	return secretsNames, Error{}
 }
 
+// secretHistoryMaxPages the max number of config log pages to scan when reconstructing a secret's history
+const secretHistoryMaxPages = 10
+
+// secretHistoryPageSize the number of config logs to fetch per page
+const secretHistoryPageSize = 100
+
+// GetSecretHistory reconstructs a secret's change history from the config's audit logs.
+// Only the diff entries affecting the specified secret are retained on each log.
+func GetSecretHistory(config models.ScopedOptions, name string, maxEntries int) ([]models.ConfigLog, Error) {
+	utils.RequireValue("token", config.Token.Value)
+
+	var history []models.ConfigLog
+	for page := 1; page <= secretHistoryMaxPages; page++ {
+		logs, err := http.GetConfigLogs(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value, page, secretHistoryPageSize)
+		if !err.IsNil() {
+			return nil, Error{Err: err.Unwrap(), Message: err.Message}
+		}
+
+		history = append(history, FilterConfigLogsBySecret(logs, name)...)
+		if maxEntries > 0 && len(history) >= maxEntries {
+			return history[0:maxEntries], Error{}
+		}
+
+		if len(logs) < secretHistoryPageSize {
+			return history, Error{}
+		}
+	}
+
+	utils.LogDebug(fmt.Sprintf("Stopped scanning config logs after %d pages", secretHistoryMaxPages))
+	return history, Error{}
+}
+
+// FilterConfigLogsBySecret returns the logs that modified the specified secret
+func FilterConfigLogsBySecret(logs []models.ConfigLog, name string) []models.ConfigLog {
+	var filtered []models.ConfigLog
+	for _, log := range logs {
+		var diffs []models.LogDiff
+		for _, diff := range log.Diff {
+			if diff.Name == name {
+				diffs = append(diffs, diff)
+			}
+		}
+
+		if len(diffs) > 0 {
+			log.Diff = diffs
+			filtered = append(filtered, log)
+		}
+	}
+	return filtered
+}
+
+// FillSecretAges sets UpdatedAt for secrets the API didn't provide timestamps for, using the most recent config log
+// that changed each secret. Secrets not changed in the scanned logs are left without a timestamp
+func FillSecretAges(config models.ScopedOptions, secrets map[string]models.ComputedSecret) Error {
+	remaining := map[string]bool{}
+	for name, secret := range secrets {
+		if secret.UpdatedAt == "" {
+			remaining[name] = true
+		}
+	}
+	if len(remaining) == 0 {
+		return Error{}
+	}
+
+	utils.RequireValue("token", config.Token.Value)
+
+	utils.LogDebug(fmt.Sprintf("Determining when %d secret(s) last changed from the config's logs", len(remaining)))
+	for page := 1; page <= secretHistoryMaxPages && len(remaining) > 0; page++ {
+		logs, err := http.GetConfigLogs(config.APIHost.Value, utils.GetBool(config.VerifyTLS.Value, true), config.Token.Value, config.EnclaveProject.Value, config.EnclaveConfig.Value, page, secretHistoryPageSize)
+		if !err.IsNil() {
+			return Error{Err: err.Unwrap(), Message: err.Message}
+		}
+
+		for name, changedAt := range SecretLastChangedFromLogs(logs) {
+			if remaining[name] {
+				secret := secrets[name]
+				secret.UpdatedAt = changedAt
+				secrets[name] = secret
+				delete(remaining, name)
+			}
+		}
+
+		if len(logs) < secretHistoryPageSize {
+			break
+		}
+	}
+
+	if len(remaining) > 0 {
+		utils.LogDebug(fmt.Sprintf("Unable to determine when %d secret(s) last changed", len(remaining)))
+	}
+	return Error{}
+}
+
+// SecretLastChangedFromLogs returns when each secret was last changed, keyed by name. Logs must be ordered newest first
+func SecretLastChangedFromLogs(logs []models.ConfigLog) map[string]string {
+	changed := map[string]string{}
+	for _, log := range logs {
+		for _, diff := range log.Diff {
+			if _, ok := changed[diff.Name]; !ok {
+				changed[diff.Name] = log.CreatedAt
+			}
+		}
+	}
+	return changed
+}
+
+// SortSecretNamesByAge returns the names of the secrets ordered from least to most recently changed.
+// Secrets without a valid UpdatedAt timestamp are listed last
+func SortSecretNamesByAge(secrets map[string]models.ComputedSecret) []string {
+	updatedAt := map[string]time.Time{}
+	var names []string
+	for name, secret := range secrets {
+		names = append(names, name)
+		if t, err := time.Parse(time.RFC3339, secret.UpdatedAt); err == nil {
+			updatedAt[name] = t
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		ti, iOk := updatedAt[names[i]]
+		tj, jOk := updatedAt[names[j]]
+		if iOk != jOk {
+			return iOk
+		}
+		if iOk && !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+var secretReferenceRegex = regexp.MustCompile(`\$\{[^}]+\}`)
+
+// SecretReferences returns the secret references (e.g. '${OTHER_SECRET}') contained in the value
+func SecretReferences(value string) []string {
+	return secretReferenceRegex.FindAllString(value, -1)
+}
+
+// ReferencedSecrets returns the names of the set secrets whose values were stored as references,
+// determined by whether the returned computed value differs from the raw value
+func ReferencedSecrets(secrets map[string]interface{}, response map[string]models.ComputedSecret) []string {
+	var names []string
+	for name, value := range secrets {
+		stringValue, ok := value.(string)
+		if !ok || len(SecretReferences(stringValue)) == 0 {
+			continue
+		}
+
+		secret, ok := response[name]
+		if !ok || secret.RawValue == nil || secret.ComputedValue == nil {
+			continue
+		}
+		if *secret.RawValue != *secret.ComputedValue {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DependentSecrets maps each of the named secrets to the other secrets whose raw value references it (e.g. '${NAME}').
+// Secrets that are themselves named are ignored, as are restricted raw values, which can't be scanned
+func DependentSecrets(secrets map[string]models.ComputedSecret, names []string) map[string][]string {
+	dependents := map[string][]string{}
+	for name, secret := range secrets {
+		if secret.RawValue == nil || utils.Contains(names, name) {
+			continue
+		}
+
+		for _, reference := range SecretReferences(*secret.RawValue) {
+			referencedName := strings.TrimSuffix(strings.TrimPrefix(reference, "${"), "}")
+			if utils.Contains(names, referencedName) && !utils.Contains(dependents[referencedName], name) {
+				dependents[referencedName] = append(dependents[referencedName], name)
+			}
+		}
+	}
+
+	for _, names := range dependents {
+		sort.Strings(names)
+	}
+	return dependents
+}
+
+// ParseSecretReference returns the project, config, and name that a secret reference (e.g. '${API_KEY}') refers to.
+// '${environment.NAME}' refers to the environment's root config, whose name matches the environment, and
+// '${project.environment.NAME}' to a config in another project
+func ParseSecretReference(reference string, project string, config string) (string, string, string) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(reference, "${"), "}"), ".")
+	switch len(parts) {
+	case 2:
+		return project, parts[0], parts[1]
+	case 3:
+		return parts[0], parts[1], parts[2]
+	default:
+		return project, config, strings.Join(parts, ".")
+	}
+}
+
+// TraceSecretResolution traces how the secret's value resolves by following the references in its raw value depth-first.
+// Each config's secrets are fetched once via fetch. Problems with referenced secrets (e.g. a missing secret or a circular
+// reference) are reported on their step rather than as an error
+func TraceSecretResolution(name string, project string, config string, fetch func(project string, config string) (map[string]models.ComputedSecret, Error)) ([]models.SecretResolutionStep, Error) {
+	fetched := map[string]map[string]models.ComputedSecret{}
+	fetchErrors := map[string]Error{}
+	var steps []models.SecretResolutionStep
+
+	var trace func(step models.SecretResolutionStep, path []string) Error
+	trace = func(step models.SecretResolutionStep, path []string) Error {
+		configKey := step.Project + "." + step.Config
+		secrets, ok := fetched[configKey]
+		if !ok {
+			err, failed := fetchErrors[configKey]
+			if !failed {
+				secrets, err = fetch(step.Project, step.Config)
+				if err.IsNil() {
+					fetched[configKey] = secrets
+				} else {
+					fetchErrors[configKey] = err
+				}
+			}
+			if !err.IsNil() {
+				if step.Depth == 0 {
+					return err
+				}
+				step.Error = fmt.Sprintf("unable to fetch secrets: %s", err.Message)
+				steps = append(steps, step)
+				return Error{}
+			}
+		}
+
+		secret, ok := secrets[step.Name]
+		if !ok {
+			if step.Depth == 0 {
+				return Error{Err: fmt.Errorf("Could not find requested secret: %s", step.Name)}
+			}
+			step.Error = "secret does not exist"
+			steps = append(steps, step)
+			return Error{}
+		}
+
+		step.RawValue = secret.RawValue
+		step.ComputedValue = secret.ComputedValue
+		step.Inherited = secret.Inherited
+
+		secretKey := configKey + "." + step.Name
+		if utils.Contains(path, secretKey) {
+			step.Error = "circular reference"
+			steps = append(steps, step)
+			return Error{}
+		}
+		if secret.RawValue == nil {
+			step.Error = "raw value is restricted, so its references can't be traced"
+			steps = append(steps, step)
+			return Error{}
+		}
+		steps = append(steps, step)
+
+		path = append(path, secretKey)
+		for _, reference := range SecretReferences(*secret.RawValue) {
+			referenceProject, referenceConfig, referenceName := ParseSecretReference(reference, step.Project, step.Config)
+			next := models.SecretResolutionStep{Depth: step.Depth + 1, Reference: reference, Name: referenceName, Project: referenceProject, Config: referenceConfig}
+			// copy the path so sibling references don't share it
+			if err := trace(next, append([]string{}, path...)); !err.IsNil() {
+				return err
+			}
+		}
+		return Error{}
+	}
+
+	if err := trace(models.SecretResolutionStep{Name: name, Project: project, Config: config}, nil); !err.IsNil() {
+		return nil, err
+	}
+	return steps, Error{}
+}
+
+// ResolveSecretNameIgnoreCase returns the existing secret name that matches the name case-insensitively.
+// An exact match is preferred; otherwise it's an error if multiple names differ only by case.
+// The name is returned unchanged if nothing matches
+func ResolveSecretNameIgnoreCase(name string, names []string) (string, Error) {
+	var matches []string
+	for _, existing := range names {
+		if existing == name {
+			return name, Error{}
+		}
+		if strings.EqualFold(existing, name) {
+			matches = append(matches, existing)
+		}
+	}
+
+	if len(matches) == 0 {
+		return name, Error{}
+	}
+	if len(matches) > 1 {
+		sort.Strings(matches)
+		return "", Error{Err: fmt.Errorf("Secret name %s is ambiguous; it matches %s", name, strings.Join(matches, ", "))}
+	}
+	return matches[0], Error{}
+}
+
+// FilterReferencingSecrets returns the secrets whose raw value contains a secret reference (e.g. '${OTHER_SECRET}').
+// Secrets with a restricted raw value are excluded
+func FilterReferencingSecrets(secrets map[string]models.ComputedSecret) map[string]models.ComputedSecret {
+	filtered := map[string]models.ComputedSecret{}
+	for name, secret := range secrets {
+		if secret.RawValue != nil && len(SecretReferences(*secret.RawValue)) > 0 {
+			filtered[name] = secret
+		}
+	}
+	return filtered
+}
+
+// RawAndComputedSecrets maps each secret name to both its raw and computed value.
+// Restricted values are output as null.
+func RawAndComputedSecrets(secrets map[string]models.ComputedSecret) map[string]map[string]*string {
+	values := map[string]map[string]*string{}
+	for name, secret := range secrets {
+		values[name] = map[string]*string{
+			"raw":      secret.RawValue,
+			"computed": secret.ComputedValue,
+		}
+	}
+	return values
+}
+
+// RawSecretValues maps each secret name to its raw value, failing if any value is restricted
+func RawSecretValues(secrets map[string]models.ComputedSecret) (map[string]string, error) {
+	values := map[string]string{}
+	for name, secret := range secrets {
+		if secret.RawValue == nil {
+			return nil, fmt.Errorf("the raw value of secret %s is restricted", name)
+		}
+		values[name] = *secret.RawValue
+	}
+	return values, nil
+}
+
+// configMetadataSecretNames secrets added by the API that describe the config rather than its contents
+var configMetadataSecretNames = []string{"DOPPLER_PROJECT", "DOPPLER_CONFIG", "DOPPLER_ENVIRONMENT"}
+
+// InjectConfigMetadata adds the DOPPLER_PROJECT and DOPPLER_CONFIG variables describing the resolved config when the
+// secrets don't already include them (e.g. when using --only-secrets). Returns a warning for each included value that
+// differs from the resolved config, in which case the included value is kept
+func InjectConfigMetadata(secrets map[string]string, project string, config string) []string {
+	var warnings []string
+	for name, value := range map[string]string{"DOPPLER_PROJECT": project, "DOPPLER_CONFIG": config} {
+		if value == "" {
+			continue
+		}
+
+		existing, ok := secrets[name]
+		if !ok {
+			secrets[name] = value
+		} else if existing != value {
+			warnings = append(warnings, fmt.Sprintf("Secret %s (%s) doesn't match the resolved config (%s); using the secret's value", name, existing, value))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// RemoveConfigMetadata removes the variables describing the config (e.g. DOPPLER_PROJECT) from the secrets
+func RemoveConfigMetadata(secrets map[string]string) {
+	for _, name := range configMetadataSecretNames {
+		delete(secrets, name)
+	}
+}
+
+// IsConfigMetadata whether the secret is a variable describing the config (e.g. DOPPLER_PROJECT)
+func IsConfigMetadata(name string) bool {
+	return utils.Contains(configMetadataSecretNames, name)
+}
+
+// HashSecrets computes a stable SHA-256 hash of the secrets' names and values, returning the hash and the number of secrets hashed.
+// Config metadata secrets are excluded so that configs with identical contents produce identical hashes
+func HashSecrets(secrets map[string]models.ComputedSecret, raw bool) (string, int, Error) {
+	var names []string
+	for name := range secrets {
+		if !utils.Contains(configMetadataSecretNames, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// encode as JSON so that names and values can't be ambiguously concatenated
+	pairs := [][]*string{}
+	for _, name := range names {
+		name := name
+		value := secrets[name].ComputedValue
+		if raw {
+			value = secrets[name].RawValue
+		}
+		pairs = append(pairs, []*string{&name, value})
+	}
+
+	data, err := json.Marshal(pairs)
+	if err != nil {
+		return "", 0, Error{Err: err, Message: "Unable to encode secrets"}
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), len(names), Error{}
+}
+
+// DiffSecrets returns the secrets that are only in one of the configs or whose raw values differ, sorted by name.
+// Secrets that are restricted in both configs are returned as unknown, as they can't be compared.
+// Config metadata secrets (e.g. DOPPLER_CONFIG) are excluded, as they always differ
+func DiffSecrets(first map[string]models.ComputedSecret, second map[string]models.ComputedSecret) []models.SecretDifference {
+	names := map[string]bool{}
+	for name := range first {
+		names[name] = true
+	}
+	for name := range second {
+		names[name] = true
+	}
+
+	differences := []models.SecretDifference{}
+	for name := range names {
+		if utils.Contains(configMetadataSecretNames, name) {
+			continue
+		}
+
+		firstSecret, inFirst := first[name]
+		secondSecret, inSecond := second[name]
+		difference := models.SecretDifference{Name: name, First: firstSecret.RawValue, Second: secondSecret.RawValue}
+		switch {
+		case !inSecond:
+			difference.Status = models.SecretOnlyFirst
+		case !inFirst:
+			difference.Status = models.SecretOnlySecond
+		case firstSecret.RawValue == nil && secondSecret.RawValue == nil:
+			difference.Status = models.SecretUnknown
+		case firstSecret.RawValue == nil || secondSecret.RawValue == nil || *firstSecret.RawValue != *secondSecret.RawValue:
+			difference.Status = models.SecretChanged
+		default:
+			continue
+		}
+		differences = append(differences, difference)
+	}
+
+	sort.Slice(differences, func(i, j int) bool { return differences[i].Name < differences[j].Name })
+	return differences
+}
+
 // SecretsToBytes converts secrets to byte array
 func SecretsToBytes(secrets map[string]string, format string, templateBody string) ([]byte, Error) {
 	if format == models.TemplateMountFormat {
@@ -150,6 +951,39 @@ func SecretsToBytes(secrets map[string]string, format string, templateBody strin
 	return nil, Error{Err: fmt.Errorf("invalid mount format. Valid formats are %s", models.SecretsMountFormats)}
 }
 
+// EncryptForGPGRecipients encrypts data to the public keys of the recipients (e.g. email addresses or key IDs),
+// which are read from the user's GPG keyring
+func EncryptForGPGRecipients(data []byte, recipients []string, armored bool) ([]byte, Error) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		return nil, Error{Err: err, Message: "Unable to find gpg, which is needed to read public keys from your GPG keyring"}
+	}
+
+	var keys []byte
+	for _, recipient := range recipients {
+		// #nosec G204 nosemgrep: semgrep_configs.prohibit-exec-command
+		key, err := exec.Command("gpg", "--batch", "--export", "--", recipient).Output()
+		if err != nil {
+			return nil, Error{Err: err, Message: fmt.Sprintf("Unable to export the public key of %s from your GPG keyring", recipient)}
+		}
+		// gpg exits successfully with no output when no keys match
+		if len(key) == 0 {
+			return nil, Error{Err: fmt.Errorf("no public key found in your GPG keyring for %s", recipient)}
+		}
+		keys = append(keys, key...)
+	}
+
+	entities, err := crypto.ReadPGPPublicKeys(keys)
+	if err != nil {
+		return nil, Error{Err: err, Message: "Unable to parse GPG public keys"}
+	}
+
+	encrypted, err := crypto.EncryptPGP(entities, data, armored)
+	if err != nil {
+		return nil, Error{Err: err, Message: "Unable to encrypt secrets to GPG recipients"}
+	}
+	return encrypted, Error{}
+}
+
 // MountSecrets mounts
 func MountSecrets(secrets []byte, mountPath string, maxReads int) (string, func(), Error) {
 	if !utils.SupportsNamedPipes {
@@ -326,6 +1160,135 @@ func CheckForDangerousSecretNames(secrets map[string]string) error {
 	return nil
 }
 
+// CoercedSecret a secret whose non-string value was converted to a string
+type CoercedSecret struct {
+	Name string
+	// Type the value's original type (e.g. 'number')
+	Type  string
+	Value string
+}
+
+// ParseStructuredSecrets parses a JSON or YAML map of secrets, converting non-string values (numbers, booleans, null, objects,
+// and arrays) to strings and returning which secrets were converted. With strict, non-string values are an error instead.
+func ParseStructuredSecrets(data []byte, format string, strict bool) (map[string]string, []CoercedSecret, error) {
+	values := map[string]interface{}{}
+	switch format {
+	case "json":
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		// preserve numbers as written (e.g. '5432' rather than '5432.0')
+		decoder.UseNumber()
+		if err := decoder.Decode(&values); err != nil {
+			return nil, nil, err
+		}
+	case "yaml":
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported format %s", format)
+	}
+
+	secrets := map[string]string{}
+	var coerced []CoercedSecret
+	var invalid []string
+	for name, value := range values {
+		if s, ok := value.(string); ok {
+			secrets[name] = s
+			continue
+		}
+
+		var valueType, stringValue string
+		switch v := value.(type) {
+		case nil:
+			valueType = "null"
+		case bool:
+			valueType = "boolean"
+			stringValue = fmt.Sprint(v)
+		case json.Number, int, int64, uint64, float64:
+			valueType = "number"
+			stringValue = fmt.Sprint(v)
+		default:
+			valueType = "object"
+			if _, isArray := v.([]interface{}); isArray {
+				valueType = "array"
+			}
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, nil, fmt.Errorf("unable to convert value of %s to a string: %w", name, err)
+			}
+			stringValue = string(encoded)
+		}
+
+		if strict {
+			invalid = append(invalid, fmt.Sprintf("%s (%s)", name, valueType))
+			continue
+		}
+		secrets[name] = stringValue
+		coerced = append(coerced, CoercedSecret{Name: name, Type: valueType, Value: stringValue})
+	}
+
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return nil, nil, fmt.Errorf("the following secrets have non-string values:\n- %s", strings.Join(invalid, "\n- "))
+	}
+
+	sort.Slice(coerced, func(i, j int) bool { return coerced[i].Name < coerced[j].Name })
+	return secrets, coerced, nil
+}
+
+// ParseDeploySecrets parses secrets that were fetched ahead of time (e.g. via 'doppler secrets download --no-file'),
+// which must be a JSON object mapping each secret's name to its string value, or a dotenv file (e.g. an env format fallback file)
+func ParseDeploySecrets(data []byte) (map[string]string, Error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, Error{Err: errors.New("no secrets were provided"), Message: "Unable to parse secrets"}
+	}
+
+	var secrets map[string]string
+	var err error
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		secrets, _, err = ParseStructuredSecrets(data, "json", true)
+	} else {
+		secrets, err = utils.ParseDotenv(string(data))
+	}
+	if err != nil {
+		return nil, Error{Err: err, Message: "Unable to parse secrets. Expected a JSON object of secret names and string values (e.g. {\"KEY\":\"value\"}) or a dotenv file"}
+	}
+
+	for name := range secrets {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, "=\x00") {
+			return nil, Error{Err: fmt.Errorf("invalid secret name %q", name), Message: "Unable to parse secrets"}
+		}
+	}
+
+	return secrets, Error{}
+}
+
+const secretValueEnvPrefix = "env:"
+
+// ResolveSecretValue resolves values of the form 'env:NAME' to the value of environment variable NAME.
+// A leading backslash (e.g. '\\env:NAME') escapes the prefix and yields the literal value 'env:NAME'.
+func ResolveSecretValue(value string) (string, error) {
+	if strings.HasPrefix(value, "\\"+secretValueEnvPrefix) {
+		return strings.TrimPrefix(value, "\\"), nil
+	}
+
+	if !strings.HasPrefix(value, secretValueEnvPrefix) {
+		return value, nil
+	}
+
+	name := strings.TrimPrefix(value, secretValueEnvPrefix)
+	if name == "" {
+		return "", errors.New("environment variable name must be specified after 'env:'")
+	}
+
+	envValue, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return envValue, nil
+}
+
 func ValidateSecrets(secrets map[string]string, secretsToInclude []string, exitOnMissingIncludedSecrets bool, mountOptions MountOptions) {
 	if len(secretsToInclude) > 0 {
 		missingSecrets := MissingSecrets(secrets, secretsToInclude)
@@ -349,6 +1312,38 @@ func ValidateSecrets(secrets map[string]string, secretsToInclude []string, exitO
 	}
 }
 
+// ClearEnvAllowlist environment variables kept when the environment is cleared, as many programs don't work without them
+var ClearEnvAllowlist = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "LANG", "TZ", "TMPDIR",
+	// required by most Windows programs
+	"SYSTEMROOT", "WINDIR", "COMSPEC", "PATHEXT", "TEMP", "TMP", "USERPROFILE"}
+
+// ClearEnv removes all variables from the environment except those in ClearEnvAllowlist and keep
+func ClearEnv(originalEnv []string, keep []string) []string {
+	allowed := map[string]bool{}
+	for _, name := range append(append([]string{}, ClearEnvAllowlist...), keep...) {
+		// variable names are case-insensitive on Windows
+		if utils.IsWindows() {
+			name = strings.ToUpper(name)
+		}
+		allowed[name] = true
+	}
+
+	env := []string{}
+	for _, envVar := range originalEnv {
+		name := strings.SplitN(envVar, "=", 2)[0]
+		if utils.IsWindows() {
+			name = strings.ToUpper(name)
+		}
+		if allowed[name] {
+			env = append(env, envVar)
+		}
+	}
+	return env
+}
+
+// ReservedSecretNames secrets that aren't injected into the environment, as overwriting these variables would break the command's shell
+var ReservedSecretNames = []string{"PATH", "PS1", "HOME"}
+
 func PrepareSecrets(dopplerSecrets map[string]string, originalEnv []string, preserveEnv string, mountOptions MountOptions) ([]string, func()) {
 	env := []string{}
 	secrets := map[string]string{}
@@ -371,11 +1366,10 @@ func PrepareSecrets(dopplerSecrets map[string]string, originalEnv []string, pres
 		// export path to mounted file
 		env = append(env, fmt.Sprintf("%s=%s", "DOPPLER_CLI_SECRETS_PATH", mountPath))
 	} else {
-		// remove any reserved keys from secrets
-		reservedKeys := []string{"PATH", "PS1", "HOME"}
-		for _, reservedKey := range reservedKeys {
+		// remove any reserved keys from secrets. skipping them is long-standing behavior, so the warning doesn't fail --fail-on-warning
+		for _, reservedKey := range ReservedSecretNames {
 			if _, found := dopplerSecrets[reservedKey]; found {
-				utils.LogDebug(fmt.Sprintf("Ignoring reserved secret %s", reservedKey))
+				utils.LogUncountedWarning(fmt.Sprintf("Ignoring secret %s, as it would overwrite the reserved environment variable of the same name", reservedKey))
 				delete(dopplerSecrets, reservedKey)
 			}
 		}
@@ -428,13 +1422,93 @@ func PrepareSecrets(dopplerSecrets map[string]string, originalEnv []string, pres
 	return env, onExit
 }
 
+// PrepareSecretsFD passes secrets to the process via a pipe on the specified file descriptor rather than via the environment
+// ValidateSecretsFD checks that secrets can be passed on the file descriptor
+func ValidateSecretsFD(fd int) error {
+	if fd < 3 {
+		return errors.New("--fd must be 3 or greater, as 0-2 are the command's stdin, stdout, and stderr")
+	}
+	if fd > MaxSecretsFD {
+		return fmt.Errorf("--fd must be %d or less", MaxSecretsFD)
+	}
+	return nil
+}
+
+func PrepareSecretsFD(dopplerSecrets map[string]string, originalEnv []string, fdOptions FDOptions) ([]string, []*os.File, func()) {
+	secretsBytes, err := SecretsToBytes(dopplerSecrets, fdOptions.Format, "")
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	reader, onExit, err := PipeSecrets(secretsBytes)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+
+	// ExtraFiles begins at fd 3; lower unused fds are closed in the process
+	extraFiles := make([]*os.File, fdOptions.FD-2)
+	extraFiles[fdOptions.FD-3] = reader
+
+	env := append([]string{}, originalEnv...)
+	env = append(env, fmt.Sprintf("%s=%d", SecretsFDEnvVar, fdOptions.FD))
+	return env, extraFiles, onExit
+}
+
+// SecretsAccessEntry a record of which secrets were made available to a process. secret values are never recorded
+type SecretsAccessEntry struct {
+	Time    time.Time `json:"time"`
+	PID     int       `json:"pid"`
+	Command []string  `json:"command"`
+	Project string    `json:"project,omitempty"`
+	Config  string    `json:"config,omitempty"`
+	Secrets []string  `json:"secrets"`
+}
+
+// OpenSecretsAccessLog opens the secrets access log for appending, creating it if necessary
+func OpenSecretsAccessLog(path string) (*os.File, Error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304
+	if err != nil {
+		return nil, Error{Err: err, Message: "Unable to open secrets access log"}
+	}
+	return file, Error{}
+}
+
+// LogSecretsAccess appends an entry to the secrets access log as a line of JSON, recording only the names of the secrets
+func LogSecretsAccess(log io.Writer, pid int, command []string, project string, config string, secrets map[string]string) Error {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entry := SecretsAccessEntry{Time: time.Now().UTC(), PID: pid, Command: command, Project: project, Config: config, Secrets: names}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return Error{Err: err, Message: "Unable to encode secrets access log entry"}
+	}
+	if _, err := log.Write(append(line, '\n')); err != nil {
+		return Error{Err: err, Message: "Unable to write secrets access log"}
+	}
+	return Error{}
+}
+
 // fetchSecrets from Doppler and handle fallback file
 func FetchSecrets(localConfig models.ScopedOptions, enableCache bool, fallbackOpts FallbackOptions, metadataPath string, nameTransformer *models.SecretsNameTransformer, dynamicSecretsTTL time.Duration, format models.SecretsFormat, secretNames []string) map[string]string {
+	secrets, err := TryFetchSecrets(localConfig, enableCache, fallbackOpts, metadataPath, nameTransformer, dynamicSecretsTTL, format, secretNames)
+	if !err.IsNil() {
+		utils.HandleError(err.Unwrap(), err.Message)
+	}
+	return secrets
+}
+
+// TryFetchSecrets is like FetchSecrets, but returns an error rather than exiting when the secrets can't be fetched
+// from the API and the fallback file can't be used instead. Failures reading the fallback file still exit
+func TryFetchSecrets(localConfig models.ScopedOptions, enableCache bool, fallbackOpts FallbackOptions, metadataPath string, nameTransformer *models.SecretsNameTransformer, dynamicSecretsTTL time.Duration, format models.SecretsFormat, secretNames []string) (map[string]string, Error) {
 	if fallbackOpts.Exclusive {
 		if !fallbackOpts.Enable {
 			utils.HandleError(errors.New("Conflict: unable to specify --no-fallback with --fallback-only"))
 		}
-		return readFallbackFile(fallbackOpts.Path, fallbackOpts.LegacyPath, fallbackOpts.Passphrase, false)
+		return readFallbackFile(fallbackOpts.Path, fallbackOpts.LegacyPath, metadataPath, fallbackOpts, false), Error{}
 	}
 
 	// this scenario likely isn't possible, but just to be safe, disable using cache when there's no metadata file
@@ -459,9 +1533,9 @@ func FetchSecrets(localConfig models.ScopedOptions, enableCache bool, fallbackOp
 		if fallbackOpts.Enable && canUseFallback {
 			utils.Log("Unable to fetch secrets from the Doppler API")
 			utils.LogError(httpErr.Unwrap())
-			return readFallbackFile(fallbackOpts.Path, fallbackOpts.LegacyPath, fallbackOpts.Passphrase, false)
+			return readFallbackFile(fallbackOpts.Path, fallbackOpts.LegacyPath, metadataPath, fallbackOpts, false), Error{}
 		}
-		utils.HandleError(httpErr.Unwrap(), httpErr.Message)
+		return nil, Error{Err: httpErr.Unwrap(), Message: httpErr.Message}
 	}
 
 	if enableCache && statusCode == 304 {
@@ -475,7 +1549,17 @@ func FetchSecrets(localConfig models.ScopedOptions, enableCache bool, fallbackOp
 			utils.HandleError(err.Unwrap(), err.Message)
 		}
 
-		return cache
+		// the API confirmed the fallback file is current, so record that for max age checks
+		if !fallbackOpts.Readonly {
+			if metadata, err := MetadataFile(metadataPath); err.IsNil() {
+				if err := WriteMetadataFile(metadataPath, metadata.ETag, metadata.Hash, time.Now()); !err.IsNil() {
+					utils.LogDebugError(err.Unwrap())
+					utils.LogDebug(err.Message)
+				}
+			}
+		}
+
+		return cache, Error{}
 	}
 
 	// ensure the response can be parsed before proceeding
@@ -486,21 +1570,30 @@ func FetchSecrets(localConfig models.ScopedOptions, enableCache bool, fallbackOp
 		if fallbackOpts.Enable {
 			utils.Log("Unable to parse the Doppler API response")
 			utils.LogError(httpErr.Unwrap())
-			return readFallbackFile(fallbackOpts.Path, fallbackOpts.LegacyPath, fallbackOpts.Passphrase, false)
+			return readFallbackFile(fallbackOpts.Path, fallbackOpts.LegacyPath, metadataPath, fallbackOpts, false), Error{}
 		}
-		utils.HandleError(err, "Unable to parse API response")
+		return nil, Error{Err: err, Message: "Unable to parse API response"}
 	}
 
 	writeFallbackFile := fallbackOpts.Enable && !fallbackOpts.Readonly && nameTransformer == nil
 	if writeFallbackFile {
-		utils.LogDebug("Encrypting secrets")
-		encryptedResponse, err := crypto.Encrypt(fallbackOpts.Passphrase, response, "base64")
-		if err != nil {
-			utils.HandleError(err, "Unable to encrypt your secrets. No fallback file has been written.")
+		var fallbackContents string
+		if fallbackOpts.Format == "env" {
+			// warn on every write, as the secrets are stored on disk in plain text
+			utils.LogUncountedWarning(fmt.Sprintf("Writing unencrypted secrets to fallback file %s", fallbackOpts.Path))
+			lines := append([]string{EnvFallbackFileHeader}, utils.MapToEnvFormat(secrets, true)...)
+			fallbackContents = strings.Join(lines, "\n") + "\n"
+		} else {
+			utils.LogDebug("Encrypting secrets")
+			encryptedResponse, err := crypto.Encrypt(fallbackOpts.Passphrase, response, "base64")
+			if err != nil {
+				utils.HandleError(err, "Unable to encrypt your secrets. No fallback file has been written.")
+			}
+			fallbackContents = encryptedResponse
 		}
 
 		utils.LogDebug(fmt.Sprintf("Writing to fallback file %s", fallbackOpts.Path))
-		if err := utils.WriteFile(fallbackOpts.Path, []byte(encryptedResponse), utils.RestrictedFilePerms()); err != nil {
+		if err := utils.WriteFile(fallbackOpts.Path, []byte(fallbackContents), utils.RestrictedFilePerms()); err != nil {
 			utils.Log("Unable to write to fallback file")
 			if fallbackOpts.ExitOnWriteFailure {
 				utils.HandleError(err, "", strings.Join(WriteFailureMessage(), "\n"))
@@ -509,38 +1602,141 @@ func FetchSecrets(localConfig models.ScopedOptions, enableCache bool, fallbackOp
 			}
 		}
 
-		if enableCache {
-			if etag := respHeaders.Get("etag"); etag != "" {
-				hash := crypto.Hash(encryptedResponse)
-
-				if err := WriteMetadataFile(metadataPath, etag, hash); !err.IsNil() {
-					utils.LogDebugError(err.Unwrap())
-					utils.LogDebug(err.Message)
+		// the metadata file records when the fallback file was fetched for max age checks, and its ETag for caching
+		if metadataPath != "" {
+			etag := ""
+			if enableCache {
+				etag = respHeaders.Get("etag")
+				if etag == "" {
+					utils.LogDebug("API response does not contain ETag")
 				}
-			} else {
-				utils.LogDebug("API response does not contain ETag")
+			}
+			hash := crypto.Hash(fallbackContents)
+
+			if err := WriteMetadataFile(metadataPath, etag, hash, time.Now()); !err.IsNil() {
+				utils.LogDebugError(err.Unwrap())
+				utils.LogDebug(err.Message)
 			}
 		}
 	}
 
-	return secrets
+	return secrets, Error{}
 }
 
-func Run(cmd *cobra.Command, args []string, env []string, forwardSignals bool) (*exec.Cmd, error) {
+// SecretValue the secret's computed (or raw) value, failing if it's restricted
+func SecretValue(secrets map[string]models.ComputedSecret, name string, raw bool) (string, Error) {
+	secret, ok := secrets[name]
+	if !ok {
+		return "", Error{Err: fmt.Errorf("Could not find requested secret: %s", name)}
+	}
+	value := secret.ComputedValue
+	if raw {
+		value = secret.RawValue
+	}
+	if value == nil {
+		return "", Error{Err: fmt.Errorf("Unable to write restricted value of secret %s", name)}
+	}
+	return *value, Error{}
+}
+
+// WriteSecretValue writes the secret's value to the file verbatim, with the specified permissions
+func WriteSecretValue(secrets map[string]models.ComputedSecret, name string, raw bool, path string, mode os.FileMode, mkdir bool) Error {
+	value, e := SecretValue(secrets, name, raw)
+	if !e.IsNil() {
+		return e
+	}
+
+	if mkdir {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return Error{Err: err, Message: "Unable to create parent directories"}
+		}
+	}
+
+	if err := utils.WriteFile(path, []byte(value), mode); err != nil {
+		return Error{Err: err, Message: "Unable to write the secret file"}
+	}
+	// the file is created subject to the umask; ensure it has exactly the requested permissions
+	if err := os.Chmod(path, mode); err != nil {
+		return Error{Err: err, Message: "Unable to set the secret file's permissions"}
+	}
+
+	return Error{}
+}
+
+// ReadSecretFile reads a file to store as a secret's value, failing if it's larger than maxSize bytes.
+// Binary content must be base64 encoded, as secret values are text
+func ReadSecretFile(path string, maxSize int64, base64Encode bool) (string, Error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", Error{Err: err, Message: "Unable to read the secret file"}
+	}
+	if info.IsDir() {
+		return "", Error{Err: fmt.Errorf("%s is a directory", path)}
+	}
+	if info.Size() > maxSize {
+		return "", Error{Err: fmt.Errorf("%s is %d bytes, which exceeds the max size of %d bytes", path, info.Size(), maxSize)}
+	}
+
+	// #nosec G304
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", Error{Err: err, Message: "Unable to read the secret file"}
+	}
+
+	if base64Encode {
+		return base64.StdEncoding.EncodeToString(content), Error{}
+	}
+	if !utf8.Valid(content) || bytes.IndexByte(content, 0) != -1 {
+		return "", Error{Err: fmt.Errorf("%s contains binary data, which must be stored base64 encoded", path)}
+	}
+	return string(content), Error{}
+}
+
+// PipeSecrets writes secrets to a pipe in the background, returning the pipe's read end.
+// The pipe is written once and closed, so readers receive EOF after the final secret.
+// The returned handler must be called once the process exits.
+func PipeSecrets(secrets []byte) (*os.File, func(), Error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, nil, Error{Err: err, Message: "Unable to create secrets pipe"}
+	}
+
+	go func() {
+		// closing the write end signals EOF to the reader
+		defer writer.Close()
+		// writes block until read, and fail once the read end is closed
+		if _, err := writer.Write(secrets); err != nil {
+			utils.LogDebug("Unable to write secrets to pipe")
+			utils.LogDebugError(err)
+		}
+	}()
+
+	cleanup := func() {
+		// unblock the writer if the process exited without reading all secrets
+		if err := reader.Close(); err != nil {
+			utils.LogDebugError(err)
+		}
+	}
+
+	return reader, cleanup, Error{}
+}
+
+func Run(cmd *cobra.Command, args []string, env []string, forwardSignals bool, opts utils.CommandOptions) (*exec.Cmd, error) {
 	var c *exec.Cmd
 	var err error
 
 	if cmd.Flags().Changed("command") {
 		command := cmd.Flag("command").Value.String()
-		c, err = utils.RunCommandString(command, env, os.Stdin, os.Stdout, os.Stderr, forwardSignals)
+		c, err = utils.RunCommandString(command, env, os.Stdin, os.Stdout, os.Stderr, forwardSignals, opts)
 	} else {
-		c, err = utils.RunCommand(args, env, os.Stdin, os.Stdout, os.Stderr, forwardSignals)
+		c, err = utils.RunCommand(args, env, os.Stdin, os.Stdout, os.Stderr, forwardSignals, opts)
 	}
 
 	return c, err
 }
 
-func readFallbackFile(path string, legacyPath string, passphrase string, silent bool) map[string]string {
+func readFallbackFile(path string, legacyPath string, metadataPath string, fallbackOpts FallbackOptions, silent bool) map[string]string {
+	passphrase := fallbackOpts.Passphrase
 	// avoid re-logging if re-running for legacy file
 	// TODO remove this when removing legacy path support
 	if !silent {
@@ -553,7 +1749,8 @@ func readFallbackFile(path string, legacyPath string, passphrase string, silent
 			// attempt to read from the legacy path, in case the fallback file was created with an older version of the CLI
 			// TODO remove this when releasing CLI v4 (DPLR-435)
 			if legacyPath != "" {
-				return readFallbackFile(legacyPath, "", passphrase, true)
+				// the legacy file has no metadata file
+				return readFallbackFile(legacyPath, "", "", fallbackOpts, true)
 			}
 
 			utils.HandleError(errors.New("The fallback file does not exist"))
@@ -562,11 +1759,29 @@ func readFallbackFile(path string, legacyPath string, passphrase string, silent
 		utils.HandleError(err, "Unable to read fallback file")
 	}
 
+	if fallbackOpts.MaxAge > 0 {
+		if err := checkFallbackFileAge(path, metadataPath, fallbackOpts.MaxAge); err != nil {
+			if !fallbackOpts.WarnOnStale {
+				utils.HandleError(err, "Refusing to use stale fallback file", "Use --fallback-stale=warn to use the fallback file anyway")
+			}
+			utils.LogWarning(err.Error())
+		}
+	}
+
 	response, err := ioutil.ReadFile(path) // #nosec G304
 	if err != nil {
 		utils.HandleError(err, "Unable to read fallback file")
 	}
 
+	if IsEnvFallbackFile(response) {
+		utils.LogDebug("Parsing dotenv fallback file")
+		secrets, err := utils.ParseDotenv(string(response))
+		if err != nil {
+			utils.HandleError(err, "Unable to parse fallback file")
+		}
+		return secrets
+	}
+
 	utils.LogDebug("Decrypting fallback file")
 	decryptedSecrets, err := crypto.Decrypt(passphrase, response)
 	if err != nil {
@@ -597,6 +1812,33 @@ func readFallbackFile(path string, legacyPath string, passphrase string, silent
 	return secrets
 }
 
+// checkFallbackFileAge returns an error if the fallback file was last fetched more than maxAge ago, according to its metadata file.
+// The file's modification time isn't used, as copying, restoring, or touching the file resets it
+func checkFallbackFileAge(path string, metadataPath string, maxAge time.Duration) error {
+	unknownAgeErr := errors.New("unable to determine when the fallback file was fetched, as its metadata file is missing or doesn't match it")
+	if metadataPath == "" {
+		return unknownAgeErr
+	}
+	metadata, metadataErr := MetadataFile(metadataPath)
+	if !metadataErr.IsNil() || metadata.FetchedAt.IsZero() {
+		return unknownAgeErr
+	}
+
+	contents, err := ioutil.ReadFile(path) // #nosec G304
+	if err != nil {
+		return err
+	}
+	if crypto.Hash(string(contents)) != metadata.Hash {
+		return unknownAgeErr
+	}
+
+	age := time.Since(metadata.FetchedAt)
+	if age > maxAge {
+		return fmt.Errorf("the fallback file was last updated %s ago, which exceeds the max age of %s", age.Round(time.Second), maxAge)
+	}
+	return nil
+}
+
 func WriteFailureMessage() []string {
 	var msg []string
 
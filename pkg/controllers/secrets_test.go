@@ -17,9 +17,19 @@ limitations under the License.
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/DopplerHQ/cli/pkg/crypto"
+	"github.com/DopplerHQ/cli/pkg/models"
+	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -94,3 +104,622 @@ func TestSecretsToBytes(t *testing.T) {
 		t.Errorf("Unable to convert secrets to byte array in %s format", format)
 	}
 }
+
+func TestFilterConfigLogsBySecret(t *testing.T) {
+	logs := []models.ConfigLog{
+		{ID: "1", Diff: []models.LogDiff{{Name: "A", Added: "1"}, {Name: "B", Added: "2"}}},
+		{ID: "2", Diff: []models.LogDiff{{Name: "B", Added: "3", Removed: "2"}}},
+		{ID: "3"},
+	}
+
+	filtered := FilterConfigLogsBySecret(logs, "A")
+	assert.Equal(t, 1, len(filtered))
+	assert.Equal(t, "1", filtered[0].ID)
+	assert.Equal(t, []models.LogDiff{{Name: "A", Added: "1"}}, filtered[0].Diff)
+
+	filtered = FilterConfigLogsBySecret(logs, "B")
+	assert.Equal(t, 2, len(filtered))
+	assert.Equal(t, "B", filtered[0].Diff[0].Name)
+
+	assert.Empty(t, FilterConfigLogsBySecret(logs, "C"))
+}
+
+func TestResolveSecretValue(t *testing.T) {
+	t.Setenv("DOPPLER_TEST_SECRET_VALUE", "abc")
+
+	value, err := ResolveSecretValue("plain")
+	assert.Nil(t, err)
+	assert.Equal(t, "plain", value)
+
+	value, err = ResolveSecretValue("env:DOPPLER_TEST_SECRET_VALUE")
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", value)
+
+	value, err = ResolveSecretValue("\\env:DOPPLER_TEST_SECRET_VALUE")
+	assert.Nil(t, err)
+	assert.Equal(t, "env:DOPPLER_TEST_SECRET_VALUE", value)
+
+	_, err = ResolveSecretValue("env:DOPPLER_TEST_UNSET_SECRET_VALUE")
+	assert.NotNil(t, err)
+
+	_, err = ResolveSecretValue("env:")
+	assert.NotNil(t, err)
+}
+
+func TestRawAndComputedSecrets(t *testing.T) {
+	raw := "${B}"
+	computed := "123"
+	secrets := map[string]models.ComputedSecret{
+		"A": {Name: "A", RawValue: &raw, ComputedValue: &computed},
+		"C": {Name: "C"},
+	}
+
+	values := RawAndComputedSecrets(secrets)
+	assert.Equal(t, "${B}", *values["A"]["raw"])
+	assert.Equal(t, "123", *values["A"]["computed"])
+	assert.Nil(t, values["C"]["raw"])
+	assert.Nil(t, values["C"]["computed"])
+}
+
+func TestBatchSecretNames(t *testing.T) {
+	names := []string{"A", "B", "C", "D", "E"}
+
+	assert.Equal(t, [][]string{{"A", "B"}, {"C", "D"}, {"E"}}, BatchSecretNames(names, 2))
+	assert.Equal(t, [][]string{names}, BatchSecretNames(names, 5))
+	assert.Equal(t, [][]string{names}, BatchSecretNames(names, 100))
+	assert.Equal(t, [][]string{names}, BatchSecretNames(names, 0))
+}
+
+func TestSecretLastChangedFromLogs(t *testing.T) {
+	logs := []models.ConfigLog{
+		{CreatedAt: "2026-03-01T00:00:00Z", Diff: []models.LogDiff{{Name: "A"}}},
+		{CreatedAt: "2026-02-01T00:00:00Z", Diff: []models.LogDiff{{Name: "A"}, {Name: "B"}}},
+		{CreatedAt: "2026-01-01T00:00:00Z"},
+	}
+	assert.Equal(t, map[string]string{"A": "2026-03-01T00:00:00Z", "B": "2026-02-01T00:00:00Z"}, SecretLastChangedFromLogs(logs))
+}
+
+func TestSortSecretNamesByAge(t *testing.T) {
+	secrets := map[string]models.ComputedSecret{
+		"NEW":     {UpdatedAt: "2026-03-01T00:00:00Z"},
+		"OLD":     {UpdatedAt: "2025-01-01T00:00:00Z"},
+		"OLD_TOO": {UpdatedAt: "2025-01-01T00:00:00Z"},
+		"UNKNOWN": {},
+		"INVALID": {UpdatedAt: "yesterday"},
+	}
+	assert.Equal(t, []string{"OLD", "OLD_TOO", "NEW", "INVALID", "UNKNOWN"}, SortSecretNamesByAge(secrets))
+}
+
+func TestCheckExpectedSecretValue(t *testing.T) {
+	leader := "node-1"
+	other := "node-2"
+	empty := ""
+	secrets := map[string]models.ComputedSecret{
+		"LEADER":   {Name: "LEADER", RawValue: &leader},
+		"EMPTY":    {Name: "EMPTY", RawValue: &empty},
+		"RESTRICT": {Name: "RESTRICT"},
+	}
+
+	assert.NoError(t, CheckExpectedSecretValue(secrets, "LEADER", &leader))
+	assert.NoError(t, CheckExpectedSecretValue(secrets, "EMPTY", &empty))
+	assert.NoError(t, CheckExpectedSecretValue(secrets, "MISSING", nil))
+
+	for _, err := range []error{
+		CheckExpectedSecretValue(secrets, "LEADER", &other),
+		CheckExpectedSecretValue(secrets, "EMPTY", nil),
+		CheckExpectedSecretValue(secrets, "MISSING", &empty),
+	} {
+		assert.ErrorIs(t, err, ErrUnexpectedSecretValue)
+	}
+
+	err := CheckExpectedSecretValue(secrets, "RESTRICT", &leader)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrUnexpectedSecretValue))
+}
+
+func TestHashSecrets(t *testing.T) {
+	raw := "${B}"
+	computed := "123"
+	otherComputed := "456"
+	project := "backend"
+	config := "dev"
+	otherConfig := "prd"
+
+	secrets := map[string]models.ComputedSecret{
+		"A":              {Name: "A", RawValue: &raw, ComputedValue: &computed},
+		"DOPPLER_CONFIG": {Name: "DOPPLER_CONFIG", RawValue: &config, ComputedValue: &config},
+	}
+	identical := map[string]models.ComputedSecret{
+		"A":               {Name: "A", RawValue: &computed, ComputedValue: &computed},
+		"DOPPLER_CONFIG":  {Name: "DOPPLER_CONFIG", RawValue: &otherConfig, ComputedValue: &otherConfig},
+		"DOPPLER_PROJECT": {Name: "DOPPLER_PROJECT", RawValue: &project, ComputedValue: &project},
+	}
+	different := map[string]models.ComputedSecret{
+		"A": {Name: "A", RawValue: &raw, ComputedValue: &otherComputed},
+	}
+
+	hash, count, err := HashSecrets(secrets, false)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, 1, count)
+	assert.Len(t, hash, 64)
+
+	identicalHash, _, _ := HashSecrets(identical, false)
+	assert.Equal(t, hash, identicalHash)
+
+	differentHash, _, _ := HashSecrets(different, false)
+	assert.NotEqual(t, hash, differentHash)
+
+	// raw values differ even though computed values match
+	rawHash, _, _ := HashSecrets(secrets, true)
+	identicalRawHash, _, _ := HashSecrets(identical, true)
+	assert.NotEqual(t, rawHash, identicalRawHash)
+}
+
+func TestDiffSecrets(t *testing.T) {
+	reference := "${B}"
+	one := "1"
+	two := "2"
+	config := "stg"
+	otherConfig := "prd"
+
+	first := map[string]models.ComputedSecret{
+		"CHANGED":        {Name: "CHANGED", RawValue: &one, ComputedValue: &one},
+		"ONLY_FIRST":     {Name: "ONLY_FIRST", RawValue: &one, ComputedValue: &one},
+		"REFERENCE":      {Name: "REFERENCE", RawValue: &reference, ComputedValue: &one},
+		"SAME":           {Name: "SAME", RawValue: &one, ComputedValue: &one},
+		"RESTRICTED":     {Name: "RESTRICTED"},
+		"DOPPLER_CONFIG": {Name: "DOPPLER_CONFIG", RawValue: &config, ComputedValue: &config},
+	}
+	second := map[string]models.ComputedSecret{
+		"CHANGED":        {Name: "CHANGED", RawValue: &two, ComputedValue: &two},
+		"ONLY_SECOND":    {Name: "ONLY_SECOND", RawValue: &two, ComputedValue: &two},
+		"REFERENCE":      {Name: "REFERENCE", RawValue: &reference, ComputedValue: &two},
+		"SAME":           {Name: "SAME", RawValue: &one, ComputedValue: &one},
+		"RESTRICTED":     {Name: "RESTRICTED"},
+		"DOPPLER_CONFIG": {Name: "DOPPLER_CONFIG", RawValue: &otherConfig, ComputedValue: &otherConfig},
+	}
+
+	// raw values are compared, so a reference resolving to different values isn't a difference.
+	// values restricted in both configs can't be compared, so they aren't reported as the same
+	assert.Equal(t, []models.SecretDifference{
+		{Name: "CHANGED", Status: models.SecretChanged, First: &one, Second: &two},
+		{Name: "ONLY_FIRST", Status: models.SecretOnlyFirst, First: &one},
+		{Name: "ONLY_SECOND", Status: models.SecretOnlySecond, Second: &two},
+		{Name: "RESTRICTED", Status: models.SecretUnknown},
+	}, DiffSecrets(first, second))
+
+	delete(first, "RESTRICTED")
+	assert.Empty(t, DiffSecrets(first, first))
+}
+
+func TestReferencedSecrets(t *testing.T) {
+	assert.Equal(t, []string{"${A}", "${B.C}"}, SecretReferences("${A}:${B.C}"))
+	assert.Empty(t, SecretReferences("$A {B}"))
+
+	ref := "${A}"
+	resolved := "123"
+	literal := "${MISSING}"
+	secrets := map[string]interface{}{"REF": ref, "LITERAL": literal, "PLAIN": "abc", "DELETED": nil}
+	response := map[string]models.ComputedSecret{
+		"REF":     {Name: "REF", RawValue: &ref, ComputedValue: &resolved},
+		"LITERAL": {Name: "LITERAL", RawValue: &literal, ComputedValue: &literal},
+	}
+
+	assert.Equal(t, []string{"REF"}, ReferencedSecrets(secrets, response))
+}
+
+func TestCheckFallbackFileAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fallback.json")
+	metadataPath := filepath.Join(dir, "metadata.json")
+	assert.Nil(t, os.WriteFile(path, []byte("{}"), 0600))
+	hash := crypto.Hash("{}")
+
+	err := WriteMetadataFile(metadataPath, "", hash, time.Now())
+	assert.True(t, err.IsNil())
+	assert.Nil(t, checkFallbackFileAge(path, metadataPath, time.Hour))
+
+	err = WriteMetadataFile(metadataPath, "", hash, time.Now().Add(-2*time.Hour))
+	assert.True(t, err.IsNil())
+	assert.NotNil(t, checkFallbackFileAge(path, metadataPath, time.Hour))
+
+	// touching the file doesn't make it current
+	now := time.Now()
+	assert.Nil(t, os.Chtimes(path, now, now))
+	assert.NotNil(t, checkFallbackFileAge(path, metadataPath, time.Hour))
+
+	// the age of a file that doesn't match its metadata is unknown
+	err = WriteMetadataFile(metadataPath, "", crypto.Hash("other"), time.Now())
+	assert.True(t, err.IsNil())
+	assert.ErrorContains(t, checkFallbackFileAge(path, metadataPath, time.Hour), "unable to determine")
+	assert.ErrorContains(t, checkFallbackFileAge(path, filepath.Join(dir, "missing.json"), time.Hour), "unable to determine")
+	assert.ErrorContains(t, checkFallbackFileAge(path, "", time.Hour), "unable to determine")
+}
+
+func TestPipeSecrets(t *testing.T) {
+	reader, cleanup, err := PipeSecrets([]byte(`{"A":"1"}`))
+	assert.True(t, err.IsNil())
+	defer cleanup()
+
+	body, e := io.ReadAll(reader)
+	assert.NoError(t, e)
+	assert.Equal(t, `{"A":"1"}`, string(body))
+}
+
+func TestPrepareSecretsReservedNames(t *testing.T) {
+	warnings := utils.WarningCount()
+	env, _ := PrepareSecrets(map[string]string{"A": "1", "HOME": "/tmp", "PATH": "/bin"}, []string{"HOME=/root"}, "false", MountOptions{})
+
+	assert.ElementsMatch(t, []string{"A=1", "HOME=/root"}, env)
+	// skipping reserved names doesn't fail --fail-on-warning
+	assert.Equal(t, warnings, utils.WarningCount())
+}
+
+func TestPrepareSecretsFD(t *testing.T) {
+	env, extraFiles, cleanup := PrepareSecretsFD(map[string]string{"A": "1"}, []string{"HOME=/root"}, FDOptions{Enable: true, FD: 5, Format: "env"})
+	defer cleanup()
+
+	assert.Equal(t, []string{"HOME=/root", "DOPPLER_CLI_SECRETS_FD=5"}, env)
+	assert.Len(t, extraFiles, 3)
+	assert.Nil(t, extraFiles[0])
+	assert.Nil(t, extraFiles[1])
+
+	body, e := io.ReadAll(extraFiles[2])
+	assert.NoError(t, e)
+	assert.Equal(t, `A="1"`, string(body))
+}
+
+func TestValidateSecretsFD(t *testing.T) {
+	assert.NoError(t, ValidateSecretsFD(3))
+	assert.NoError(t, ValidateSecretsFD(MaxSecretsFD))
+	assert.Error(t, ValidateSecretsFD(2))
+	assert.Error(t, ValidateSecretsFD(MaxSecretsFD+1))
+	assert.Error(t, ValidateSecretsFD(2000000000))
+}
+
+func TestFilterSecretsByTags(t *testing.T) {
+	secrets := map[string]models.ComputedSecret{
+		"DB_HOST": {Name: "DB_HOST", Tags: []string{"db", "prod"}},
+		"DB_PORT": {Name: "DB_PORT", Tags: []string{"db"}},
+		"API_KEY": {Name: "API_KEY", Tags: []string{"prod"}},
+		"DEBUG":   {Name: "DEBUG"},
+	}
+
+	filtered := FilterSecretsByTags(secrets, []string{"db"})
+	assert.Len(t, filtered, 2)
+	assert.Contains(t, filtered, "DB_HOST")
+	assert.Contains(t, filtered, "DB_PORT")
+
+	filtered = FilterSecretsByTags(secrets, []string{"db", "prod"})
+	assert.Len(t, filtered, 3)
+	assert.NotContains(t, filtered, "DEBUG")
+
+	assert.Empty(t, FilterSecretsByTags(secrets, []string{"missing"}))
+}
+
+func TestResolveSecretNameIgnoreCase(t *testing.T) {
+	names := []string{"DB_HOST", "API_KEY", "api_key", "Api_Key"}
+
+	name, err := ResolveSecretNameIgnoreCase("db_host", names)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, "DB_HOST", name)
+
+	// exact matches aren't ambiguous
+	name, err = ResolveSecretNameIgnoreCase("api_key", names)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, "api_key", name)
+
+	_, err = ResolveSecretNameIgnoreCase("API_key", names)
+	assert.False(t, err.IsNil())
+	assert.EqualError(t, err.Unwrap(), "Secret name API_key is ambiguous; it matches API_KEY, Api_Key, api_key")
+
+	name, err = ResolveSecretNameIgnoreCase("MISSING", names)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, "MISSING", name)
+}
+
+func TestDependentSecrets(t *testing.T) {
+	url := "postgres://${DB_USER}@${DB_HOST}/${DB_HOST}"
+	dsn := "${DB_HOST}:5432"
+	crossConfig := "${prd.DB_USER}"
+	user := "admin"
+	secrets := map[string]models.ComputedSecret{
+		"DB_URL":     {Name: "DB_URL", RawValue: &url},
+		"DB_DSN":     {Name: "DB_DSN", RawValue: &dsn},
+		"PRD_USER":   {Name: "PRD_USER", RawValue: &crossConfig},
+		"DB_USER":    {Name: "DB_USER", RawValue: &user},
+		"RESTRICTED": {Name: "RESTRICTED"},
+	}
+
+	dependents := DependentSecrets(secrets, []string{"DB_HOST", "DB_USER"})
+	assert.Equal(t, map[string][]string{
+		"DB_HOST": {"DB_DSN", "DB_URL"},
+		"DB_USER": {"DB_URL"},
+	}, dependents)
+
+	// dependents that are also being deleted don't block the deletion
+	assert.Empty(t, DependentSecrets(secrets, []string{"DB_HOST", "DB_URL", "DB_DSN"}))
+}
+
+func TestInjectConfigMetadata(t *testing.T) {
+	secrets := map[string]string{"API_KEY": "abc"}
+	assert.Empty(t, InjectConfigMetadata(secrets, "backend", "dev"))
+	assert.Equal(t, map[string]string{"API_KEY": "abc", "DOPPLER_PROJECT": "backend", "DOPPLER_CONFIG": "dev"}, secrets)
+
+	// values provided by the API take precedence
+	secrets = map[string]string{"DOPPLER_PROJECT": "frontend", "DOPPLER_CONFIG": "dev"}
+	warnings := InjectConfigMetadata(secrets, "backend", "dev")
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, "frontend", secrets["DOPPLER_PROJECT"])
+
+	// nothing is known about the config (e.g. when using a service token)
+	secrets = map[string]string{}
+	assert.Empty(t, InjectConfigMetadata(secrets, "", ""))
+	assert.Empty(t, secrets)
+
+	secrets = map[string]string{"API_KEY": "abc", "DOPPLER_PROJECT": "backend", "DOPPLER_CONFIG": "dev", "DOPPLER_ENVIRONMENT": "dev"}
+	RemoveConfigMetadata(secrets)
+	assert.Equal(t, map[string]string{"API_KEY": "abc"}, secrets)
+}
+
+func TestFilterReferencingSecrets(t *testing.T) {
+	reference := "postgres://${DB_USER}@${DB_HOST}"
+	computed := "postgres://admin@localhost"
+	plain := "localhost"
+	secrets := map[string]models.ComputedSecret{
+		"DB_URL":     {Name: "DB_URL", RawValue: &reference, ComputedValue: &computed},
+		"DB_HOST":    {Name: "DB_HOST", RawValue: &plain, ComputedValue: &plain},
+		"RESTRICTED": {Name: "RESTRICTED"},
+	}
+
+	filtered := FilterReferencingSecrets(secrets)
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, &computed, filtered["DB_URL"].ComputedValue)
+
+	assert.Empty(t, FilterReferencingSecrets(map[string]models.ComputedSecret{}))
+}
+
+func TestFilterLocalSecrets(t *testing.T) {
+	inherited := true
+	local := false
+	secrets := map[string]models.ComputedSecret{
+		"DB_HOST": {Name: "DB_HOST", Inherited: &inherited},
+		"DB_PORT": {Name: "DB_PORT", Inherited: &local},
+	}
+
+	filtered, err := FilterLocalSecrets(secrets)
+	assert.True(t, err.IsNil())
+	assert.Len(t, filtered, 1)
+	assert.Contains(t, filtered, "DB_PORT")
+
+	secrets["UNKNOWN"] = models.ComputedSecret{Name: "UNKNOWN"}
+	_, err = FilterLocalSecrets(secrets)
+	assert.False(t, err.IsNil())
+}
+
+func TestWriteSecretValue(t *testing.T) {
+	raw := "${KEY}\n"
+	computed := "-----BEGIN KEY-----\nabc\n-----END KEY-----\n"
+	secrets := map[string]models.ComputedSecret{
+		"TLS_KEY":    {Name: "TLS_KEY", RawValue: &raw, ComputedValue: &computed},
+		"RESTRICTED": {Name: "RESTRICTED"},
+	}
+
+	path := filepath.Join(t.TempDir(), "nested", "key.pem")
+	err := WriteSecretValue(secrets, "TLS_KEY", false, path, 0640, false)
+	assert.False(t, err.IsNil())
+
+	err = WriteSecretValue(secrets, "TLS_KEY", false, path, 0640, true)
+	assert.True(t, err.IsNil())
+	body, e := os.ReadFile(path)
+	assert.NoError(t, e)
+	assert.Equal(t, computed, string(body))
+	info, e := os.Stat(path)
+	assert.NoError(t, e)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	err = WriteSecretValue(secrets, "TLS_KEY", true, path, 0600, false)
+	assert.True(t, err.IsNil())
+	body, _ = os.ReadFile(path)
+	assert.Equal(t, raw, string(body))
+
+	err = WriteSecretValue(secrets, "RESTRICTED", false, path, 0600, false)
+	assert.False(t, err.IsNil())
+	err = WriteSecretValue(secrets, "MISSING", false, path, 0600, false)
+	assert.False(t, err.IsNil())
+}
+
+func TestReadSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	assert.NoError(t, os.WriteFile(cert, []byte("-----BEGIN CERTIFICATE-----\nabc\n-----END CERTIFICATE-----\n"), 0600))
+	keystore := filepath.Join(dir, "keystore.p12")
+	assert.NoError(t, os.WriteFile(keystore, []byte{0x30, 0x82, 0x00, 0xff}, 0600))
+
+	value, err := ReadSecretFile(cert, 1024, false)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----\nabc\n-----END CERTIFICATE-----\n", value)
+
+	// binary content must be encoded
+	_, err = ReadSecretFile(keystore, 1024, false)
+	assert.False(t, err.IsNil())
+	value, err = ReadSecretFile(keystore, 1024, true)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, "MIIA/w==", value)
+
+	_, err = ReadSecretFile(cert, 10, false)
+	assert.EqualError(t, err.Unwrap(), cert+" is 58 bytes, which exceeds the max size of 10 bytes")
+	_, err = ReadSecretFile(dir, 1024, false)
+	assert.False(t, err.IsNil())
+	_, err = ReadSecretFile(filepath.Join(dir, "missing"), 1024, false)
+	assert.False(t, err.IsNil())
+}
+
+func TestParseStructuredSecrets(t *testing.T) {
+	secrets, coerced, err := ParseStructuredSecrets([]byte(`{"HOST":"db","PORT":5432,"RATIO":0.5,"DEBUG":true,"EMPTY":null,"HOSTS":["a","b"]}`), "json", false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"HOST": "db", "PORT": "5432", "RATIO": "0.5", "DEBUG": "true", "EMPTY": "", "HOSTS": `["a","b"]`}, secrets)
+	assert.Equal(t, []CoercedSecret{
+		{Name: "DEBUG", Type: "boolean", Value: "true"},
+		{Name: "EMPTY", Type: "null", Value: ""},
+		{Name: "HOSTS", Type: "array", Value: `["a","b"]`},
+		{Name: "PORT", Type: "number", Value: "5432"},
+		{Name: "RATIO", Type: "number", Value: "0.5"},
+	}, coerced)
+
+	secrets, coerced, err = ParseStructuredSecrets([]byte("HOST: db\nPORT: 5432\nTLS:\n  enabled: yes\n"), "yaml", false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"HOST": "db", "PORT": "5432", "TLS": `{"enabled":"yes"}`}, secrets)
+	assert.Equal(t, []CoercedSecret{{Name: "PORT", Type: "number", Value: "5432"}, {Name: "TLS", Type: "object", Value: `{"enabled":"yes"}`}}, coerced)
+
+	_, _, err = ParseStructuredSecrets([]byte(`{"HOST":"db","PORT":5432}`), "json", true)
+	assert.EqualError(t, err, "the following secrets have non-string values:\n- PORT (number)")
+}
+
+func TestParseDeploySecrets(t *testing.T) {
+	secrets, err := ParseDeploySecrets([]byte(`{"HOST":"db","PORT":"5432"}` + "\n"))
+	assert.True(t, err.IsNil())
+	assert.Equal(t, map[string]string{"HOST": "db", "PORT": "5432"}, secrets)
+
+	// dotenv, as written to env format fallback files
+	secrets, err = ParseDeploySecrets([]byte("HOST=\"db\"\nPORT=\"5432\"\n"))
+	assert.True(t, err.IsNil())
+	assert.Equal(t, map[string]string{"HOST": "db", "PORT": "5432"}, secrets)
+
+	for _, data := range []string{"", " \n", `[{"name":"HOST","value":"db"}]`, `{"PORT":5432}`, `{"A=B":"c"}`, `HOST`} {
+		_, err := ParseDeploySecrets([]byte(data))
+		assert.False(t, err.IsNil(), data)
+	}
+}
+
+func TestParseSecretReference(t *testing.T) {
+	project, config, name := ParseSecretReference("${API_KEY}", "backend", "dev")
+	assert.Equal(t, []string{"backend", "dev", "API_KEY"}, []string{project, config, name})
+
+	project, config, name = ParseSecretReference("${stg.API_KEY}", "backend", "dev")
+	assert.Equal(t, []string{"backend", "stg", "API_KEY"}, []string{project, config, name})
+
+	project, config, name = ParseSecretReference("${shared.prd.API_KEY}", "backend", "dev")
+	assert.Equal(t, []string{"shared", "prd", "API_KEY"}, []string{project, config, name})
+}
+
+func TestTraceSecretResolution(t *testing.T) {
+	value := func(s string) *string { return &s }
+	inherited := true
+	configs := map[string]map[string]models.ComputedSecret{
+		"backend.dev": {
+			"URL":  {RawValue: value("https://${HOST}/${stg.PATH}"), ComputedValue: value("https://db/api"), Inherited: &inherited},
+			"HOST": {RawValue: value("db"), ComputedValue: value("db")},
+			"LOOP": {RawValue: value("${LOOP}"), ComputedValue: value("")},
+			"BAD":  {RawValue: value("${MISSING}-${other.prd.KEY}"), ComputedValue: value("-")},
+		},
+		"backend.stg": {
+			"PATH": {RawValue: value("api"), ComputedValue: value("api")},
+		},
+	}
+	fetches := 0
+	fetch := func(project string, config string) (map[string]models.ComputedSecret, Error) {
+		fetches++
+		secrets, ok := configs[project+"."+config]
+		if !ok {
+			return nil, Error{Err: errors.New("not found"), Message: "Unable to fetch secrets"}
+		}
+		return secrets, Error{}
+	}
+
+	steps, err := TraceSecretResolution("URL", "backend", "dev", fetch)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, []models.SecretResolutionStep{
+		{Depth: 0, Name: "URL", Project: "backend", Config: "dev", RawValue: value("https://${HOST}/${stg.PATH}"), ComputedValue: value("https://db/api"), Inherited: &inherited},
+		{Depth: 1, Reference: "${HOST}", Name: "HOST", Project: "backend", Config: "dev", RawValue: value("db"), ComputedValue: value("db")},
+		{Depth: 1, Reference: "${stg.PATH}", Name: "PATH", Project: "backend", Config: "stg", RawValue: value("api"), ComputedValue: value("api")},
+	}, steps)
+	// each config is only fetched once
+	assert.Equal(t, 2, fetches)
+
+	steps, err = TraceSecretResolution("LOOP", "backend", "dev", fetch)
+	assert.True(t, err.IsNil())
+	assert.Len(t, steps, 2)
+	assert.Equal(t, "circular reference", steps[1].Error)
+
+	steps, err = TraceSecretResolution("BAD", "backend", "dev", fetch)
+	assert.True(t, err.IsNil())
+	assert.Len(t, steps, 3)
+	assert.Equal(t, "secret does not exist", steps[1].Error)
+	assert.Equal(t, "unable to fetch secrets: Unable to fetch secrets", steps[2].Error)
+
+	_, err = TraceSecretResolution("NOPE", "backend", "dev", fetch)
+	assert.False(t, err.IsNil())
+	_, err = TraceSecretResolution("URL", "backend", "prd", fetch)
+	assert.False(t, err.IsNil())
+}
+
+func TestLogSecretsAccess(t *testing.T) {
+	var log strings.Builder
+	err := LogSecretsAccess(&log, 123, []string{"node", "server.js"}, "backend", "dev", map[string]string{"PORT": "5432", "API_KEY": "s3cr3t"})
+	assert.True(t, err.IsNil())
+
+	line := log.String()
+	assert.True(t, strings.HasSuffix(line, "\n"))
+	assert.NotContains(t, line, "s3cr3t")
+	assert.NotContains(t, line, "5432")
+
+	var entry SecretsAccessEntry
+	assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+	assert.Equal(t, 123, entry.PID)
+	assert.Equal(t, []string{"node", "server.js"}, entry.Command)
+	assert.Equal(t, "backend", entry.Project)
+	assert.Equal(t, "dev", entry.Config)
+	assert.Equal(t, []string{"API_KEY", "PORT"}, entry.Secrets)
+}
+
+func TestClearEnv(t *testing.T) {
+	env := ClearEnv([]string{"PATH=/usr/bin", "HOME=/home/app", "AWS_SECRET_ACCESS_KEY=abc", "FOO=a=b", "BAR=1"}, []string{"FOO"})
+	assert.Equal(t, []string{"PATH=/usr/bin", "HOME=/home/app", "FOO=a=b"}, env)
+
+	assert.Equal(t, []string{}, ClearEnv([]string{"BAR=1"}, nil))
+}
+
+func TestFindSecretReplacements(t *testing.T) {
+	url := "https://old.example.com/api"
+	host := "old.example.com"
+	other := "unrelated"
+	secrets := map[string]models.ComputedSecret{
+		"URL":        {Name: "URL", RawValue: &url},
+		"HOST":       {Name: "HOST", RawValue: &host},
+		"OTHER":      {Name: "OTHER", RawValue: &other},
+		"RESTRICTED": {Name: "RESTRICTED"},
+	}
+
+	replacements := FindSecretReplacements(secrets, regexp.MustCompile(regexp.QuoteMeta("old.example.com")), "new.example.com", true)
+	assert.Equal(t, []SecretReplacement{
+		{Name: "HOST", Before: host, After: "new.example.com", MaskedBefore: "old.example.com", MaskedAfter: "new.example.com"},
+		{Name: "URL", Before: url, After: "https://new.example.com/api", MaskedBefore: "****old.example.com****", MaskedAfter: "****new.example.com****"},
+	}, replacements)
+
+	// capture groups are only expanded in regex mode
+	replacements = FindSecretReplacements(secrets, regexp.MustCompile(`(\w+)\.example\.com`), "$1.internal", false)
+	assert.Len(t, replacements, 2)
+	assert.Equal(t, "https://old.internal/api", replacements[1].After)
+	replacements = FindSecretReplacements(secrets, regexp.MustCompile(`(\w+)\.example\.com`), "$1.internal", true)
+	assert.Equal(t, "https://$1.internal/api", replacements[1].After)
+
+	// replacing a value with itself changes nothing
+	assert.Empty(t, FindSecretReplacements(secrets, regexp.MustCompile("unrelated"), "unrelated", true))
+}
+
+func TestRawSecretValues(t *testing.T) {
+	raw := "https://${HOST}/api"
+	computed := "https://example.com/api"
+	secrets := map[string]models.ComputedSecret{
+		"URL": {Name: "URL", RawValue: &raw, ComputedValue: &computed},
+	}
+
+	values, err := RawSecretValues(secrets)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"URL": raw}, values)
+
+	secrets["RESTRICTED"] = models.ComputedSecret{Name: "RESTRICTED", ComputedValue: &computed}
+	_, err = RawSecretValues(secrets)
+	assert.EqualError(t, err, "the raw value of secret RESTRICTED is restricted")
+}
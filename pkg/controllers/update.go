@@ -159,9 +159,9 @@ func RunInstallScript() (bool, string, Error) {
 		// must execute in sh on MINGW64 Windows to avoid "command not found" error
 		c := []string{"sh"}
 		c = append(c, command...)
-		cmd, err = utils.RunCommand(c, os.Environ(), nil, &out, &out, true)
+		cmd, err = utils.RunCommand(c, os.Environ(), nil, &out, &out, true, utils.CommandOptions{})
 	} else {
-		cmd, err = utils.RunCommand(command, os.Environ(), nil, &out, &out, true)
+		cmd, err = utils.RunCommand(command, os.Environ(), nil, &out, &out, true, utils.CommandOptions{})
 	}
 	waitExitCode, waitErr := utils.WaitCommand(cmd)
 
@@ -295,7 +295,7 @@ func installedViaWinget() bool {
 	if utils.CanLogDebug() {
 		out = os.Stderr
 	}
-	cmd, err := utils.RunCommandString(command, os.Environ(), nil, out, out, true)
+	cmd, err := utils.RunCommandString(command, os.Environ(), nil, out, out, true, utils.CommandOptions{})
 	if err != nil {
 		utils.LogDebugError(err)
 		return false
@@ -313,7 +313,7 @@ func isUpdateAvailableViaWinget(updateVersion string) bool {
 	utils.LogDebug(fmt.Sprintf("Executing \"%s\"", command))
 
 	var out bytes.Buffer
-	cmd, err := utils.RunCommandString(command, os.Environ(), nil, &out, &out, true)
+	cmd, err := utils.RunCommandString(command, os.Environ(), nil, &out, &out, true, utils.CommandOptions{})
 	if err != nil {
 		utils.LogDebugError(err)
 		return false
@@ -341,7 +341,7 @@ func updateViaWinget(version string) error {
 	command := fmt.Sprintf("winget upgrade --id %s --exact --disable-interactivity --version %s", wingetPackageId, strings.TrimPrefix(version, "v"))
 
 	utils.LogDebug(fmt.Sprintf("Executing \"%s\"", command))
-	_, err := utils.RunCommandString(command, os.Environ(), nil, os.Stdout, os.Stderr, true)
+	_, err := utils.RunCommandString(command, os.Environ(), nil, os.Stdout, os.Stderr, true, utils.CommandOptions{})
 	if err != nil {
 		CaptureEvent("WingetUpgradeFailed", nil)
 		return err
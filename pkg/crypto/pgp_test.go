@@ -0,0 +1,86 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package crypto
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+func TestEncryptPGP(t *testing.T) {
+	alice, err := openpgp.NewEntity("Alice", "", "alice@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := openpgp.NewEntity("Bob", "", "bob@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// round trip the public keys as they would be exported by gpg
+	var exported bytes.Buffer
+	w, _ := armor.Encode(&exported, openpgp.PublicKeyType, nil)
+	if err := alice.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	recipients, err := ReadPGPPublicKeys(exported.Bytes())
+	if err != nil || len(recipients) != 2 {
+		t.Fatalf("Unable to read exported public keys: %v", err)
+	}
+
+	for _, armored := range []bool{false, true} {
+		ciphertext, err := EncryptPGP(recipients, []byte(originalPlaintext), armored)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if armored != strings.HasPrefix(string(ciphertext), "-----BEGIN PGP MESSAGE-----") {
+			t.Errorf("Unexpected armoring when armored=%t", armored)
+		}
+
+		// each recipient can decrypt the message
+		for _, entity := range []*openpgp.Entity{alice, bob} {
+			var r io.Reader = bytes.NewReader(ciphertext)
+			if armored {
+				block, err := armor.Decode(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r = block.Body
+			}
+			md, err := openpgp.ReadMessage(r, openpgp.EntityList{entity}, nil, nil)
+			if err != nil {
+				t.Fatalf("%s is unable to decrypt message: %v", entity.PrimaryKey.KeyIdString(), err)
+			}
+			plaintext, err := io.ReadAll(md.UnverifiedBody)
+			if err != nil || string(plaintext) != originalPlaintext {
+				t.Error("Invalid plaintext when decrypting PGP message")
+			}
+		}
+	}
+
+	if _, err := EncryptPGP(nil, []byte(originalPlaintext), false); err == nil {
+		t.Error("Expected error when encrypting without recipients")
+	}
+}
@@ -44,6 +44,36 @@ func apiKeyHeader(apiKey string) map[string]string {
 	return map[string]string{"Authorization": fmt.Sprintf("Bearer %s", apiKey)}
 }
 
+// parseJSONResponse parses a JSON API response, returning a clear error rather than a cryptic parsing error
+// if the response isn't JSON (e.g. a maintenance page returned with HTTP 200)
+func parseJSONResponse(headers http.Header, response []byte, v interface{}) error {
+	if err := checkJSONResponse(headers, response); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(response, v)
+}
+
+// checkJSONResponse returns an error if the response has a body whose content type isn't JSON
+func checkJSONResponse(headers http.Header, response []byte) error {
+	contentType := headers.Get("content-type")
+	if len(response) == 0 || contentType == "" || isJSONContentType(contentType) {
+		return nil
+	}
+
+	err := fmt.Errorf("unexpected non-JSON response from API (content-type: %s)", contentType)
+	if requestID := headers.Get("x-request-id"); requestID != "" {
+		err = fmt.Errorf("%w\nRequest ID: %s", err, requestID)
+	}
+	return withRequestID(headers, err)
+}
+
+// isJSONContentType whether the content type is JSON (e.g. 'application/json; charset=utf-8')
+func isJSONContentType(contentType string) bool {
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	return strings.EqualFold(mediaType, "application/json") || strings.HasSuffix(strings.ToLower(mediaType), "+json")
+}
+
 // GenerateAuthCode generate an auth code
 func GenerateAuthCode(host string, verifyTLS bool, hostname string, os string, arch string) (map[string]interface{}, Error) {
 	var params []queryParam
@@ -56,13 +86,13 @@ func GenerateAuthCode(host string, verifyTLS bool, hostname string, os string, a
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
-	statusCode, _, response, err := GetRequest(url, verifyTLS, nil)
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, nil)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch auth code", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -84,13 +114,13 @@ func GetAuthToken(host string, verifyTLS bool, code string) (map[string]interfac
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, nil, body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, nil, body)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch auth token", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch auth token", Code: statusCode}
 	}
@@ -112,13 +142,13 @@ func RollAuthToken(host string, verifyTLS bool, token string) (map[string]interf
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, nil, body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, nil, body)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to roll auth token", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -140,13 +170,13 @@ func RevokeAuthToken(host string, verifyTLS bool, token string) (map[string]inte
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, nil, body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, nil, body)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to revoke auth token", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -170,7 +200,7 @@ func WatchSecrets(host string, verifyTLS bool, apiKey string, project string, co
 	headers["Accept"] = "text/event-stream"
 	headers["Connection"] = "keep-alive"
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(Context, "GET", url.String(), nil)
 	if err != nil {
 		return 0, nil, Error{Err: err, Message: "Unable to submit request"}
 	}
@@ -216,10 +246,15 @@ func DownloadSecrets(host string, verifyTLS bool, apiKey string, project string,
 		return 0, nil, nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, respHeaders, response, err := GetRequest(url, verifyTLS, headers)
+	statusCode, respHeaders, response, err := coalescedGetRequest(Context, url, verifyTLS, headers)
 	if err != nil {
 		return statusCode, respHeaders, nil, Error{Err: err, Message: "Unable to download secrets", Code: statusCode}
 	}
+	if format == models.JSON {
+		if err := checkJSONResponse(respHeaders, response); err != nil {
+			return statusCode, respHeaders, nil, Error{Err: err, Message: "Unable to download secrets", Code: statusCode}
+		}
+	}
 
 	return statusCode, respHeaders, response, Error{}
 }
@@ -247,16 +282,20 @@ func GetSecrets(host string, verifyTLS bool, apiKey string, project string, conf
 
 	headers := apiKeyHeader(apiKey)
 	headers["Accept"] = "application/json"
-	statusCode, _, response, err := GetRequest(url, verifyTLS, headers)
+	statusCode, respHeaders, response, err := coalescedGetRequest(Context, url, verifyTLS, headers)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch secrets", Code: statusCode}
 	}
+	if err := checkJSONResponse(respHeaders, response); err != nil {
+		return nil, Error{Err: err, Message: "Unable to fetch secrets", Code: statusCode}
+	}
 
 	return response, Error{}
 }
 
 // SetSecrets for specified project and config
-func SetSecrets(host string, verifyTLS bool, apiKey string, project string, config string, secrets map[string]interface{}, changeRequests []models.ChangeRequest) (map[string]models.ComputedSecret, Error) {
+// ifMatch is the ETag of the config's secrets when they were read. if specified, the request fails when the config has since changed
+func SetSecrets(host string, verifyTLS bool, apiKey string, project string, config string, secrets map[string]interface{}, changeRequests []models.ChangeRequest, ifMatch string) (map[string]models.ComputedSecret, Error) {
 	reqBody := map[string]interface{}{}
 	if changeRequests != nil {
 		reqBody["change_requests"] = changeRequests
@@ -277,13 +316,21 @@ func SetSecrets(host string, verifyTLS bool, apiKey string, project string, conf
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	headers := apiKeyHeader(apiKey)
+	if ifMatch != "" {
+		headers["If-Match"] = ifMatch
+	}
+
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, headers, body)
 	if err != nil {
+		if statusCode == 412 {
+			return nil, Error{Err: err, Message: "The config has changed since it was read", Code: statusCode}
+		}
 		return nil, Error{Err: err, Message: "Unable to set secrets", Code: statusCode}
 	}
 
 	var result models.APISecretResponse
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -308,13 +355,13 @@ func SetSecretNoteViaConfig(host string, verifyTLS bool, apiKey string, project
 		return models.SecretNote{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.SecretNote{}, Error{Err: err, Message: "Unable to set secret note", Code: statusCode}
 	}
 
 	var secretNote models.SecretNote
-	err = json.Unmarshal(response, &secretNote)
+	err = parseJSONResponse(respHeaders, response, &secretNote)
 	if err != nil {
 		return models.SecretNote{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -337,13 +384,13 @@ func SetSecretNoteViaProject(host string, verifyTLS bool, apiKey string, project
 		return models.SecretNote{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.SecretNote{}, Error{Err: err, Message: "Unable to set secret note", Code: statusCode}
 	}
 
 	var secretNote models.SecretNote
-	err = json.Unmarshal(response, &secretNote)
+	err = parseJSONResponse(respHeaders, response, &secretNote)
 	if err != nil {
 		return models.SecretNote{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -363,7 +410,7 @@ func GetSecretNames(host string, verifyTLS bool, apiKey string, project string,
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch secret names", Code: statusCode}
 	}
@@ -371,7 +418,7 @@ func GetSecretNames(host string, verifyTLS bool, apiKey string, project string,
 	var result struct {
 		Names []string `json:"names"`
 	}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -397,13 +444,13 @@ func UploadSecrets(host string, verifyTLS bool, apiKey string, project string, c
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to upload secrets", Code: statusCode}
 	}
 
 	var result models.APISecretResponse
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -418,13 +465,13 @@ func GetWorkplaceSettings(host string, verifyTLS bool, apiKey string) (models.Wo
 		return models.WorkplaceSettings{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return models.WorkplaceSettings{}, Error{Err: err, Message: "Unable to fetch workplace settings", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.WorkplaceSettings{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -449,13 +496,13 @@ func SetWorkplaceSettings(host string, verifyTLS bool, apiKey string, values mod
 		return models.WorkplaceSettings{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.WorkplaceSettings{}, Error{Err: err, Message: "Unable to update workplace settings", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.WorkplaceSettings{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -479,13 +526,13 @@ func GetProjects(host string, verifyTLS bool, apiKey string, page int, number in
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch projects", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -512,13 +559,13 @@ func GetProject(host string, verifyTLS bool, apiKey string, project string) (mod
 		return models.ProjectInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return models.ProjectInfo{}, Error{Err: err, Message: "Unable to fetch project", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ProjectInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -544,13 +591,13 @@ func CreateProject(host string, verifyTLS bool, apiKey string, name string, desc
 		return models.ProjectInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.ProjectInfo{}, Error{Err: err, Message: "Unable to create project", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ProjectInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -584,13 +631,13 @@ func UpdateProject(host string, verifyTLS bool, apiKey string, project string, n
 		return models.ProjectInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.ProjectInfo{}, Error{Err: err, Message: "Unable to update project", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ProjectInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -613,13 +660,13 @@ func DeleteProject(host string, verifyTLS bool, apiKey string, project string) E
 		return Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := DeleteRequest(url, verifyTLS, apiKeyHeader(apiKey), nil)
+	statusCode, respHeaders, response, err := DeleteRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), nil)
 	if err != nil {
 		return Error{Err: err, Message: "Unable to delete project", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -639,13 +686,13 @@ func GetEnvironments(host string, verifyTLS bool, apiKey string, project string,
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch environments", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -673,13 +720,13 @@ func GetEnvironment(host string, verifyTLS bool, apiKey string, project string,
 		return models.EnvironmentInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return models.EnvironmentInfo{}, Error{Err: err, Message: "Unable to fetch environment", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.EnvironmentInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -705,13 +752,13 @@ func CreateEnvironment(host string, verifyTLS bool, apiKey string, project strin
 		return models.EnvironmentInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.EnvironmentInfo{}, Error{Err: err, Message: "Unable to create environment", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.EnvironmentInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -737,13 +784,13 @@ func DeleteEnvironment(host string, verifyTLS bool, apiKey string, project strin
 		return Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := DeleteRequest(url, verifyTLS, apiKeyHeader(apiKey), nil)
+	statusCode, respHeaders, response, err := DeleteRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), nil)
 	if err != nil {
 		return Error{Err: err, Message: "Unable to delete environment", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -770,13 +817,13 @@ func RenameEnvironment(host string, verifyTLS bool, apiKey string, project strin
 		return models.EnvironmentInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PutRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PutRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.EnvironmentInfo{}, Error{Err: err, Message: "Unable to rename environment", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.EnvironmentInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -805,13 +852,13 @@ func GetConfigs(host string, verifyTLS bool, apiKey string, project string, envi
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch configs", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -839,13 +886,13 @@ func GetConfig(host string, verifyTLS bool, apiKey string, project string, confi
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to fetch configs", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -874,13 +921,13 @@ func CreateConfig(host string, verifyTLS bool, apiKey string, project string, na
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to create config", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -904,13 +951,13 @@ func DeleteConfig(host string, verifyTLS bool, apiKey string, project string, co
 		return Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := DeleteRequest(url, verifyTLS, apiKeyHeader(apiKey), nil)
+	statusCode, respHeaders, response, err := DeleteRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), nil)
 	if err != nil {
 		return Error{Err: err, Message: "Unable to delete config", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -929,13 +976,13 @@ func LockConfig(host string, verifyTLS bool, apiKey string, project string, conf
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), nil)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), nil)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to lock config", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -959,13 +1006,13 @@ func UnlockConfig(host string, verifyTLS bool, apiKey string, project string, co
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), nil)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), nil)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to unlock config", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -995,13 +1042,13 @@ func CloneConfig(host string, verifyTLS bool, apiKey string, project string, con
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to clone config", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -1031,13 +1078,13 @@ func UpdateConfig(host string, verifyTLS bool, apiKey string, project string, co
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to update config", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ConfigInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -1065,19 +1112,24 @@ func GetActivityLogs(host string, verifyTLS bool, apiKey string, page int, numbe
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch activity logs", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
 
+	rawLogs, ok := result["logs"].([]interface{})
+	if !ok {
+		return nil, Error{Err: fmt.Errorf("Unexpected type parsing activity logs, expected []interface{}, got %T", result["logs"]), Message: "Unable to parse API response", Code: statusCode}
+	}
+
 	var logs []models.ActivityLog
-	for _, log := range result["logs"].([]interface{}) {
+	for _, log := range rawLogs {
 		log, ok := log.(map[string]interface{})
 		if !ok {
 			return nil, Error{Err: fmt.Errorf("Unexpected type parsing activity log, expected map[string]interface{}, got %T", log), Message: "Unable to parse API response", Code: statusCode}
@@ -1097,13 +1149,13 @@ func GetActivityLog(host string, verifyTLS bool, apiKey string, log string) (mod
 		return models.ActivityLog{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return models.ActivityLog{}, Error{Err: err, Message: "Unable to fetch activity log", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ActivityLog{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -1133,13 +1185,13 @@ func GetConfigLogs(host string, verifyTLS bool, apiKey string, project string, c
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch config logs", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -1168,13 +1220,13 @@ func GetConfigLog(host string, verifyTLS bool, apiKey string, project string, co
 		return models.ConfigLog{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return models.ConfigLog{}, Error{Err: err, Message: "Unable to fetch config log", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ConfigLog{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -1199,13 +1251,13 @@ func RollbackConfigLog(host string, verifyTLS bool, apiKey string, project strin
 		return models.ConfigLog{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), nil)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), nil)
 	if err != nil {
 		return models.ConfigLog{}, Error{Err: err, Message: "Unable to rollback config log", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ConfigLog{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -1229,13 +1281,13 @@ func GetConfigServiceTokens(host string, verifyTLS bool, apiKey string, project
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch service tokens", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -1274,13 +1326,13 @@ func CreateConfigServiceToken(host string, verifyTLS bool, apiKey string, projec
 		return models.ConfigServiceToken{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return models.ConfigServiceToken{}, Error{Err: err, Message: "Unable to create service token", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return models.ConfigServiceToken{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -1314,13 +1366,13 @@ func DeleteConfigServiceToken(host string, verifyTLS bool, apiKey string, projec
 		return Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := DeleteRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := DeleteRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return Error{Err: err, Message: "Unable to delete service token", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -1342,13 +1394,13 @@ func ImportTemplate(host string, verifyTLS bool, apiKey string, template []byte)
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := PostRequest(url, verifyTLS, apiKeyHeader(apiKey), body)
+	statusCode, respHeaders, response, err := PostRequest(Context, url, verifyTLS, apiKeyHeader(apiKey), body)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to import project(s)", Code: statusCode}
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(response, &result)
+	err = parseJSONResponse(respHeaders, response, &result)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
@@ -1371,13 +1423,13 @@ func GetActorInfo(host string, verifyTLS bool, apiKey string) (models.ActorInfo,
 		return models.ActorInfo{}, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	statusCode, _, response, err := GetRequest(url, verifyTLS, apiKeyHeader(apiKey))
+	statusCode, respHeaders, response, err := GetRequest(Context, url, verifyTLS, apiKeyHeader(apiKey))
 	if err != nil {
 		return models.ActorInfo{}, Error{Err: err, Message: "Unable to fetch actor", Code: statusCode}
 	}
 
 	var info models.ActorInfo
-	err = json.Unmarshal(response, &info)
+	err = parseJSONResponse(respHeaders, response, &info)
 	if err != nil {
 		return models.ActorInfo{}, Error{Err: err, Message: "Unable to parse API response", Code: statusCode}
 	}
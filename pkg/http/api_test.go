@@ -0,0 +1,193 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// mockTransport responds to every request with the specified status and body, recording the requests it receives
+func mockTransport(t *testing.T, statusCode int, body string) *[]*http.Request {
+	var requests []*http.Request
+	original := Transport
+	Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req)
+		return &http.Response{
+			StatusCode: statusCode,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+			Request:    req,
+		}, nil
+	})
+	t.Cleanup(func() { Transport = original })
+	return &requests
+}
+
+func TestGetSecrets(t *testing.T) {
+	requests := mockTransport(t, 200, `{"secrets":{"A":{"raw":"1","computed":"1"}}}`)
+
+	response, err := GetSecrets("https://api.example.com", true, "dp.st.token", "backend", "dev", []string{"A"}, false, 0)
+	assert.True(t, err.IsNil())
+	assert.Equal(t, `{"secrets":{"A":{"raw":"1","computed":"1"}}}`, string(response))
+
+	assert.Len(t, *requests, 1)
+	req := (*requests)[0]
+	assert.Equal(t, "GET", req.Method)
+	assert.Equal(t, "/v3/configs/config/secrets", req.URL.Path)
+	assert.Equal(t, "backend", req.URL.Query().Get("project"))
+	assert.Equal(t, "dev", req.URL.Query().Get("config"))
+	assert.Equal(t, "A", req.URL.Query().Get("secrets"))
+	assert.Equal(t, "Bearer dp.st.token", req.Header.Get("Authorization"))
+}
+
+func TestSetSecretsPreconditionFailed(t *testing.T) {
+	requests := mockTransport(t, 412, `{"messages":["Precondition failed"],"success":false}`)
+
+	_, err := SetSecrets("https://api.example.com", true, "dp.st.token", "backend", "dev", map[string]interface{}{"A": "1"}, nil, `"v1"`)
+	assert.False(t, err.IsNil())
+	assert.Equal(t, 412, err.Code)
+	assert.Equal(t, "The config has changed since it was read", err.Message)
+
+	// non-retryable failures are only attempted once
+	assert.Len(t, *requests, 1)
+	assert.Equal(t, `"v1"`, (*requests)[0].Header.Get("If-Match"))
+}
+
+func TestGetSecretsCoalescesConcurrentRequests(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []*http.Request
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	original := Transport
+	Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mutex.Lock()
+		requests = append(requests, req)
+		mutex.Unlock()
+
+		started <- struct{}{}
+		<-release
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewBufferString(`{"secrets":{}}`)),
+			Request:    req,
+		}, nil
+	})
+	t.Cleanup(func() { Transport = original })
+
+	const callers = 5
+	var wg sync.WaitGroup
+	responses := make([][]byte, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := GetSecrets("https://api.example.com", true, "dp.st.token", "backend", "dev", nil, false, 0)
+			assert.True(t, err.IsNil())
+			responses[i] = response
+		}(i)
+	}
+
+	// a request for a different config isn't shared
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := GetSecrets("https://api.example.com", true, "dp.st.token", "backend", "prd", nil, false, 0)
+		assert.True(t, err.IsNil())
+	}()
+
+	<-started
+	<-started
+	// give the remaining callers time to join the in-flight request
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Len(t, requests, 2)
+	for _, response := range responses {
+		assert.Equal(t, `{"secrets":{}}`, string(response))
+	}
+}
+
+func TestNonJSONResponse(t *testing.T) {
+	original := Transport
+	Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"text/html; charset=utf-8"}, "X-Request-Id": []string{"abc123"}},
+			Body:       io.NopCloser(bytes.NewBufferString("<html>Down for maintenance</html>")),
+			Request:    req,
+		}, nil
+	})
+	t.Cleanup(func() { Transport = original })
+
+	_, err := GetSecrets("https://api.example.com", true, "dp.st.token", "backend", "dev", nil, false, 0)
+	assert.False(t, err.IsNil())
+	assert.EqualError(t, err.Unwrap(), "unexpected non-JSON response from API (content-type: text/html; charset=utf-8)\nRequest ID: abc123")
+	assert.Equal(t, "abc123", utils.ErrorRequestID(err.Unwrap()))
+
+	_, err = GetWorkplaceSettings("https://api.example.com", true, "dp.st.token")
+	assert.False(t, err.IsNil())
+	assert.Contains(t, err.Unwrap().Error(), "unexpected non-JSON response from API")
+}
+
+func TestErrorRequestID(t *testing.T) {
+	original := Transport
+	Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 403,
+			Header:     http.Header{"Content-Type": []string{"application/json"}, "X-Request-Id": []string{"abc123"}},
+			Body:       io.NopCloser(bytes.NewBufferString(`{"messages":["Forbidden"],"success":false}`)),
+			Request:    req,
+		}, nil
+	})
+	t.Cleanup(func() { Transport = original })
+
+	_, err := GetSecrets("https://api.example.com", true, "dp.st.token", "backend", "dev", nil, false, 0)
+	assert.False(t, err.IsNil())
+	assert.Equal(t, "abc123", utils.ErrorRequestID(err.Unwrap()))
+	// the request ID doesn't change the human-readable error
+	assert.True(t, strings.HasPrefix(err.Unwrap().Error(), "Forbidden"))
+
+	// responses without a request ID
+	mockTransport(t, 403, `{"messages":["Forbidden"],"success":false}`)
+	_, err = GetSecrets("https://api.example.com", true, "dp.st.token", "backend", "dev", nil, false, 0)
+	assert.False(t, err.IsNil())
+	assert.Empty(t, utils.ErrorRequestID(err.Unwrap()))
+}
+
+func TestIsJSONContentType(t *testing.T) {
+	assert.True(t, isJSONContentType("application/json"))
+	assert.True(t, isJSONContentType("application/json; charset=utf-8"))
+	assert.True(t, isJSONContentType("application/problem+json"))
+	assert.False(t, isJSONContentType("text/html"))
+	assert.False(t, isJSONContentType("text/plain; charset=utf-8"))
+}
@@ -15,7 +15,37 @@ limitations under the License.
 */
 package http
 
-import "time"
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// Context the context requests are performed with. cancelling it aborts in-flight requests and any pending retries
+var Context = context.Background()
+
+// CancelOnInterrupt cancels the requests' Context on the first signal that isn't handled by a child process, then passes
+// the signal to onInterrupt. signals a child process handles leave the context as is, so that the requests made while
+// it keeps running (e.g. when watching for secrets changes) aren't aborted
+func CancelOnInterrupt(signals <-chan os.Signal, cancel context.CancelFunc, handled func() bool, onInterrupt func(os.Signal)) {
+	for sig := range signals {
+		if handled() {
+			utils.LogDebug("Interrupted, leaving the signal to the child process")
+			continue
+		}
+
+		utils.LogDebug("Interrupted, cancelling http requests")
+		cancel()
+		onInterrupt(sig)
+		return
+	}
+}
 
 // UseTimeout whether to timeout long-running requests
 var UseTimeout = true
@@ -23,5 +53,51 @@ var UseTimeout = true
 // TimeoutDuration how long to wait for a request to complete before timing out
 var TimeoutDuration = 10 * time.Second
 
+// ConnectTimeoutDuration how long to wait for a connection to be established before timing out. zero uses TimeoutDuration
+var ConnectTimeoutDuration time.Duration
+
+// connectTimeout the connect timeout, which defaults to the request timeout. it still applies when the request timeout
+// is disabled (e.g. for streaming requests), so dead hosts fail fast
+func connectTimeout() time.Duration {
+	if ConnectTimeoutDuration > 0 {
+		return ConnectTimeoutDuration
+	}
+	return TimeoutDuration
+}
+
 // RequestAttempts how many request attempts are made before giving up
 var RequestAttempts = 5
+
+// RetryBackoff the backoff strategy used between request attempts
+var RetryBackoff utils.Backoff = utils.FullJitterBackoff
+
+// BaseBackoff the delay the backoff strategy starts from. a 429 response's Retry-After header takes precedence
+var BaseBackoff = 500 * time.Millisecond
+
+// UserAgentSuffix an identifier appended to the user agent (e.g. the name of the tool embedding the CLI)
+var UserAgentSuffix = ""
+
+// RequestIDHeader the header used to send a client-generated request ID. an empty value disables the header
+var RequestIDHeader = "x-client-request-id"
+
+// TLSServerName overrides the server name sent via SNI and used to verify the server's certificate. empty uses the request's host
+var TLSServerName = ""
+
+// RootCAs the certificate authorities trusted when verifying the API's TLS certificate. nil uses the system's certificate pool
+var RootCAs *x509.CertPool
+
+// ProxyURL the proxy all requests are sent through. nil uses the proxy from the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY env vars
+var ProxyURL *url.URL
+
+// Transport overrides the transport used to perform requests (e.g. a mock transport in tests). nil uses the default transport
+var Transport http.RoundTripper
+
+// BeforeRequest is called before each request attempt, after the CLI's own headers are set (e.g. to add custom headers). nil disables the hook
+var BeforeRequest func(req *http.Request)
+
+// AfterRequest is called after each request attempt with the response, which is nil if the attempt failed, and the error from performing it.
+// The hook must not read or close the response body. nil disables the hook
+var AfterRequest func(req *http.Request, resp *http.Response, err error)
+
+// RequestLog receives a JSON line describing each request attempt, with credentials redacted and bodies omitted. nil disables logging
+var RequestLog io.Writer
@@ -36,7 +36,7 @@ func getLatestVersion() (string, error) {
 		return "", err
 	}
 
-	_, _, resp, err := GetRequest(url, true, nil)
+	_, _, resp, err := GetRequest(Context, url, true, nil)
 	TimeoutDuration = origTimeout
 	if err != nil {
 		return "", err
@@ -76,7 +76,7 @@ func GetCLIInstallScript() ([]byte, Error) {
 		return nil, Error{Err: err, Message: "Unable to generate url"}
 	}
 
-	_, _, resp, err := GetRequest(url, true, nil)
+	_, _, resp, err := GetRequest(Context, url, true, nil)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to download CLI install script"}
 	}
@@ -91,7 +91,7 @@ func GetChangelog() ([]byte, Error) {
 	}
 
 	headers := map[string]string{"Accept": "application/json"}
-	_, _, resp, err := GetRequest(url, true, headers)
+	_, _, resp, err := GetRequest(Context, url, true, headers)
 	if err != nil {
 		return nil, Error{Err: err, Message: "Unable to fetch changelog"}
 	}
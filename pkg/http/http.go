@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,12 +28,17 @@ import (
 	"net/http"
 	"net/url"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/DopplerHQ/cli/pkg/utils"
 	"github.com/DopplerHQ/cli/pkg/version"
+	"golang.org/x/sync/singleflight"
 )
 
 type queryParam struct {
@@ -45,6 +51,14 @@ type errorResponse struct {
 	Success  bool
 }
 
+// inFlightRequests the number of requests currently being performed, including their retries
+var inFlightRequests int32
+
+// RequestsInFlight whether any request is currently being performed
+func RequestsInFlight() bool {
+	return atomic.LoadInt32(&inFlightRequests) > 0
+}
+
 // DNS resolver
 var UseCustomDNSResolver = false
 var DNSResolverAddress = "1.1.1.1:53"
@@ -52,7 +66,7 @@ var DNSResolverProto = "udp"
 var DNSResolverTimeout = time.Duration(5) * time.Second
 
 func generateURL(host string, uri string, params []queryParam) (*url.URL, error) {
-	host = strings.TrimSuffix(host, "/")
+	host = utils.BracketIPv6Host(strings.TrimSuffix(host, "/"))
 	if !strings.HasPrefix(uri, "/") {
 		uri = fmt.Sprintf("/%s", uri)
 	}
@@ -70,9 +84,9 @@ func generateURL(host string, uri string, params []queryParam) (*url.URL, error)
 	return url, nil
 }
 
-// GetRequest perform HTTP GET
-func GetRequest(url *url.URL, verifyTLS bool, headers map[string]string) (int, http.Header, []byte, error) {
-	req, err := http.NewRequest("GET", url.String(), nil)
+// GetRequest perform HTTP GET. successCodes, if specified, replace the default success status codes (2xx and 3xx)
+func GetRequest(ctx context.Context, url *url.URL, verifyTLS bool, headers map[string]string, successCodes ...int) (int, http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return 0, nil, nil, err
 	}
@@ -81,7 +95,7 @@ func GetRequest(url *url.URL, verifyTLS bool, headers map[string]string) (int, h
 		req.Header.Set(key, value)
 	}
 
-	statusCode, respHeaders, body, err := performRequest(req, verifyTLS)
+	statusCode, respHeaders, body, err := performRequest(req, verifyTLS, successCodes)
 	if err != nil {
 		return statusCode, respHeaders, body, err
 	}
@@ -89,9 +103,48 @@ func GetRequest(url *url.URL, verifyTLS bool, headers map[string]string) (int, h
 	return statusCode, respHeaders, body, nil
 }
 
+// coalescedRequests the in-flight GET requests that concurrent identical requests wait on
+var coalescedRequests singleflight.Group
+
+type coalescedResponse struct {
+	statusCode int
+	headers    http.Header
+	body       []byte
+}
+
+// coalescedGetRequest perform HTTP GET, sharing a single request among concurrent callers requesting the same url with the same headers
+func coalescedGetRequest(ctx context.Context, url *url.URL, verifyTLS bool, headers map[string]string) (int, http.Header, []byte, error) {
+	var headerNames []string
+	for name := range headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	key := url.String()
+	for _, name := range headerNames {
+		key += fmt.Sprintf("\n%s: %s", name, headers[name])
+	}
+
+	result, err, shared := coalescedRequests.Do(key, func() (interface{}, error) {
+		statusCode, respHeaders, body, err := GetRequest(ctx, url, verifyTLS, headers)
+		return coalescedResponse{statusCode: statusCode, headers: respHeaders, body: body}, err
+	})
+
+	response := result.(coalescedResponse)
+	if shared {
+		utils.LogDebug(fmt.Sprintf("Shared response of concurrent request to %s", url))
+		// each caller receives its own copy, as the response may be modified
+		response.headers = response.headers.Clone()
+		if response.body != nil {
+			response.body = append([]byte{}, response.body...)
+		}
+	}
+	return response.statusCode, response.headers, response.body, err
+}
+
 // PostRequest perform HTTP POST
-func PostRequest(url *url.URL, verifyTLS bool, headers map[string]string, body []byte) (int, http.Header, []byte, error) {
-	req, err := http.NewRequest("POST", url.String(), bytes.NewReader(body))
+func PostRequest(ctx context.Context, url *url.URL, verifyTLS bool, headers map[string]string, body []byte, successCodes ...int) (int, http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url.String(), bytes.NewReader(body))
 	if err != nil {
 		return 0, nil, nil, err
 	}
@@ -100,7 +153,7 @@ func PostRequest(url *url.URL, verifyTLS bool, headers map[string]string, body [
 		req.Header.Set(key, value)
 	}
 
-	statusCode, respHeaders, body, err := performRequest(req, verifyTLS)
+	statusCode, respHeaders, body, err := performRequest(req, verifyTLS, successCodes)
 	if err != nil {
 		return statusCode, respHeaders, body, err
 	}
@@ -109,8 +162,8 @@ func PostRequest(url *url.URL, verifyTLS bool, headers map[string]string, body [
 }
 
 // PutRequest perform HTTP PUT
-func PutRequest(url *url.URL, verifyTLS bool, headers map[string]string, body []byte) (int, http.Header, []byte, error) {
-	req, err := http.NewRequest("PUT", url.String(), bytes.NewReader(body))
+func PutRequest(ctx context.Context, url *url.URL, verifyTLS bool, headers map[string]string, body []byte, successCodes ...int) (int, http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "PUT", url.String(), bytes.NewReader(body))
 	if err != nil {
 		return 0, nil, nil, err
 	}
@@ -119,7 +172,7 @@ func PutRequest(url *url.URL, verifyTLS bool, headers map[string]string, body []
 		req.Header.Set(key, value)
 	}
 
-	statusCode, respHeaders, body, err := performRequest(req, verifyTLS)
+	statusCode, respHeaders, body, err := performRequest(req, verifyTLS, successCodes)
 	if err != nil {
 		return statusCode, respHeaders, body, err
 	}
@@ -128,8 +181,8 @@ func PutRequest(url *url.URL, verifyTLS bool, headers map[string]string, body []
 }
 
 // DeleteRequest perform HTTP DELETE
-func DeleteRequest(url *url.URL, verifyTLS bool, headers map[string]string, body []byte) (int, http.Header, []byte, error) {
-	req, err := http.NewRequest("DELETE", url.String(), bytes.NewReader(body))
+func DeleteRequest(ctx context.Context, url *url.URL, verifyTLS bool, headers map[string]string, body []byte, successCodes ...int) (int, http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url.String(), bytes.NewReader(body))
 	if err != nil {
 		return 0, nil, nil, err
 	}
@@ -138,7 +191,7 @@ func DeleteRequest(url *url.URL, verifyTLS bool, headers map[string]string, body
 		req.Header.Set(key, value)
 	}
 
-	statusCode, respHeaders, body, err := performRequest(req, verifyTLS)
+	statusCode, respHeaders, body, err := performRequest(req, verifyTLS, successCodes)
 	if err != nil {
 		return statusCode, respHeaders, body, err
 	}
@@ -146,75 +199,50 @@ func DeleteRequest(url *url.URL, verifyTLS bool, headers map[string]string, body
 	return statusCode, respHeaders, body, nil
 }
 
-func request(req *http.Request, verifyTLS bool, allowTimeout bool) (*http.Response, error) {
+func request(req *http.Request, verifyTLS bool, allowTimeout bool, successCodes []int) (*http.Response, error) {
+	atomic.AddInt32(&inFlightRequests, 1)
+	defer atomic.AddInt32(&inFlightRequests, -1)
+
 	// set headers
 	req.Header.Set("client-sdk", "go-cli")
 	req.Header.Set("client-version", version.ProgramVersion)
 	req.Header.Set("client-os", runtime.GOOS)
 	req.Header.Set("client-arch", runtime.GOARCH)
-	req.Header.Set("user-agent", "doppler-go-cli-"+version.ProgramVersion)
+	req.Header.Set("user-agent", userAgent())
 	if req.Header.Get("Accept") == "" {
 		req.Header.Set("Accept", "application/json")
 	}
 	req.Header.Set("Content-Type", "application/json")
+	// the ID is generated once per request so that retries share the same ID
+	if RequestIDHeader != "" && req.Header.Get(RequestIDHeader) == "" {
+		if clientRequestID, err := utils.UUID(); err == nil {
+			req.Header.Set(RequestIDHeader, clientRequestID)
+			utils.LogDebug(fmt.Sprintf("Client request ID %s", clientRequestID))
+		}
+	}
 
-	// close the connection after reading the response, to help prevent socket exhaustion
-	req.Close = true
-
+	// the client is cheap to create, while its transport is shared so that connections are reused across requests
 	client := &http.Client{}
 	// set http timeout
 	if allowTimeout && UseTimeout {
 		client.Timeout = TimeoutDuration
 	}
 
-	// set TLS config
-	tlsConfig := &tls.Config{
-		MinVersion: tls.VersionTLS12,
-	}
-	// #nosec G402
-	if !verifyTLS {
-		tlsConfig.InsecureSkipVerify = true
+	if Transport != nil {
+		client.Transport = Transport
+	} else {
+		client.Transport = sharedTransport(req, verifyTLS)
 	}
 
-	// use custom DNS resolver
-	dialer := &net.Dialer{}
-	if UseCustomDNSResolver {
-		utils.LogDebug(fmt.Sprintf("Using custom DNS resolver %s", DNSResolverAddress))
-
-		dialer = &net.Dialer{
-			Resolver: &net.Resolver{
-				PreferGo: true,
-				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-					d := net.Dialer{
-						Timeout: DNSResolverTimeout,
-					}
-					return d.DialContext(ctx, DNSResolverProto, DNSResolverAddress)
-				},
-			},
+	// return redirects the caller accepts rather than following them (e.g. to read the Location of a CDN download)
+	if len(successCodes) > 0 {
+		client.CheckRedirect = func(redirectReq *http.Request, via []*http.Request) error {
+			if redirectReq.Response != nil && isSuccess(redirectReq.Response.StatusCode, successCodes) {
+				return http.ErrUseLastResponse
+			}
+			return nil
 		}
 	}
-	dialContext := func(ctx context.Context, network, addr string) (net.Conn, error) {
-		return dialer.DialContext(ctx, network, addr)
-	}
-
-	proxyUrl, err := http.ProxyFromEnvironment(req)
-	if err != nil {
-		utils.LogDebug("Unable to read proxy from environment")
-		utils.LogDebugError(err)
-		proxyUrl = nil
-	}
-	if proxyUrl != nil {
-		utils.LogDebug(fmt.Sprintf("Using proxy %s", proxyUrl))
-	}
-
-	client.Transport = &http.Transport{
-		// disable keep alives to prevent multiple CLI instances from exhausting the
-		// OS's available network sockets. this adds a negligible performance penalty
-		DisableKeepAlives: true,
-		TLSClientConfig:   tlsConfig,
-		DialContext:       dialContext,
-		Proxy:             http.ProxyURL(proxyUrl),
-	}
 
 	utils.LogDebug(fmt.Sprintf("Performing HTTP %s to %s", req.Method, req.URL))
 
@@ -222,9 +250,20 @@ func request(req *http.Request, verifyTLS bool, allowTimeout bool) (*http.Respon
 	var response *http.Response
 	response = nil
 
-	err = utils.Retry(RequestAttempts, 500*time.Millisecond, func() error {
+	attempt := 0
+	err := utils.RetryWithContext(req.Context(), RequestAttempts, BaseBackoff, RetryBackoff, func() error {
+		attempt++
+		if BeforeRequest != nil {
+			BeforeRequest(req)
+		}
+
+		attemptStartTime := time.Now()
 		// disable semgrep rule b/c we properly check that resp isn't nil before using it within the err block
 		resp, err := client.Do(req) // nosemgrep: trailofbits.go.invalid-usage-of-modified-variable.invalid-usage-of-modified-variable
+		logRequestAttempt(req, resp, err, attempt, attemptStartTime)
+		if AfterRequest != nil {
+			AfterRequest(req, resp, err)
+		}
 		if err != nil {
 			if resp != nil {
 				defer func() {
@@ -250,17 +289,25 @@ func request(req *http.Request, verifyTLS bool, allowTimeout bool) (*http.Respon
 			utils.LogDebug(fmt.Sprintf("Request ID %s", requestID))
 		}
 
-		if isSuccess(resp.StatusCode) {
+		if isSuccess(resp.StatusCode, successCodes) {
 			return nil
 		}
 
 		contentType := resp.Header.Get("content-type")
 		if IsRetry(resp.StatusCode, contentType) {
+			// release the connection before retrying, while keeping the body readable in case this is the final attempt
+			bufferBody(resp)
 			// start logging retries after 10 seconds so it doesn't feel like we've frozen
 			// we subtract 1 millisecond so that we always win the race against a request that exhausts its full 10 second time out
 			if time.Now().After(startTime.Add(10 * time.Second).Add(-1 * time.Millisecond)) {
 				utils.Log(fmt.Sprintf("Request failed with HTTP %d, retrying", resp.StatusCode))
 			}
+			if resp.StatusCode == 429 {
+				if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+					utils.LogDebug(fmt.Sprintf("Rate limited, retrying after %s", delay))
+					return utils.RetryAfterError(errors.New("Request failed"), delay)
+				}
+			}
 			return errors.New("Request failed")
 		}
 
@@ -271,15 +318,146 @@ func request(req *http.Request, verifyTLS bool, allowTimeout bool) (*http.Respon
 	return response, err
 }
 
+// bufferBody reads the response's body into memory and closes it, so that its connection is returned to the pool
+func bufferBody(resp *http.Response) {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		utils.LogDebug(err.Error())
+	}
+	if closeErr := resp.Body.Close(); closeErr != nil {
+		utils.LogDebug(closeErr.Error())
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+}
+
+// LoadCACert returns the system's certificate pool with the PEM-encoded certificates in the file added to it
+func LoadCACert(path string) (*x509.CertPool, error) {
+	// #nosec G304
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM-encoded certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// transportKey the settings that distinguish the shared transports
+type transportKey struct {
+	verifyTLS     bool
+	proxy         string
+	tlsServerName string
+	rootCAs       *x509.CertPool
+}
+
+var sharedTransports = map[transportKey]*http.Transport{}
+var sharedTransportsMutex sync.Mutex
+
+// sharedTransport the transport for the request's settings, which is created once and reused so that its connections can be kept alive
+func sharedTransport(req *http.Request, verifyTLS bool) *http.Transport {
+	key := transportKey{verifyTLS: verifyTLS, tlsServerName: TLSServerName, rootCAs: RootCAs}
+	if proxyUrl := requestProxy(req); proxyUrl != nil {
+		key.proxy = proxyUrl.String()
+	}
+
+	sharedTransportsMutex.Lock()
+	defer sharedTransportsMutex.Unlock()
+
+	transport, ok := sharedTransports[key]
+	if !ok {
+		transport = newTransport(req, verifyTLS)
+		sharedTransports[key] = transport
+	}
+	return transport
+}
+
+// requestProxy the proxy the request is sent through, or nil to connect directly
+func requestProxy(req *http.Request) *url.URL {
+	if ProxyURL != nil {
+		return ProxyURL
+	}
+
+	proxyUrl, err := http.ProxyFromEnvironment(req)
+	if err != nil {
+		utils.LogDebug("Unable to read proxy from environment")
+		utils.LogDebugError(err)
+		return nil
+	}
+	return proxyUrl
+}
+
+// newTransport the default transport, configured for TLS verification, the DNS resolver, and any proxy
+func newTransport(req *http.Request, verifyTLS bool) *http.Transport {
+	// set TLS config
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	// #nosec G402
+	if !verifyTLS {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	// the name used for SNI and to verify the certificate, rather than the request's host (e.g. when connecting via an IP address)
+	if TLSServerName != "" {
+		tlsConfig.ServerName = TLSServerName
+	}
+	if RootCAs != nil {
+		tlsConfig.RootCAs = RootCAs
+	}
+
+	// use custom DNS resolver
+	// the connect timeout is separate from the overall request timeout so that dead hosts fail fast
+	dialer := &net.Dialer{Timeout: connectTimeout()}
+	if UseCustomDNSResolver {
+		utils.LogDebug(fmt.Sprintf("Using custom DNS resolver %s", DNSResolverAddress))
+
+		dialer = &net.Dialer{
+			Timeout: connectTimeout(),
+			Resolver: &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+					d := net.Dialer{
+						Timeout: DNSResolverTimeout,
+					}
+					return d.DialContext(ctx, DNSResolverProto, DNSResolverAddress)
+				},
+			},
+		}
+	}
+	dialContext := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	proxyUrl := requestProxy(req)
+	if proxyUrl != nil {
+		utils.LogDebug(fmt.Sprintf("Using proxy %s", proxyUrl))
+	}
+
+	return &http.Transport{
+		TLSClientConfig: tlsConfig,
+		DialContext:     dialContext,
+		Proxy:           http.ProxyURL(proxyUrl),
+		// idle connections are kept for reuse by later requests, but closed quickly so that
+		// many concurrent CLI instances don't exhaust the OS's available network sockets
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     30 * time.Second,
+	}
+}
+
 func performSSERequest(req *http.Request, verifyTLS bool, handler func([]byte)) (int, http.Header, error) {
 	// nosemgrep: trailofbits.go.invalid-usage-of-modified-variable.invalid-usage-of-modified-variable
-	response, requestErr := request(req, verifyTLS, false)
+	response, requestErr := request(req, verifyTLS, false, nil)
 	if requestErr != nil {
 		statusCode := 0
 		if response != nil {
 			statusCode = response.StatusCode
 		}
-		return statusCode, nil, requestErr
+		return statusCode, nil, withClientRequestID(req, requestErr)
 	}
 
 	if response != nil {
@@ -311,8 +489,8 @@ func performSSERequest(req *http.Request, verifyTLS bool, handler func([]byte))
 	}
 }
 
-func performRequest(req *http.Request, verifyTLS bool) (int, http.Header, []byte, error) {
-	response, requestErr := request(req, verifyTLS, true)
+func performRequest(req *http.Request, verifyTLS bool, successCodes []int) (int, http.Header, []byte, error) {
+	response, requestErr := request(req, verifyTLS, true, successCodes)
 	if response != nil {
 		defer func() {
 			if closeErr := response.Body.Close(); closeErr != nil {
@@ -322,7 +500,7 @@ func performRequest(req *http.Request, verifyTLS bool) (int, http.Header, []byte
 	}
 
 	if requestErr != nil && response == nil {
-		return 0, nil, nil, requestErr
+		return 0, nil, nil, withClientRequestID(req, requestErr)
 	}
 
 	headers := response.Header.Clone()
@@ -343,16 +521,64 @@ func performRequest(req *http.Request, verifyTLS bool) (int, http.Header, []byte
 		err = json.Unmarshal(body, &errResponse)
 		if err != nil {
 			utils.LogDebug(fmt.Sprintf("Unable to parse response body: \n%s", string(body)))
-			return response.StatusCode, headers, nil, err
+			return response.StatusCode, headers, nil, withRequestID(headers, err)
 		}
 
-		return response.StatusCode, headers, body, errors.New(strings.Join(errResponse.Messages, "\n"))
+		return response.StatusCode, headers, body, withClientRequestID(req, withRequestID(headers, errors.New(strings.Join(errResponse.Messages, "\n"))))
+	}
+
+	return response.StatusCode, headers, nil, withClientRequestID(req, withRequestID(headers, fmt.Errorf("Request failed with HTTP %d", response.StatusCode)))
+}
+
+// withRequestID attaches the API-assigned request ID to the error, if the response included one
+func withRequestID(headers http.Header, err error) error {
+	requestID := headers.Get("x-request-id")
+	if requestID == "" {
+		return err
+	}
+
+	return utils.RequestIDError{Err: err, RequestID: requestID}
+}
+
+// withClientRequestID appends the client-generated request ID to the error, if one was sent
+func withClientRequestID(req *http.Request, err error) error {
+	if RequestIDHeader == "" {
+		return err
+	}
+
+	clientRequestID := req.Header.Get(RequestIDHeader)
+	if clientRequestID == "" {
+		return err
+	}
+
+	return fmt.Errorf("%w\nClient request ID: %s", err, clientRequestID)
+}
+
+// userAgent the user agent to send with each request, including the optional suffix
+func userAgent() string {
+	ua := "doppler-go-cli-" + version.ProgramVersion
+
+	// strip CR/LF to prevent header injection
+	suffix := strings.NewReplacer("\r", "", "\n", "").Replace(UserAgentSuffix)
+	suffix = strings.TrimSpace(suffix)
+	if suffix != "" {
+		ua = fmt.Sprintf("%s (%s)", ua, suffix)
 	}
 
-	return response.StatusCode, headers, nil, fmt.Errorf("Request failed with HTTP %d", response.StatusCode)
+	return ua
 }
 
-func isSuccess(statusCode int) bool {
+// isSuccess whether the status code is one of successCodes or, if none are specified, 2xx or 3xx
+func isSuccess(statusCode int, successCodes []int) bool {
+	if len(successCodes) > 0 {
+		for _, code := range successCodes {
+			if statusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+
 	return (statusCode >= 200 && statusCode <= 299) || (statusCode >= 300 && statusCode <= 399)
 }
 
@@ -363,6 +589,24 @@ func IsRetry(statusCode int, contentType string) bool {
 		(statusCode >= 500 && statusCode <= 599 && !strings.HasPrefix(contentType, "application/json"))
 }
 
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an HTTP date
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return date.Sub(now), true
+	}
+	return 0, false
+}
+
 func isTimeout(err error) bool {
 	if urlErr, ok := err.(*url.Error); ok {
 		if netErr, ok := urlErr.Err.(net.Error); ok {
@@ -0,0 +1,97 @@
+/*
+Copyright © 2021 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/DopplerHQ/cli/pkg/utils"
+)
+
+// requestLogEntry a single request attempt written to RequestLog. request and response bodies are never logged, as they may contain secrets
+type requestLogEntry struct {
+	Time            string            `json:"time"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	Attempt         int               `json:"attempt"`
+	Status          int               `json:"status,omitempty"`
+	DurationMs      int64             `json:"durationMs"`
+	RequestID       string            `json:"requestId,omitempty"`
+	ClientRequestID string            `json:"clientRequestId,omitempty"`
+	RequestHeaders  map[string]string `json:"requestHeaders"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// redactedHeaders headers whose values are replaced in the request log
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+var requestLogMutex sync.Mutex
+
+// logRequestAttempt writes the request attempt to RequestLog, if set
+func logRequestAttempt(req *http.Request, resp *http.Response, err error, attempt int, startTime time.Time) {
+	if RequestLog == nil {
+		return
+	}
+
+	entry := requestLogEntry{
+		Time:           startTime.UTC().Format(time.RFC3339Nano),
+		Method:         req.Method,
+		URL:            req.URL.Redacted(),
+		Attempt:        attempt,
+		DurationMs:     time.Since(startTime).Milliseconds(),
+		RequestHeaders: redactHeaders(req.Header),
+	}
+	if RequestIDHeader != "" {
+		entry.ClientRequestID = req.Header.Get(RequestIDHeader)
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+		entry.RequestID = resp.Header.Get("x-request-id")
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	line, e := json.Marshal(entry)
+	if e != nil {
+		utils.LogDebug(e.Error())
+		return
+	}
+
+	requestLogMutex.Lock()
+	defer requestLogMutex.Unlock()
+	if _, e := RequestLog.Write(append(line, '\n')); e != nil {
+		utils.LogDebug(e.Error())
+	}
+}
+
+// redactHeaders returns the first value of each header, with credentials redacted
+func redactHeaders(headers http.Header) map[string]string {
+	redacted := map[string]string{}
+	for name := range headers {
+		value := headers.Get(name)
+		for _, header := range redactedHeaders {
+			if http.CanonicalHeaderKey(header) == http.CanonicalHeaderKey(name) {
+				value = "[REDACTED]"
+			}
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
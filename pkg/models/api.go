@@ -17,12 +17,52 @@ package models
 
 // ComputedSecret holds all info about a secret
 type ComputedSecret struct {
-	Name               string  `json:"name"`
-	RawValue           *string `json:"raw"`
-	ComputedValue      *string `json:"computed"`
-	RawVisibility      string  `json:"rawVisibility"`
-	ComputedVisibility string  `json:"computedVisibility"`
-	Note               string  `json:"note"`
+	Name               string   `json:"name"`
+	RawValue           *string  `json:"raw"`
+	ComputedValue      *string  `json:"computed"`
+	RawVisibility      string   `json:"rawVisibility"`
+	ComputedVisibility string   `json:"computedVisibility"`
+	Note               string   `json:"note"`
+	Tags               []string `json:"tags"`
+	// Inherited whether the secret's value is inherited from the root config rather than overridden in this config.
+	// nil when the API doesn't report it
+	Inherited *bool `json:"inherited,omitempty"`
+	// CreatedAt and UpdatedAt when the secret was created and last changed, in RFC 3339 format. empty when unknown
+	CreatedAt string `json:"createdAt,omitempty"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// Secret difference statuses
+const (
+	SecretChanged    = "changed"
+	SecretOnlyFirst  = "only-first"
+	SecretOnlySecond = "only-second"
+	SecretUnknown    = "unknown" // restricted in both configs, so the values can't be compared
+)
+
+// SecretDifference a secret whose raw value differs between two configs. A nil value means the secret isn't set in that config
+type SecretDifference struct {
+	Name   string  `json:"name"`
+	Status string  `json:"status"`
+	First  *string `json:"first"`
+	Second *string `json:"second"`
+}
+
+// SecretResolutionStep one step in tracing how a secret's value resolves. Steps are ordered depth-first
+type SecretResolutionStep struct {
+	// Depth how many references away from the traced secret this secret is
+	Depth int `json:"depth"`
+	// Reference the reference that led to this secret (e.g. '${dev.API_KEY}'). empty for the traced secret
+	Reference string `json:"reference,omitempty"`
+	Name      string `json:"name"`
+	Project   string `json:"project"`
+	Config    string `json:"config"`
+	// RawValue and ComputedValue are nil when restricted or when the secret couldn't be resolved
+	RawValue      *string `json:"raw"`
+	ComputedValue *string `json:"computed"`
+	Inherited     *bool   `json:"inherited,omitempty"`
+	// Error why this step couldn't be resolved (e.g. the secret doesn't exist)
+	Error string `json:"error,omitempty"`
 }
 
 // ChangeRequest can be used to smartly update secrets
@@ -32,6 +72,7 @@ type ChangeRequest struct {
 	Name          string      `json:"name"`
 	Value         string      `json:"value"`
 	ShouldDelete  bool        `json:"shouldDelete"`
+	Tags          []string    `json:"tags,omitempty"`
 }
 
 // SecretNote contains a secret and its note
@@ -74,6 +115,8 @@ type ConfigInfo struct {
 	CreatedAt      string `json:"created_at"`
 	InitialFetchAt string `json:"initial_fetch_at"`
 	LastFetchAt    string `json:"last_fetch_at"`
+	// MissingVariables required secrets that haven't been set. the config isn't deployable until this is empty
+	MissingVariables []string `json:"missing_variables,omitempty"`
 }
 
 // ConfigLog a log
@@ -101,6 +144,26 @@ type ActivityLog struct {
 	User               User   `json:"user"`
 }
 
+// ActivityLogOutput the stable shape of an activity log in JSON output, independent of the API's field names
+type ActivityLogOutput struct {
+	// ID the activity log's unique ID
+	ID string `json:"id"`
+	// Text a plain text description of the activity
+	Text string `json:"text"`
+	// HTML an HTML description of the activity
+	HTML string `json:"html"`
+	// CreatedAt when the activity occurred, in RFC 3339 format
+	CreatedAt string `json:"created_at"`
+	// Project the project the activity occurred in, if any
+	Project string `json:"project"`
+	// Environment the environment the activity occurred in, if any
+	Environment string `json:"environment"`
+	// Config the config the activity occurred in, if any
+	Config string `json:"config"`
+	// User the user who performed the activity
+	User User `json:"user"`
+}
+
 // User user profile
 type User struct {
 	Email        string `json:"email"`
@@ -137,11 +200,15 @@ type APISecretResponse struct {
 
 // APISecret is the object the API returns for a given secret
 type APISecret struct {
-	RawValue           *string `json:"raw"`
-	ComputedValue      *string `json:"computed"`
-	RawVisibility      string  `json:"rawVisibility"`
-	ComputedVisibility string  `json:"computedVisibility"`
-	Note               string  `json:"note"`
+	RawValue           *string  `json:"raw"`
+	ComputedValue      *string  `json:"computed"`
+	RawVisibility      string   `json:"rawVisibility"`
+	ComputedVisibility string   `json:"computedVisibility"`
+	Note               string   `json:"note"`
+	Tags               []string `json:"tags"`
+	Inherited          *bool    `json:"inherited"`
+	CreatedAt          string   `json:"createdAt"`
+	UpdatedAt          string   `json:"updatedAt"`
 }
 
 type ActorInfo struct {
@@ -158,6 +225,18 @@ type ActorWorkplaceInfo struct {
 	Slug string `json:"slug"`
 }
 
+// PingResult whether the API is reachable and the token is valid
+type PingResult struct {
+	APIHost       string `json:"apiHost"`
+	Reachable     bool   `json:"reachable"`
+	LatencyMs     int64  `json:"latencyMs,omitempty"`
+	Authenticated bool   `json:"authenticated"`
+	Workplace     string `json:"workplace,omitempty"`
+	Scope         string `json:"scope,omitempty"`
+	TokenSource   string `json:"tokenSource,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
 type WatchSecrets struct {
 	Type string `json:"type"`
 }
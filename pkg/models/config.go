@@ -16,6 +16,7 @@ limitations under the License.
 package models
 
 import (
+	"sort"
 	"time"
 )
 
@@ -36,6 +37,7 @@ type FileScopedOptions struct {
 	VerifyTLS      string `json:"verify-tls,omitempty" yaml:"verify-tls,omitempty"`
 	EnclaveProject string `json:"enclave.project,omitempty" yaml:"enclave.project,omitempty"`
 	EnclaveConfig  string `json:"enclave.config,omitempty" yaml:"enclave.config,omitempty"`
+	CACert         string `json:"ca-cert,omitempty" yaml:"ca-cert,omitempty"`
 }
 
 // VersionCheck info about the last check for the latest cli version
@@ -54,6 +56,27 @@ type TUIOptions struct {
 	IntroVersionSeen int `yaml:"introVersionSeen"`
 }
 
+// APIEnvironment the hosts of a Doppler environment
+type APIEnvironment struct {
+	APIHost       string
+	DashboardHost string
+}
+
+// APIEnvironments environments that can be selected by name via --api-env rather than specifying their hosts
+var APIEnvironments = map[string]APIEnvironment{
+	"production": {APIHost: "https://api.doppler.com", DashboardHost: "https://dashboard.doppler.com"},
+}
+
+// APIEnvironmentNames the names of APIEnvironments, sorted
+func APIEnvironmentNames() []string {
+	var names []string
+	for name := range APIEnvironments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // ScopedOptions options with their scope
 type ScopedOptions struct {
 	Token          ScopedOption `json:"token,omitempty" yaml:"token,omitempty"`
@@ -62,6 +85,7 @@ type ScopedOptions struct {
 	VerifyTLS      ScopedOption `json:"verify-tls,omitempty" yaml:"verify-tls,omitempty"`
 	EnclaveProject ScopedOption `json:"enclave.project,omitempty" yaml:"enclave.project,omitempty"`
 	EnclaveConfig  ScopedOption `json:"enclave.config,omitempty" yaml:"enclave.config,omitempty"`
+	CACert         ScopedOption `json:"ca-cert,omitempty" yaml:"ca-cert,omitempty"`
 }
 
 // ScopedOption value and its scope
@@ -71,6 +95,14 @@ type ScopedOption struct {
 	Source string `json:"source"`
 }
 
+// RequiredOption whether a required config option resolves to a value, and where the value came from
+type RequiredOption struct {
+	Name      string `json:"name"`
+	Satisfied bool   `json:"satisfied"`
+	Source    string `json:"source,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+}
+
 type source int
 
 // the source of the value
@@ -92,6 +124,7 @@ var allConfigOptions = []string{
 	"verify-tls",
 	"enclave.project",
 	"enclave.config",
+	"ca-cert",
 }
 
 type configOption int
@@ -104,6 +137,7 @@ const (
 	ConfigVerifyTLS
 	ConfigEnclaveProject
 	ConfigEnclaveConfig
+	ConfigCACert
 )
 
 func (s configOption) String() string {
@@ -124,6 +158,7 @@ func OptionsMap(conf FileScopedOptions) map[string]string {
 		ConfigVerifyTLS.String():      conf.VerifyTLS,
 		ConfigEnclaveProject.String(): conf.EnclaveProject,
 		ConfigEnclaveConfig.String():  conf.EnclaveConfig,
+		ConfigCACert.String():         conf.CACert,
 	}
 }
 
@@ -136,6 +171,7 @@ func ScopedOptionsMap(conf *ScopedOptions) map[string]*ScopedOption {
 		ConfigVerifyTLS.String():      &conf.VerifyTLS,
 		ConfigEnclaveProject.String(): &conf.EnclaveProject,
 		ConfigEnclaveConfig.String():  &conf.EnclaveConfig,
+		ConfigCACert.String():         &conf.CACert,
 	}
 }
 
@@ -148,6 +184,7 @@ func ScopedOptionsStringMap(conf *ScopedOptions) map[string]string {
 		ConfigVerifyTLS.String():      conf.VerifyTLS.Value,
 		ConfigEnclaveProject.String(): conf.EnclaveProject.Value,
 		ConfigEnclaveConfig.String():  conf.EnclaveConfig.Value,
+		ConfigCACert.String():         conf.CACert.Value,
 	}
 }
 
@@ -160,6 +197,7 @@ func EnvOptions(conf *ScopedOptions) map[string]*ScopedOption {
 		"DOPPLER_VERIFY_TLS":     &conf.VerifyTLS,
 		"DOPPLER_PROJECT":        &conf.EnclaveProject,
 		"DOPPLER_CONFIG":         &conf.EnclaveConfig,
+		"DOPPLER_CA_CERT":        &conf.CACert,
 		"ENCLAVE_PROJECT":        &conf.EnclaveProject, // deprecated, remove in v4
 		"ENCLAVE_CONFIG":         &conf.EnclaveConfig,  // deprecated, remove in v4
 	}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package models
+
+// Example a runnable example of a CLI command
+type Example struct {
+	Description   string   `json:"description"`
+	Command       string   `json:"command"`
+	RequiredFlags []string `json:"requiredFlags"`
+}
+
+// Examples curated examples, keyed by command
+var Examples = map[string][]Example{
+	"run": {
+		{
+			Description:   "Run a command with secrets injected into the environment",
+			Command:       "doppler run -- printenv",
+			RequiredFlags: []string{},
+		},
+		{
+			Description:   "Run a command from a specific project and config",
+			Command:       "doppler run --project backend --config dev -- printenv",
+			RequiredFlags: []string{"--project", "--config"},
+		},
+		{
+			Description:   "Run a shell command string, allowing the use of pipes and operators",
+			Command:       "doppler run --command 'echo $DOPPLER_CONFIG && printenv'",
+			RequiredFlags: []string{"--command"},
+		},
+		{
+			Description:   "Mount secrets to an ephemeral file instead of the environment",
+			Command:       "doppler run --mount secrets.json -- cat secrets.json",
+			RequiredFlags: []string{"--mount"},
+		},
+		{
+			Description:   "Use a fallback file when the Doppler API is unreachable",
+			Command:       "doppler run --fallback ./fallback.json -- printenv",
+			RequiredFlags: []string{"--fallback"},
+		},
+	},
+	"secrets": {
+		{
+			Description:   "List all secrets in the current config",
+			Command:       "doppler secrets",
+			RequiredFlags: []string{},
+		},
+		{
+			Description:   "Print the value of a single secret",
+			Command:       "doppler secrets get API_KEY --plain",
+			RequiredFlags: []string{"--plain"},
+		},
+		{
+			Description:   "Set multiple secrets",
+			Command:       "doppler secrets set API_KEY=123 DATABASE_URL=postgres://localhost:5432",
+			RequiredFlags: []string{},
+		},
+		{
+			Description:   "Set a secret from stdin to avoid exposing its value in shell history",
+			Command:       "echo -n '123' | doppler secrets set API_KEY",
+			RequiredFlags: []string{},
+		},
+		{
+			Description:   "Print secrets in env format without writing to the filesystem",
+			Command:       "doppler secrets download --format env --no-file",
+			RequiredFlags: []string{"--format", "--no-file"},
+		},
+		{
+			Description:   "Delete a secret without a confirmation prompt",
+			Command:       "doppler secrets delete API_KEY --yes",
+			RequiredFlags: []string{"--yes"},
+		},
+	},
+	"configure": {
+		{
+			Description:   "View the active configuration",
+			Command:       "doppler configure",
+			RequiredFlags: []string{},
+		},
+		{
+			Description:   "View the active configuration and the source of each value",
+			Command:       "doppler configure debug",
+			RequiredFlags: []string{},
+		},
+		{
+			Description:   "Set the project and config for the current directory",
+			Command:       "doppler configure set project=backend config=dev",
+			RequiredFlags: []string{},
+		},
+		{
+			Description:   "Set the project for another directory",
+			Command:       "doppler configure set project=backend --scope /path/to/dir",
+			RequiredFlags: []string{"--scope"},
+		},
+		{
+			Description:   "Print a single configured value",
+			Command:       "doppler configure get token --plain",
+			RequiredFlags: []string{"--plain"},
+		},
+		{
+			Description:   "Remove the configured project for the current directory",
+			Command:       "doppler configure unset project",
+			RequiredFlags: []string{},
+		},
+	},
+}
@@ -15,11 +15,15 @@ limitations under the License.
 */
 package models
 
+import "time"
+
 // SecretsFileMetadata contains metadata about a secrets file
 type SecretsFileMetadata struct {
 	Version string `json:"version,omitempty" yaml:"version,omitempty"`
 	ETag    string `json:"etag,omitempty" yaml:"etag,omitempty"`
 	Hash    string `json:"hash,omitempty" yaml:"hash,omitempty"`
+	// FetchedAt when the secrets file was last fetched from, or confirmed current by, the API
+	FetchedAt time.Time `json:"fetchedAt,omitempty" yaml:"fetchedAt,omitempty"`
 }
 
 // ParseSecretsFileMetadata parse secrets file metadata
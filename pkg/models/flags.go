@@ -19,18 +19,22 @@ const (
 	FlagAnalytics   string = "analytics"
 	FlagEnvWarning  string = "env-warning"
 	FlagUpdateCheck string = "update-check"
+	// FlagProtectLockedConfigs refuses secret writes to locked configs unless --force is specified
+	FlagProtectLockedConfigs string = "protect-locked-configs"
 )
 
 type Flags struct {
-	Analytics   *bool `yaml:"analytics,omitempty"`
-	EnvWarning  *bool `yaml:"env-warning,omitempty"`
-	UpdateCheck *bool `yaml:"update-check,omitempty"`
+	Analytics            *bool `yaml:"analytics,omitempty"`
+	EnvWarning           *bool `yaml:"env-warning,omitempty"`
+	UpdateCheck          *bool `yaml:"update-check,omitempty"`
+	ProtectLockedConfigs *bool `yaml:"protect-locked-configs,omitempty"`
 }
 
 var flags = []string{
 	FlagAnalytics,
 	FlagEnvWarning,
 	FlagUpdateCheck,
+	FlagProtectLockedConfigs,
 }
 
 func GetFlags() []string {
@@ -111,6 +111,13 @@ func ParseConfigInfo(info map[string]interface{}) ConfigInfo {
 	if info["last_fetch_at"] != nil {
 		configInfo.LastFetchAt = info["last_fetch_at"].(string)
 	}
+	if missingVariables, ok := info["missing_variables"].([]interface{}); ok {
+		for _, name := range missingVariables {
+			if s, ok := name.(string); ok {
+				configInfo.MissingVariables = append(configInfo.MissingVariables, s)
+			}
+		}
+	}
 
 	return configInfo
 }
@@ -231,6 +238,29 @@ func ParseActivityLog(log map[string]interface{}) ActivityLog {
 	return parsedLog
 }
 
+// ConvertActivityLogToOutput converts a parsed activity log to its stable JSON output shape
+func ConvertActivityLogToOutput(log ActivityLog) ActivityLogOutput {
+	return ActivityLogOutput{
+		ID:          log.ID,
+		Text:        log.Text,
+		HTML:        log.HTML,
+		CreatedAt:   log.CreatedAt,
+		Project:     log.EnclaveProject,
+		Environment: log.EnclaveEnvironment,
+		Config:      log.EnclaveConfig,
+		User:        log.User,
+	}
+}
+
+// ConvertActivityLogsToOutput converts parsed activity logs to their stable JSON output shape
+func ConvertActivityLogsToOutput(logs []ActivityLog) []ActivityLogOutput {
+	output := []ActivityLogOutput{}
+	for _, log := range logs {
+		output = append(output, ConvertActivityLogToOutput(log))
+	}
+	return output
+}
+
 func ConvertAPIToComputedSecrets(apiSecrets map[string]APISecret) map[string]ComputedSecret {
 	computed := map[string]ComputedSecret{}
 	for key, secret := range apiSecrets {
@@ -241,6 +271,10 @@ func ConvertAPIToComputedSecrets(apiSecrets map[string]APISecret) map[string]Com
 			RawVisibility:      secret.RawVisibility,
 			ComputedVisibility: secret.ComputedVisibility,
 			Note:               secret.Note,
+			Tags:               secret.Tags,
+			Inherited:          secret.Inherited,
+			CreatedAt:          secret.CreatedAt,
+			UpdatedAt:          secret.UpdatedAt,
 		}
 	}
 	return computed
@@ -26,9 +26,13 @@ const (
 	YAML
 	DOCKER
 	ENV_NO_QUOTES
+	TOML
+	CLOUDINIT
+	SYSTEMD
+	XML
 )
 
-var SecretFormats = []string{"json", "dotnet-json", "env", "yaml", "docker", "env-no-quotes"}
+var SecretFormats = []string{"json", "dotnet-json", "env", "yaml", "docker", "env-no-quotes", "toml", "cloudinit", "systemd", "xml"}
 
 func (s SecretsFormat) String() string {
 	return SecretFormats[s]
@@ -36,7 +40,12 @@ func (s SecretsFormat) String() string {
 
 // OutputFile the default secrets file name
 func (s SecretsFormat) OutputFile() string {
-	return [...]string{"doppler.json", "appsettings.json", "doppler.env", "secrets.yaml", "doppler.env", "doppler.env"}[s]
+	return [...]string{"doppler.json", "appsettings.json", "doppler.env", "secrets.yaml", "doppler.env", "doppler.env", "doppler.toml", "cloud-config.yaml", "doppler.env", "doppler.xml"}[s]
+}
+
+// RenderedLocally whether the format is rendered by the CLI rather than the API
+func (s SecretsFormat) RenderedLocally() bool {
+	return s == TOML || s == CLOUDINIT || s == SYSTEMD || s == XML
 }
 
 // SecretsFormatList list of supported secrets formats
@@ -49,4 +58,8 @@ func init() {
 	SecretsFormatList = append(SecretsFormatList, YAML)
 	SecretsFormatList = append(SecretsFormatList, DOCKER)
 	SecretsFormatList = append(SecretsFormatList, ENV_NO_QUOTES)
+	SecretsFormatList = append(SecretsFormatList, TOML)
+	SecretsFormatList = append(SecretsFormatList, CLOUDINIT)
+	SecretsFormatList = append(SecretsFormatList, SYSTEMD)
+	SecretsFormatList = append(SecretsFormatList, XML)
 }
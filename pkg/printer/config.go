@@ -36,6 +36,26 @@ func ScopedConfigSource(conf models.ScopedOptions, jsonFlag bool, source bool, o
 	pairs := models.ScopedOptionsMap(&conf)
 
 	if jsonFlag {
+		if source {
+			// include where each value came from (e.g. flag, environment, config file) so automation can assert on it
+			confMap := map[string]map[string]map[string]string{}
+
+			for name, pair := range pairs {
+				if *pair != (models.ScopedOption{}) {
+					scope := pair.Scope
+
+					if confMap[scope] == nil {
+						confMap[scope] = map[string]map[string]string{}
+					}
+
+					confMap[scope][name] = map[string]string{"value": pair.Value, "source": pair.Source}
+				}
+			}
+
+			JSON(confMap)
+			return
+		}
+
 		confMap := map[string]map[string]string{}
 
 		for name, pair := range pairs {
@@ -87,6 +107,47 @@ func ScopedConfigSource(conf models.ScopedOptions, jsonFlag bool, source bool, o
 	Table(headers, rows, TableOptions())
 }
 
+// RequiredOptions print whether each required config option is satisfied, and where its value came from
+func RequiredOptions(options []models.RequiredOption, jsonFlag bool) {
+	if jsonFlag {
+		JSON(options)
+		return
+	}
+
+	var rows [][]string
+	for _, option := range options {
+		rows = append(rows, []string{option.Name, strconv.FormatBool(option.Satisfied), option.Source, option.Scope})
+	}
+	Table([]string{"name", "satisfied", "source", "scope"}, rows, TableOptions())
+}
+
+// PingResult print whether the API is reachable and the token is valid
+func PingResult(result models.PingResult, jsonFlag bool) {
+	if jsonFlag {
+		JSON(result)
+		return
+	}
+
+	reachable := "no"
+	if result.Reachable {
+		reachable = fmt.Sprintf("yes (%dms)", result.LatencyMs)
+	}
+	authenticated := "no"
+	if result.Authenticated {
+		authenticated = "yes"
+		if result.Workplace != "" {
+			authenticated = fmt.Sprintf("yes (%s)", result.Workplace)
+		}
+	}
+	scope := "-"
+	if result.Scope != "" {
+		scope = fmt.Sprintf("%s (%s)", result.Scope, result.TokenSource)
+	}
+
+	rows := [][]string{{result.APIHost, reachable, authenticated, scope}}
+	Table([]string{"api host", "reachable", "authenticated", "token scope"}, rows, TableOptions())
+}
+
 // ScopedConfigValues print scoped config value(s)
 func ScopedConfigValues(conf models.ScopedOptions, args []string, values map[string]*models.ScopedOption, jsonFlag bool, plain bool, copy bool) {
 	if plain || copy {
@@ -105,7 +166,7 @@ func ScopedConfigValues(conf models.ScopedOptions, args []string, values map[str
 		}
 
 		if plain {
-			fmt.Println(print)
+			fmt.Fprintln(Output, print)
 			return
 		}
 	}
@@ -205,7 +266,7 @@ func Flag(flag string, value bool, jsonFlag bool, plain bool, copy bool) {
 		}
 
 		if plain {
-			fmt.Println(strconv.FormatBool(value))
+			fmt.Fprintln(Output, strconv.FormatBool(value))
 			return
 		}
 	}
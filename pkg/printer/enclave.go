@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -52,21 +53,21 @@ func ConfigLog(log models.ConfigLog, jsonFlag bool, diff bool) {
 
 	dateTime, err := time.Parse(time.RFC3339, log.CreatedAt)
 
-	fmt.Println("Log " + log.ID)
-	fmt.Println("User: " + log.User.Name + " <" + log.User.Email + ">")
+	fmt.Fprintln(Output, "Log "+log.ID)
+	fmt.Fprintln(Output, "User: "+log.User.Name+" <"+log.User.Email+">")
 	if err == nil {
-		fmt.Println("Date: " + dateTime.In(time.Local).String())
+		fmt.Fprintln(Output, "Date: "+dateTime.In(time.Local).String())
 	}
-	fmt.Println("")
-	fmt.Println("\t" + log.Text)
-	fmt.Println("")
+	fmt.Fprintln(Output, "")
+	fmt.Fprintln(Output, "\t"+log.Text)
+	fmt.Fprintln(Output, "")
 
 	if diff && len(log.Diff) > 0 {
-		fmt.Println("")
+		fmt.Fprintln(Output, "")
 
 		for i, logDiff := range log.Diff {
 			if i != 0 {
-				fmt.Println("")
+				fmt.Fprintln(Output, "")
 			}
 
 			if logDiff.Name == "" {
@@ -80,13 +81,134 @@ func ConfigLog(log models.ConfigLog, jsonFlag bool, diff bool) {
 	}
 }
 
+// SecretsHash print a hash of a config's secrets
+func SecretsHash(hash string, count int, jsonFlag bool) {
+	if jsonFlag {
+		JSON(map[string]interface{}{"hash": hash, "count": count})
+		return
+	}
+
+	fmt.Fprintln(Output, hash)
+}
+
+// SecretsDiff print the secrets that differ between two configs
+func SecretsDiff(differences []models.SecretDifference, firstConfig string, secondConfig string, namesOnly bool, jsonFlag bool) {
+	if namesOnly {
+		for i := range differences {
+			differences[i].First = nil
+			differences[i].Second = nil
+		}
+	}
+
+	if jsonFlag {
+		JSON(differences)
+		return
+	}
+
+	if len(differences) == 0 {
+		fmt.Fprintln(Output, "No differences")
+		return
+	}
+
+	var rows [][]string
+	for _, difference := range differences {
+		first, second := "", ""
+		switch difference.Status {
+		case models.SecretOnlyFirst:
+			first, second = diffValue(difference.First, namesOnly), "(not set)"
+		case models.SecretOnlySecond:
+			first, second = "(not set)", diffValue(difference.Second, namesOnly)
+		case models.SecretUnknown:
+			first, second = "(restricted)", "(restricted)"
+		default:
+			first, second = diffValue(difference.First, namesOnly), diffValue(difference.Second, namesOnly)
+		}
+		rows = append(rows, []string{difference.Name, first, second})
+	}
+	Table([]string{"name", firstConfig, secondConfig}, rows, TableOptions())
+}
+
+func diffValue(value *string, namesOnly bool) string {
+	if namesOnly {
+		return "(set)"
+	}
+	if value == nil {
+		return "(restricted)"
+	}
+	return *value
+}
+
+// SecretHistory print the change history of a secret
+func SecretHistory(logs []models.ConfigLog, jsonFlag bool) {
+	if jsonFlag {
+		JSON(logs)
+		return
+	}
+
+	var rows [][]string
+	for _, log := range logs {
+		date := log.CreatedAt
+		if dateTime, err := time.Parse(time.RFC3339, log.CreatedAt); err == nil {
+			date = dateTime.In(time.Local).String()
+		}
+
+		for _, diff := range log.Diff {
+			change := "updated"
+			if diff.Removed == "" {
+				change = "created"
+			} else if diff.Added == "" {
+				change = "deleted"
+			}
+
+			rows = append(rows, []string{date, fmt.Sprintf("%s <%s>", log.User.Name, log.User.Email), change, log.ID})
+		}
+	}
+	Table([]string{"date", "user", "change", "log"}, rows, TableOptions())
+}
+
+// SecretResolution print the steps of resolving a secret's value, indenting each referenced secret under the secret that references it
+func SecretResolution(steps []models.SecretResolutionStep, jsonFlag bool) {
+	if jsonFlag {
+		JSON(steps)
+		return
+	}
+
+	for _, step := range steps {
+		indent := strings.Repeat("  ", step.Depth)
+
+		heading := fmt.Sprintf("%s (%s.%s)", step.Name, step.Project, step.Config)
+		if step.Reference != "" {
+			heading = fmt.Sprintf("%s → %s", step.Reference, heading)
+		}
+		if step.Inherited != nil && *step.Inherited {
+			heading += " [inherited]"
+		}
+		fmt.Fprintln(Output, indent+heading)
+
+		if step.RawValue != nil || step.ComputedValue != nil {
+			fmt.Fprintln(Output, indent+"  raw:      "+resolutionValue(step.RawValue))
+			fmt.Fprintln(Output, indent+"  computed: "+resolutionValue(step.ComputedValue))
+		}
+		if step.Error != "" {
+			fmt.Fprintln(Output, indent+"  error:    "+step.Error)
+		}
+	}
+}
+
+func resolutionValue(value *string) string {
+	if value == nil {
+		return "[restricted]"
+	}
+	return *value
+}
+
 // ActivityLogs print activity logs
 func ActivityLogs(logs []models.ActivityLog, number int, jsonFlag bool) {
 	maxLogs := int(math.Min(float64(len(logs)), float64(number)))
 	logs = logs[0:maxLogs]
 
 	if jsonFlag {
-		JSON(logs)
+		JSON(models.ConvertActivityLogsToOutput(logs))
 		return
 	}
 
@@ -98,30 +220,65 @@ func ActivityLogs(logs []models.ActivityLog, number int, jsonFlag bool) {
 // ActivityLog print activity log
 func ActivityLog(log models.ActivityLog, jsonFlag bool, diff bool) {
 	if jsonFlag {
-		JSON(log)
+		JSON(models.ConvertActivityLogToOutput(log))
 		return
 	}
 
 	dateTime, err := time.Parse(time.RFC3339, log.CreatedAt)
 
-	fmt.Println("Log " + log.ID)
-	fmt.Println("User: " + log.User.Name + " <" + log.User.Email + ">")
+	fmt.Fprintln(Output, "Log "+log.ID)
+	fmt.Fprintln(Output, "User: "+log.User.Name+" <"+log.User.Email+">")
 	if err == nil {
-		fmt.Println("Date: " + dateTime.In(time.Local).String())
+		fmt.Fprintln(Output, "Date: "+dateTime.In(time.Local).String())
+	}
+	fmt.Fprintln(Output, "")
+	fmt.Fprintln(Output, "\t"+log.Text)
+	fmt.Fprintln(Output, "")
+}
+
+// secretSource describes whether the secret is inherited from the root config or overridden in this config
+func secretSource(secret models.ComputedSecret) string {
+	if secret.Inherited == nil {
+		return ""
+	}
+	if *secret.Inherited {
+		return "inherited"
+	}
+	return "local"
+}
+
+// secretAge describes when the secret was last changed (e.g. '3 days ago')
+func secretAge(secret models.ComputedSecret, now time.Time) string {
+	updatedAt, err := time.Parse(time.RFC3339, secret.UpdatedAt)
+	if err != nil {
+		return "unknown"
 	}
-	fmt.Println("")
-	fmt.Println("\t" + log.Text)
-	fmt.Println("")
+	return utils.FormatAge(now.Sub(updatedAt))
+}
+
+func nilIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
 }
 
 // JSON print object as json
 func JSON(structure interface{}) {
-	resp, err := json.Marshal(structure)
+	resp, err := MarshalJSON(structure)
 	if err != nil {
 		utils.HandleError(err)
 	}
 
-	fmt.Println(string(resp))
+	fmt.Fprintln(Output, string(resp))
+}
+
+// MarshalJSON marshals the structure on a single line, or indented when using --pretty. There's never a trailing newline
+func MarshalJSON(structure interface{}) ([]byte, error) {
+	if utils.PrettyJSON {
+		return json.MarshalIndent(structure, "", "  ")
+	}
+	return json.Marshal(structure)
 }
 
 // ConfigInfo print config
@@ -131,8 +288,8 @@ func ConfigInfo(info models.ConfigInfo, jsonFlag bool) {
 		return
 	}
 
-	rows := [][]string{{info.Name, info.InitialFetchAt, info.LastFetchAt, info.CreatedAt, info.Environment, info.Project}}
-	Table([]string{"name", "initial fetch", "last fetch", "created at", "environment", "project"}, rows, TableOptions())
+	rows := [][]string{{info.Name, info.InitialFetchAt, info.LastFetchAt, info.CreatedAt, info.Environment, info.Project, strconv.FormatBool(info.Locked)}}
+	Table([]string{"name", "initial fetch", "last fetch", "created at", "environment", "project", "locked"}, rows, TableOptions())
 }
 
 // ConfigsInfo print configs
@@ -145,9 +302,9 @@ func ConfigsInfo(info []models.ConfigInfo, jsonFlag bool) {
 	var rows [][]string
 	for _, configInfo := range info {
 		rows = append(rows, []string{configInfo.Name, configInfo.InitialFetchAt, configInfo.LastFetchAt, configInfo.CreatedAt,
-			configInfo.Environment, configInfo.Project})
+			configInfo.Environment, configInfo.Project, strconv.FormatBool(configInfo.Locked)})
 	}
-	Table([]string{"name", "initial fetch", "last fetch", "created at", "environment", "project"}, rows, TableOptions())
+	Table([]string{"name", "initial fetch", "last fetch", "created at", "environment", "project", "locked"}, rows, TableOptions())
 }
 
 // EnvironmentsInfo print environments
@@ -203,6 +360,15 @@ func ProjectInfo(info models.ProjectInfo, jsonFlag bool) {
 
 // Secrets print secrets
 func Secrets(secrets map[string]models.ComputedSecret, secretsToPrint []string, jsonFlag bool, plain bool, raw bool, copy bool, visibility bool) {
+	printSecrets(secrets, secretsToPrint, jsonFlag, plain, raw, copy, visibility, false)
+}
+
+// SecretsWithAge print secrets, including when each was last changed
+func SecretsWithAge(secrets map[string]models.ComputedSecret, secretsToPrint []string, jsonFlag bool, raw bool, visibility bool) {
+	printSecrets(secrets, secretsToPrint, jsonFlag, false, raw, false, visibility, true)
+}
+
+func printSecrets(secrets map[string]models.ComputedSecret, secretsToPrint []string, jsonFlag bool, plain bool, raw bool, copy bool, visibility bool, showAge bool) {
 	if len(secretsToPrint) == 0 {
 		for name := range secrets {
 			secretsToPrint = append(secretsToPrint, name)
@@ -213,7 +379,7 @@ func Secrets(secrets map[string]models.ComputedSecret, secretsToPrint []string,
 	if copy {
 		vals := []string{}
 		for _, name := range secretsToPrint {
-			if secrets[name] != (models.ComputedSecret{}) {
+			if _, ok := secrets[name]; ok {
 				if secrets[name].ComputedValue == nil {
 					utils.HandleError(fmt.Errorf("Unable to copy restricted value to clipboard"))
 				} else {
@@ -230,12 +396,20 @@ func Secrets(secrets map[string]models.ComputedSecret, secretsToPrint []string,
 	if jsonFlag {
 		secretsMap := map[string]map[string]interface{}{}
 		for _, name := range secretsToPrint {
-			if secrets[name] != (models.ComputedSecret{}) {
+			if _, ok := secrets[name]; ok {
 				secretsMap[name] = map[string]interface{}{
 					"note":               secrets[name].Note,
 					"computedVisibility": secrets[name].ComputedVisibility,
 				}
 
+				if len(secrets[name].Tags) > 0 {
+					secretsMap[name]["tags"] = secrets[name].Tags
+				}
+
+				if secrets[name].Inherited != nil {
+					secretsMap[name]["inherited"] = *secrets[name].Inherited
+				}
+
 				if secrets[name].ComputedValue != nil {
 					secretsMap[name]["computed"] = *secrets[name].ComputedValue
 				} else {
@@ -250,6 +424,11 @@ func Secrets(secrets map[string]models.ComputedSecret, secretsToPrint []string,
 						secretsMap[name]["raw"] = nil
 					}
 				}
+
+				if showAge {
+					secretsMap[name]["createdAt"] = nilIfEmpty(secrets[name].CreatedAt)
+					secretsMap[name]["updatedAt"] = nilIfEmpty(secrets[name].UpdatedAt)
+				}
 			}
 		}
 
@@ -258,9 +437,14 @@ func Secrets(secrets map[string]models.ComputedSecret, secretsToPrint []string,
 	}
 
 	var matchedSecrets []models.ComputedSecret
+	// only show the tags column when tags are in use, and the source column when the API reports it
+	hasTags := false
+	hasSource := false
 	for _, name := range secretsToPrint {
-		if secrets[name] != (models.ComputedSecret{}) {
-			matchedSecrets = append(matchedSecrets, secrets[name])
+		if secret, ok := secrets[name]; ok {
+			matchedSecrets = append(matchedSecrets, secret)
+			hasTags = hasTags || len(secret.Tags) > 0
+			hasSource = hasSource || secret.Inherited != nil
 		}
 	}
 
@@ -282,7 +466,7 @@ func Secrets(secrets map[string]models.ComputedSecret, secretsToPrint []string,
 			}
 		}
 
-		fmt.Println(strings.Join(vals, "\n"))
+		fmt.Fprintln(Output, strings.Join(vals, "\n"))
 		return
 	}
 
@@ -297,8 +481,18 @@ func Secrets(secrets map[string]models.ComputedSecret, secretsToPrint []string,
 		}
 		headers = append(headers, "raw value")
 	}
+	if hasTags {
+		headers = append(headers, "tags")
+	}
+	if hasSource {
+		headers = append(headers, "source")
+	}
+	if showAge {
+		headers = append(headers, "last changed")
+	}
 	headers = append(headers, "note")
 
+	now := time.Now()
 	var rows [][]string
 	for _, secret := range matchedSecrets {
 		var computedValue string
@@ -326,6 +520,15 @@ func Secrets(secrets map[string]models.ComputedSecret, secretsToPrint []string,
 			row = append(row, rawValue)
 		}
 
+		if hasTags {
+			row = append(row, strings.Join(secret.Tags, ", "))
+		}
+		if hasSource {
+			row = append(row, secretSource(secret))
+		}
+		if showAge {
+			row = append(row, secretAge(secret, now))
+		}
 		row = append(row, secret.Note)
 
 		rows = append(rows, row)
@@ -416,7 +619,7 @@ func ConfigServiceToken(token models.ConfigServiceToken, jsonFlag bool, plain bo
 	}
 
 	if plain {
-		fmt.Println(token.Token)
+		fmt.Fprintln(Output, token.Token)
 		return
 	}
 
@@ -17,8 +17,10 @@ package printer
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/DopplerHQ/cli/pkg/models"
 	"github.com/DopplerHQ/cli/pkg/utils"
@@ -39,6 +41,9 @@ type tableOptions struct {
 
 var maxTableWidth = 80
 
+// Output where printed output is written
+var Output io.Writer = os.Stdout
+
 func init() {
 	w, _, err := terminal.GetSize(int(os.Stdout.Fd()))
 	if err != nil {
@@ -57,7 +62,7 @@ func TableOptions() tableOptions {
 // Table print table
 func Table(headers []string, rows [][]string, options tableOptions) {
 	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+	t.SetOutputMirror(Output)
 	t.SetStyle(table.StyleLight)
 
 	t.SetTitle(options.Title)
@@ -86,6 +91,36 @@ func Table(headers []string, rows [][]string, options tableOptions) {
 	t.Render()
 }
 
+// Examples print curated command examples
+func Examples(examples map[string][]models.Example, jsonFlag bool) {
+	if jsonFlag {
+		JSON(examples)
+		return
+	}
+
+	var commands []string
+	for command := range examples {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	for i, command := range commands {
+		if i != 0 {
+			fmt.Fprintln(Output, "")
+		}
+
+		fmt.Fprintln(Output, color.Cyan.Sprintf("doppler %s", command))
+		for _, example := range examples[command] {
+			fmt.Fprintln(Output, "")
+			fmt.Fprintf(Output, "# %s\n", example.Description)
+			if len(example.RequiredFlags) > 0 {
+				fmt.Fprintf(Output, "# requires: %s\n", strings.Join(example.RequiredFlags, ", "))
+			}
+			fmt.Fprintf(Output, "$ %s\n", example.Command)
+		}
+	}
+}
+
 // ChangeLog print change log
 func ChangeLog(changes map[string]models.ChangeLog, max int, jsonFlag bool) {
 	if jsonFlag {
@@ -111,14 +146,14 @@ func ChangeLog(changes map[string]models.ChangeLog, max int, jsonFlag bool) {
 			break
 		}
 		if i != 0 {
-			fmt.Println("")
+			fmt.Fprintln(Output, "")
 		}
 
 		vString := version.Normalize(v.String())
-		fmt.Println(color.Cyan.Sprintf("CLI %s", vString))
+		fmt.Fprintln(Output, color.Cyan.Sprintf("CLI %s", vString))
 		cl := changes[vString]
 		for _, change := range cl.Changes {
-			fmt.Println(fmt.Sprintf("· %s", change))
+			fmt.Fprintf(Output, "· %s\n", change)
 		}
 	}
 }
@@ -26,3 +26,9 @@ var Silent = false
 
 // OutputJSON whether to print OutputJSON
 var OutputJSON = false
+
+// PrettyJSON whether to indent JSON output for readability, rather than printing it on a single line
+var PrettyJSON = false
+
+// FailOnWarning whether logging any warning causes a non-zero exit
+var FailOnWarning = false
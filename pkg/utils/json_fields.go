@@ -0,0 +1,83 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// JSONFieldNames returns the names of the fields a struct of type T has when marshaled to JSON, in declaration order
+func JSONFieldNames[T any]() []string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// ValidateJSONFields checks that each field is the JSON name of a field of T
+func ValidateJSONFields[T any](fields []string) error {
+	validFields := JSONFieldNames[T]()
+	for _, field := range fields {
+		if !Contains(validFields, field) {
+			return fmt.Errorf("invalid field %s. Valid fields are %v", field, validFields)
+		}
+	}
+	return nil
+}
+
+// SelectJSONFields returns each item as a JSON object containing only the named fields
+func SelectJSONFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	if err := ValidateJSONFields[T](fields); err != nil {
+		return nil, err
+	}
+
+	selected := []map[string]json.RawMessage{}
+	for _, item := range items {
+		body, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(body, &object); err != nil {
+			return nil, err
+		}
+		selected = append(selected, FilterMap(object, fields))
+	}
+	return selected, nil
+}
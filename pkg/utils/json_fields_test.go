@@ -0,0 +1,61 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonFieldsTestItem struct {
+	ID         string `json:"id"`
+	Name       string `json:"name,omitempty"`
+	Locked     bool   `json:"locked"`
+	Untagged   int
+	Ignored    string `json:"-"`
+	unexported string
+}
+
+func TestJSONFieldNames(t *testing.T) {
+	assert.Equal(t, []string{"id", "name", "locked", "Untagged"}, JSONFieldNames[jsonFieldsTestItem]())
+	assert.Nil(t, JSONFieldNames[string]())
+}
+
+func TestSelectJSONFields(t *testing.T) {
+	items := []jsonFieldsTestItem{{ID: "1", Name: "a", Locked: true, Untagged: 2}, {ID: "2", Locked: false}}
+
+	selected, err := SelectJSONFields(items, []string{"id", "locked", "Untagged"})
+	assert.NoError(t, err)
+	body, err := json.Marshal(selected)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"Untagged":2,"id":"1","locked":true},{"Untagged":0,"id":"2","locked":false}]`, string(body))
+
+	// fields omitted from an item's JSON are omitted from the selection
+	selected, err = SelectJSONFields(items, []string{"name"})
+	assert.NoError(t, err)
+	body, err = json.Marshal(selected)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name":"a"},{}]`, string(body))
+
+	selected, err = SelectJSONFields([]jsonFieldsTestItem{}, []string{"id"})
+	assert.NoError(t, err)
+	assert.Empty(t, selected)
+
+	_, err = SelectJSONFields(items, []string{"id", "Ignored"})
+	assert.EqualError(t, err, "invalid field Ignored. Valid fields are [id name locked Untagged]")
+}
@@ -17,20 +17,27 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync/atomic"
 
 	"gopkg.in/gookit/color.v1"
 )
 
+// Stdout where printed output is written. --output redirects it along with the printer's output
+var Stdout io.Writer = os.Stdout
+
 // Print output to stdout
 func Print(info string) {
-	fmt.Println(info)
+	fmt.Fprintln(Stdout, info)
 }
 
 // Print output to stdout.
 func PrintWarning(s string) {
-	fmt.Println(color.Yellow.Render("Warning:"), s)
+	fmt.Fprintln(Stdout, color.Yellow.Render("Warning:"), s)
 }
 
 // Log info message to stderr
@@ -38,11 +45,37 @@ func Log(info string) {
 	fmt.Fprintln(os.Stderr, info)
 }
 
+// warningCount the number of warnings logged via LogWarning
+var warningCount int32
+
 // LogWarning message to stderr
 func LogWarning(s string) {
+	atomic.AddInt32(&warningCount, 1)
 	fmt.Fprintln(os.Stderr, color.Yellow.Render("Warning:"), s)
 }
 
+// LogUncountedWarning logs a warning to stderr that isn't counted towards --fail-on-warning, for conditions the user
+// has explicitly opted into
+func LogUncountedWarning(s string) {
+	fmt.Fprintln(os.Stderr, color.Yellow.Render("Warning:"), s)
+}
+
+// WarningCount the number of warnings logged so far
+func WarningCount() int {
+	return int(atomic.LoadInt32(&warningCount))
+}
+
+// ExitOnWarnings exits with code 1 if any warnings were logged and FailOnWarning is enabled
+func ExitOnWarnings() {
+	if count := WarningCount(); FailOnWarning && count > 0 {
+		pluralized := "warnings were"
+		if count == 1 {
+			pluralized = "warning was"
+		}
+		HandleError(fmt.Errorf("%d %s logged while using --fail-on-warning", count, pluralized))
+	}
+}
+
 // LogError prints an error message to stderr
 func LogError(e error) {
 	printError(e)
@@ -82,11 +115,7 @@ func HandleError(e error, messages ...string) {
 // ErrExit prints the error and exits with the specified code
 func ErrExit(e error, exitCode int, messages ...string) {
 	if OutputJSON {
-		resp, err := json.Marshal(map[string]string{"error": e.Error()})
-		if err != nil {
-			panic(err)
-		}
-		fmt.Fprintln(os.Stderr, string(resp))
+		fmt.Fprintln(os.Stderr, string(errorJSON(e, messages...)))
 	} else {
 		if len(messages) > 0 && messages[0] != "" {
 			fmt.Fprintln(os.Stderr, messages[0])
@@ -103,9 +132,66 @@ func ErrExit(e error, exitCode int, messages ...string) {
 		}
 	}
 
+	RestoreTerminals()
 	os.Exit(exitCode)
 }
 
+// jsonError the object printed to stderr for fatal errors when JSON output is requested
+type jsonError struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	// Error the underlying error, without any additional messages. kept for compatibility with older consumers
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// errorJSON formats a fatal error as JSON, including the messages that would otherwise be printed around it
+func errorJSON(e error, messages ...string) []byte {
+	var lines []string
+	if len(messages) > 0 && messages[0] != "" {
+		lines = append(lines, messages[0])
+	}
+	errorMessage := ""
+	if e != nil {
+		errorMessage = e.Error()
+		lines = append(lines, errorMessage)
+	}
+	if len(messages) > 0 {
+		lines = append(lines, messages[1:]...)
+	}
+
+	resp, err := json.Marshal(jsonError{
+		Success:   false,
+		Message:   strings.Join(lines, "\n"),
+		Error:     errorMessage,
+		RequestID: ErrorRequestID(e),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return resp
+}
+
+// RequestIDError an error along with the ID the API assigned to the failed request (via the 'x-request-id' header)
+type RequestIDError struct {
+	Err       error
+	RequestID string
+}
+
+func (e RequestIDError) Error() string { return e.Err.Error() }
+
+// Unwrap get the original error
+func (e RequestIDError) Unwrap() error { return e.Err }
+
+// ErrorRequestID returns the API request ID attached to the error, if any
+func ErrorRequestID(e error) string {
+	var requestIDErr RequestIDError
+	if errors.As(e, &requestIDErr) {
+		return requestIDErr.RequestID
+	}
+	return ""
+}
+
 func printError(e error) {
 	fmt.Fprintln(os.Stderr, color.Red.Render("Doppler Error:"), e)
 }
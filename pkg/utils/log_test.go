@@ -0,0 +1,46 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorJSON(t *testing.T) {
+	var output map[string]interface{}
+
+	err := fmt.Errorf("wrapped: %w", RequestIDError{Err: errors.New("Forbidden"), RequestID: "abc123"})
+	assert.NoError(t, json.Unmarshal(errorJSON(err, "Unable to fetch secrets"), &output))
+	assert.Equal(t, map[string]interface{}{
+		"success":    false,
+		"message":    "Unable to fetch secrets\nwrapped: Forbidden",
+		"error":      "wrapped: Forbidden",
+		"request_id": "abc123",
+	}, output)
+
+	output = nil
+	assert.NoError(t, json.Unmarshal(errorJSON(errors.New("invalid format")), &output))
+	assert.Equal(t, map[string]interface{}{
+		"success": false,
+		"message": "invalid format",
+		"error":   "invalid format",
+	}, output)
+}
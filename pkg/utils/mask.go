@@ -0,0 +1,72 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var pemHeaderRegex = regexp.MustCompile(`^-----BEGIN ([A-Z0-9 ]+)-----`)
+var jwtRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`)
+
+// MaskValue masks all but the last 4 characters of values long enough that doing so doesn't reveal most of the value
+func MaskValue(value string) string {
+	if len(value) < 12 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
+
+// SmartMaskValue describes values in recognized formats (PEM, JWT, URL) without revealing them, otherwise masking them with MaskValue
+func SmartMaskValue(value string) string {
+	trimmed := strings.TrimSpace(value)
+
+	// PEM: show the block type and size (e.g. "PEM RSA PRIVATE KEY (1675 bytes)")
+	if matches := pemHeaderRegex.FindStringSubmatch(trimmed); matches != nil {
+		return fmt.Sprintf("PEM %s (%d bytes)", matches[1], len(value))
+	}
+
+	// JWT: show the signing algorithm from the header, which isn't secret
+	if jwtRegex.MatchString(trimmed) {
+		header, err := base64.RawURLEncoding.DecodeString(strings.SplitN(trimmed, ".", 2)[0])
+		var parsed map[string]interface{}
+		if err == nil && json.Unmarshal(header, &parsed) == nil {
+			if alg, ok := parsed["alg"].(string); ok {
+				return fmt.Sprintf("JWT (alg: %s)", alg)
+			}
+		}
+	}
+
+	// URL: show the scheme and host, masking credentials, path, and query
+	if parsed, err := url.Parse(trimmed); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		masked := parsed.Scheme + "://"
+		if parsed.User != nil {
+			masked += "****@"
+		}
+		masked += parsed.Host
+		if (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.Fragment != "" {
+			masked += "/****"
+		}
+		return masked
+	}
+
+	return MaskValue(value)
+}
@@ -0,0 +1,89 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ParseProcessUser resolves a user spec of the form 'user[:group]', where user and group are either names or numeric IDs
+func ParseProcessUser(spec string) (*ProcessUser, error) {
+	userSpec, groupSpec, hasGroup := strings.Cut(spec, ":")
+	if userSpec == "" || (hasGroup && groupSpec == "") {
+		return nil, fmt.Errorf("invalid user %q, expected format 'user[:group]'", spec)
+	}
+
+	processUser := &ProcessUser{}
+	var u *user.User
+	if uid, err := strconv.ParseUint(userSpec, 10, 32); err == nil {
+		processUser.UID = uint32(uid)
+		// the user may not exist in the user database, which is valid for numeric IDs
+		u, _ = user.LookupId(userSpec)
+	} else {
+		u, err = user.Lookup(userSpec)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find user %s", userSpec)
+		}
+		uid, err := strconv.ParseUint(u.Uid, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse uid of user %s", userSpec)
+		}
+		processUser.UID = uint32(uid)
+	}
+
+	if hasGroup {
+		if gid, err := strconv.ParseUint(groupSpec, 10, 32); err == nil {
+			processUser.GID = uint32(gid)
+		} else {
+			g, err := user.LookupGroup(groupSpec)
+			if err != nil {
+				return nil, fmt.Errorf("unable to find group %s", groupSpec)
+			}
+			gid, err := strconv.ParseUint(g.Gid, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse gid of group %s", groupSpec)
+			}
+			processUser.GID = uint32(gid)
+		}
+	} else if u != nil {
+		gid, err := strconv.ParseUint(u.Gid, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse primary gid of user %s", userSpec)
+		}
+		processUser.GID = uint32(gid)
+	} else {
+		// numeric user with no database entry; use a matching gid
+		processUser.GID = processUser.UID
+	}
+
+	return processUser, nil
+}
+
+func setProcessUser(cmd *exec.Cmd, processUser *ProcessUser) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// supplementary groups are cleared so the child doesn't inherit ours
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: processUser.UID, Gid: processUser.GID, Groups: []uint32{}}
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProcessUser(t *testing.T) {
+	u, err := ParseProcessUser("1000:2000")
+	assert.Nil(t, err)
+	assert.Equal(t, &ProcessUser{UID: 1000, GID: 2000}, u)
+
+	u, err = ParseProcessUser("root")
+	assert.Nil(t, err)
+	assert.Equal(t, &ProcessUser{UID: 0, GID: 0}, u)
+
+	_, err = ParseProcessUser("")
+	assert.NotNil(t, err)
+
+	_, err = ParseProcessUser("1000:")
+	assert.NotNil(t, err)
+
+	_, err = ParseProcessUser("doppler-nonexistent-user")
+	assert.NotNil(t, err)
+}
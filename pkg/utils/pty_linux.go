@@ -0,0 +1,155 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+const SupportsPTY = true
+
+// openPTY allocates a pseudo-terminal, returning its master and slave ends
+func openPTY() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// copyWindowSize sizes the pseudo-terminal to match the user's terminal
+func copyWindowSize(from *os.File, to *os.File) {
+	size, err := unix.IoctlGetWinsize(int(from.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		LogDebugError(err)
+		return
+	}
+	if err := unix.IoctlSetWinsize(int(to.Fd()), unix.TIOCSWINSZ, size); err != nil {
+		LogDebugError(err)
+	}
+}
+
+// execCommandWithPTY starts the command attached to a new pseudo-terminal, relaying the terminal's input and output
+// to inFile and outFile. the user's terminal is placed in raw mode until WaitCommand or RestoreTerminals is called
+func execCommandWithPTY(cmd *exec.Cmd, inFile io.Reader, outFile io.Writer, forwardSignals bool) error {
+	master, slave, err := openPTY()
+	if err != nil {
+		return fmt.Errorf("unable to allocate a pseudo-terminal: %w", err)
+	}
+
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// make the pseudo-terminal the controlling terminal of a new session, so job control and signals work as expected
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+
+	inTerminal, isFile := inFile.(*os.File)
+	if isFile && !term.IsTerminal(int(inTerminal.Fd())) {
+		inTerminal = nil
+	}
+	if inTerminal != nil {
+		copyWindowSize(inTerminal, master)
+	}
+
+	if err := execCommand(cmd, forwardSignals); err != nil {
+		slave.Close()
+		master.Close()
+		return err
+	}
+	// the child holds its own copy of the slave; closing ours lets reads from the master end once the child exits
+	slave.Close()
+
+	resize := make(chan os.Signal, 1)
+	if inTerminal != nil {
+		if oldState, err := term.MakeRaw(int(inTerminal.Fd())); err != nil {
+			LogDebugError(err)
+		} else {
+			// restored when the command exits, or earlier if the CLI exits first (e.g. due to an error)
+			terminalRestores.Store(cmd, sync.OnceFunc(func() {
+				if err := term.Restore(int(inTerminal.Fd()), oldState); err != nil {
+					LogDebugError(err)
+				}
+			}))
+		}
+
+		signal.Notify(resize, syscall.SIGWINCH)
+		go func() {
+			for range resize {
+				copyWindowSize(inTerminal, master)
+			}
+		}()
+	}
+
+	if inFile != nil {
+		go func() {
+			_, _ = io.Copy(master, inFile)
+		}()
+	}
+	outputDone := make(chan struct{})
+	go func() {
+		// reading the master returns EIO once the child has exited
+		_, _ = io.Copy(outFile, master)
+		close(outputDone)
+	}()
+
+	ptyCleanups.Store(cmd, func() {
+		// a background process may keep the terminal open after the command exits, so don't wait indefinitely for output
+		select {
+		case <-outputDone:
+		case <-time.After(time.Second):
+		}
+		signal.Stop(resize)
+		close(resize)
+		if restore, ok := terminalRestores.LoadAndDelete(cmd); ok {
+			restore.(func())()
+		}
+		master.Close()
+	})
+
+	return nil
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package utils
 
 import (
+	"context"
 	"math/rand"
 	"time"
 )
@@ -24,25 +25,102 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-func Retry(attempts int, sleep time.Duration, f func() error) error {
-	if err := f(); err != nil {
-		if s, ok := err.(StopRetry); ok {
-			// Return the original error for later checking
-			return s.error
+// Backoff returns the delay before the specified retry (starting at 0), given the base delay
+type Backoff func(base time.Duration, retry int) time.Duration
+
+// maxBackoffDelay caps the exponential growth of the delay between attempts
+const maxBackoffDelay = 30 * time.Second
+
+// BackoffStrategies the available backoff strategies, by name
+var BackoffStrategies = map[string]Backoff{
+	"none":         NoBackoff,
+	"exponential":  ExponentialBackoff,
+	"full-jitter":  FullJitterBackoff,
+	"equal-jitter": EqualJitterBackoff,
+}
+
+// BackoffStrategyNames the names of the available backoff strategies
+var BackoffStrategyNames = []string{"none", "exponential", "full-jitter", "equal-jitter"}
+
+// NoBackoff waits the base delay between every attempt
+func NoBackoff(base time.Duration, retry int) time.Duration {
+	return base
+}
+
+// ExponentialBackoff doubles the delay after each attempt
+func ExponentialBackoff(base time.Duration, retry int) time.Duration {
+	delay := base
+	for i := 0; i < retry && delay < maxBackoffDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoffDelay {
+		return maxBackoffDelay
+	}
+	return delay
+}
+
+// FullJitterBackoff waits a random delay between 0 and the exponential delay.
+// This spreads out retries from many clients that failed at the same time (e.g. after an outage)
+func FullJitterBackoff(base time.Duration, retry int) time.Duration {
+	delay := ExponentialBackoff(base, retry)
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay))) // #nosec G404
+}
+
+// EqualJitterBackoff waits half the exponential delay plus a random delay of up to the other half
+func EqualJitterBackoff(base time.Duration, retry int) time.Duration {
+	half := ExponentialBackoff(base, retry) / 2
+	if half <= 0 {
+		return 0
+	}
+	return half + time.Duration(rand.Int63n(int64(half))) // #nosec G404
+}
+
+// Retry calls f up to the specified number of attempts, waiting between attempts according to the backoff strategy
+func Retry(attempts int, sleep time.Duration, backoff Backoff, f func() error) error {
+	return RetryWithContext(context.Background(), attempts, sleep, backoff, f)
+}
+
+// RetryWithContext is like Retry, but returns the context's error as soon as it's cancelled rather than waiting out the delay
+func RetryWithContext(ctx context.Context, attempts int, sleep time.Duration, backoff Backoff, f func() error) error {
+	var err error
+	for retry := 0; retry < attempts; retry++ {
+		if retry > 0 {
+			var delay time.Duration
+			if r, ok := err.(RetryAfter); ok {
+				delay = r.delay
+			} else {
+				delay = backoff(sleep, retry-1)
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
 		}
 
-		if attempts--; attempts > 0 {
-			// Add some randomness to prevent creating a Thundering Herd
-			jitter := time.Duration(rand.Int63n(int64(sleep))) // #nosec G404
-			sleep = sleep + jitter/2
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 
-			time.Sleep(sleep)
-			return Retry(attempts, 2*sleep, f)
+		if err = f(); err == nil {
+			return nil
+		}
+		if s, ok := err.(StopRetry); ok {
+			// Return the original error for later checking
+			return s.error
 		}
-		return err
 	}
 
-	return nil
+	if r, ok := err.(RetryAfter); ok {
+		return r.error
+	}
+	return err
 }
 
 func StopRetryError(err error) StopRetry {
@@ -53,3 +131,21 @@ func StopRetryError(err error) StopRetry {
 type StopRetry struct {
 	error
 }
+
+// RetryAfterError indicates to wait the specified delay before the next attempt, rather than the backoff strategy's delay.
+// The delay is capped, as it may come from an untrusted source (e.g. a Retry-After header)
+func RetryAfterError(err error, delay time.Duration) RetryAfter {
+	if delay > maxBackoffDelay {
+		delay = maxBackoffDelay
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return RetryAfter{err, delay}
+}
+
+// RetryAfter indicates to wait a specific delay before the next attempt. wraps an error
+type RetryAfter struct {
+	error
+	delay time.Duration
+}
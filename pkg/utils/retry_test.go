@@ -0,0 +1,131 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffStrategies(t *testing.T) {
+	base := 500 * time.Millisecond
+
+	for retry := 0; retry < 10; retry++ {
+		exponential := base << retry
+		if exponential > maxBackoffDelay {
+			exponential = maxBackoffDelay
+		}
+
+		assert.Equal(t, base, NoBackoff(base, retry))
+		assert.Equal(t, exponential, ExponentialBackoff(base, retry))
+
+		for i := 0; i < 1000; i++ {
+			delay := FullJitterBackoff(base, retry)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.Less(t, delay, exponential)
+
+			delay = EqualJitterBackoff(base, retry)
+			assert.GreaterOrEqual(t, delay, exponential/2)
+			assert.Less(t, delay, exponential)
+		}
+	}
+
+	assert.Equal(t, maxBackoffDelay, ExponentialBackoff(base, 1000))
+	assert.Equal(t, time.Duration(0), FullJitterBackoff(0, 3))
+	assert.Equal(t, time.Duration(0), EqualJitterBackoff(0, 3))
+
+	for _, name := range BackoffStrategyNames {
+		assert.Contains(t, BackoffStrategies, name)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var retries []int
+	backoff := func(base time.Duration, retry int) time.Duration {
+		retries = append(retries, retry)
+		return 0
+	}
+
+	calls := 0
+	err := Retry(3, time.Second, backoff, func() error {
+		calls++
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []int{0, 1}, retries)
+
+	calls = 0
+	err = Retry(3, time.Second, backoff, func() error {
+		calls++
+		return StopRetryError(errors.New("stop"))
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	err = Retry(3, time.Second, backoff, func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// the requested delay is used instead of the backoff strategy's
+	retries = nil
+	calls = 0
+	start := time.Now()
+	err = Retry(2, time.Second, backoff, func() error {
+		calls++
+		return RetryAfterError(errors.New("rate limited"), 10*time.Millisecond)
+	})
+	assert.EqualError(t, err, "rate limited")
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, retries)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+}
+
+func TestRetryWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// cancelling the context interrupts the delay between attempts
+	calls := 0
+	start := time.Now()
+	err := RetryWithContext(ctx, 3, time.Minute, NoBackoff, func() error {
+		calls++
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return errors.New("failed")
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+	assert.Less(t, time.Since(start), time.Minute)
+
+	// a cancelled context makes no attempts
+	calls = 0
+	err = RetryWithContext(ctx, 3, 0, NoBackoff, func() error {
+		calls++
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, calls)
+}
@@ -0,0 +1,488 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+var tomlBareKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+var systemdEnvNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CloudInitOptions the file written by a cloud-init write_files entry
+type CloudInitOptions struct {
+	Path        string
+	Owner       string
+	Permissions string
+}
+
+type cloudInitFile struct {
+	Path        string `yaml:"path"`
+	Owner       string `yaml:"owner,omitempty"`
+	Permissions string `yaml:"permissions"`
+	Encoding    string `yaml:"encoding"`
+	Content     string `yaml:"content"`
+}
+
+// MapToCloudInitFormat renders secrets as a cloud-config document with a write_files entry
+// that writes the secrets to an env file when the instance first boots
+func MapToCloudInitFormat(secrets map[string]string, opts CloudInitOptions) (string, error) {
+	if opts.Path == "" {
+		return "", fmt.Errorf("cloud-init file path must be specified")
+	}
+	if _, err := strconv.ParseUint(opts.Permissions, 8, 32); err != nil {
+		return "", fmt.Errorf("invalid cloud-init file permissions %q; must be an octal mode (e.g. '0600')", opts.Permissions)
+	}
+
+	env := strings.Join(MapToEnvFormat(secrets, true), "\n")
+	if env != "" {
+		env += "\n"
+	}
+
+	// content is base64 encoded so values can't affect the structure of the document
+	document := map[string][]cloudInitFile{
+		"write_files": {{
+			Path:        opts.Path,
+			Owner:       opts.Owner,
+			Permissions: opts.Permissions,
+			Encoding:    "b64",
+			Content:     base64.StdEncoding.EncodeToString([]byte(env)),
+		}},
+	}
+
+	body, err := yaml.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+
+	// cloud-init requires this header to identify the document as cloud-config
+	return "#cloud-config\n" + string(body), nil
+}
+
+// NamedSecret a secret's name and value, for formats that list secrets rather than map them
+type NamedSecret struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// MapToJSONArray lists secrets sorted by name, as JSON objects don't guarantee an order
+func MapToJSONArray(secrets map[string]string) []NamedSecret {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]NamedSecret, 0, len(names))
+	for _, name := range names {
+		list = append(list, NamedSecret{Name: name, Value: secrets[name]})
+	}
+	return list
+}
+
+// MapToSystemdEnvFormat renders secrets for systemd's EnvironmentFile= directive, one double-quoted KEY="value" per line.
+// systemd doesn't interpolate values, but backslash, double quote, backtick, and dollar sign must be escaped.
+// Newlines are escaped as a literal '\n', which the service must unescape itself, and names that aren't valid
+// environment variable names are skipped. Each returns a warning, or an error if strict is true
+func MapToSystemdEnvFormat(secrets map[string]string, strict bool) (string, []string, error) {
+	var keys []string
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	// sort keys alphabetically for deterministic order
+	sort.Strings(keys)
+
+	var lines []string
+	var warnings []string
+	for _, k := range keys {
+		if !systemdEnvNameRegex.MatchString(k) {
+			if strict {
+				return "", nil, fmt.Errorf("secret name %s is not a valid systemd environment variable name", k)
+			}
+			warnings = append(warnings, fmt.Sprintf("Skipping secret %s, which is not a valid systemd environment variable name", k))
+			continue
+		}
+
+		value := secrets[k]
+		if strings.ContainsAny(value, "\r\n") {
+			if strict {
+				return "", nil, fmt.Errorf("secret %s contains a newline, which systemd doesn't support", k)
+			}
+			warnings = append(warnings, fmt.Sprintf("Secret %s contains a newline, which systemd doesn't support. Newlines have been escaped as '\\n'", k))
+		}
+
+		var sb strings.Builder
+		for _, r := range value {
+			switch r {
+			case '\\', '"', '`', '$':
+				sb.WriteRune('\\')
+				sb.WriteRune(r)
+			case '\n':
+				sb.WriteString(`\n`)
+			case '\r':
+				sb.WriteString(`\r`)
+			default:
+				sb.WriteRune(r)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s=\"%s\"", k, sb.String()))
+	}
+
+	return strings.Join(lines, "\n"), warnings, nil
+}
+
+// MapToXMLFormat renders secrets as an XML document of <secret name="KEY">value</secret> elements, sorted by name.
+// Names and values are escaped, but characters that XML 1.0 doesn't allow (e.g. most control characters) can't be represented.
+// Values containing them have the characters replaced with U+FFFD and names containing them are skipped. Each returns a warning,
+// or an error if strict is true
+func MapToXMLFormat(secrets map[string]string, strict bool) (string, []string, error) {
+	var keys []string
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	// sort keys alphabetically for deterministic order
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	var warnings []string
+	sb.WriteString(xml.Header)
+	sb.WriteString("<secrets>\n")
+	for _, k := range keys {
+		if k == "" || !isValidXMLText(k) {
+			if strict {
+				return "", nil, fmt.Errorf("secret name %q contains characters that aren't valid in XML", k)
+			}
+			warnings = append(warnings, fmt.Sprintf("Skipping secret %q, whose name contains characters that aren't valid in XML", k))
+			continue
+		}
+
+		value := secrets[k]
+		if !isValidXMLText(value) {
+			if strict {
+				return "", nil, fmt.Errorf("secret %s contains characters that aren't valid in XML", k)
+			}
+			warnings = append(warnings, fmt.Sprintf("Secret %s contains characters that aren't valid in XML, which have been replaced with U+FFFD", k))
+		}
+
+		sb.WriteString(`  <secret name="`)
+		// EscapeText escapes quotes, so its output is also a valid attribute value
+		if err := xml.EscapeText(&sb, []byte(k)); err != nil {
+			return "", nil, err
+		}
+		sb.WriteString(`">`)
+		if err := xml.EscapeText(&sb, []byte(value)); err != nil {
+			return "", nil, err
+		}
+		sb.WriteString("</secret>\n")
+	}
+	sb.WriteString("</secrets>\n")
+
+	return sb.String(), warnings, nil
+}
+
+// isValidXMLText whether the string only contains characters allowed by XML 1.0
+func isValidXMLText(s string) bool {
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return false
+			}
+		}
+		valid := r == '\t' || r == '\n' || r == '\r' ||
+			(r >= 0x20 && r <= 0xD7FF) ||
+			(r >= 0xE000 && r <= 0xFFFD) ||
+			(r >= 0x10000 && r <= 0x10FFFF)
+		if !valid {
+			return false
+		}
+	}
+	return true
+}
+
+// ValueEncodings the supported encodings of secret values
+var ValueEncodings = []string{"base64"}
+
+// EncodeValues returns a copy of the secrets with each value encoded (e.g. base64)
+func EncodeValues(secrets map[string]string, encoding string) (map[string]string, error) {
+	if encoding != "base64" {
+		return nil, fmt.Errorf("invalid encoding %q. Valid encodings are %v", encoding, ValueEncodings)
+	}
+
+	encoded := map[string]string{}
+	for name, value := range secrets {
+		encoded[name] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	return encoded, nil
+}
+
+// DecodeValues returns a copy of the secrets with each value decoded (e.g. from base64).
+// It's an error if any value isn't validly encoded
+func DecodeValues(secrets map[string]string, encoding string) (map[string]string, error) {
+	if encoding != "base64" {
+		return nil, fmt.Errorf("invalid encoding %q. Valid encodings are %v", encoding, ValueEncodings)
+	}
+
+	decoded := map[string]string{}
+	var invalid []string
+	for name, value := range secrets {
+		bytes, err := base64.StdEncoding.Strict().DecodeString(value)
+		if err != nil {
+			invalid = append(invalid, name)
+			continue
+		}
+		decoded[name] = string(bytes)
+	}
+
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return nil, fmt.Errorf("the following secrets are not valid %s: %s", encoding, strings.Join(invalid, ", "))
+	}
+	return decoded, nil
+}
+
+// MapToTOMLFormat renders secrets as TOML key/value pairs, optionally under a table header.
+// Each element of section is a part of a dotted table name (e.g. [project.config])
+func MapToTOMLFormat(secrets map[string]string, section []string) string {
+	var keys []string
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	// sort keys alphabetically for deterministic order
+	sort.Strings(keys)
+
+	var lines []string
+	if len(section) > 0 {
+		var parts []string
+		for _, part := range section {
+			parts = append(parts, TOMLKey(part))
+		}
+		lines = append(lines, fmt.Sprintf("[%s]", strings.Join(parts, ".")))
+	}
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s = %s", TOMLKey(k), TOMLString(secrets[k])))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// TOMLKey returns the key as a bare key when possible, otherwise as a quoted key
+func TOMLKey(key string) string {
+	if tomlBareKeyRegex.MatchString(key) {
+		return key
+	}
+	return fmt.Sprintf("\"%s\"", escapeTOMLString(key, false))
+}
+
+// TOMLString returns the value as a TOML basic string. values containing newlines use a multi-line basic string
+func TOMLString(value string) string {
+	if strings.Contains(value, "\n") {
+		// a newline immediately following the opening delimiter is trimmed by TOML parsers
+		return fmt.Sprintf("\"\"\"\n%s\"\"\"", escapeTOMLString(value, true))
+	}
+	return fmt.Sprintf("\"%s\"", escapeTOMLString(value, false))
+}
+
+func escapeTOMLString(value string, multiline bool) string {
+	var sb strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '"':
+			sb.WriteString(`\"`)
+		case r == '\n' && multiline:
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\b':
+			sb.WriteString(`\b`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r < 0x20 || r == 0x7f:
+			sb.WriteString(fmt.Sprintf(`\u%04X`, r))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// the markers that delimit the block of a dotenv file managed by 'doppler secrets pull'
+const dotenvManagedBlockStart = "# BEGIN DOPPLER MANAGED SECRETS"
+const dotenvManagedBlockEnd = "# END DOPPLER MANAGED SECRETS"
+
+var dotenvAssignmentRegex = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=`)
+
+// MergeDotenv replaces the Doppler-managed block of a dotenv file with the secrets, preserving comments and
+// unmanaged keys outside of the block. The block is appended if the file doesn't have one yet.
+// Also returns the names of secrets that are assigned outside of the block, which may override or be overridden by it
+func MergeDotenv(existing string, secrets map[string]string) (string, []string, error) {
+	lines := strings.Split(strings.TrimRight(existing, "\n"), "\n")
+	if existing == "" {
+		lines = nil
+	}
+
+	start, end := -1, -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if start == -1 && strings.HasPrefix(trimmed, dotenvManagedBlockStart) {
+			start = i
+		} else if start != -1 && strings.HasPrefix(trimmed, dotenvManagedBlockEnd) {
+			end = i
+			break
+		}
+	}
+	if start != -1 && end == -1 {
+		return "", nil, fmt.Errorf("the Doppler-managed block is missing its closing '%s' line", dotenvManagedBlockEnd)
+	}
+
+	var unmanaged []string
+	if start == -1 {
+		unmanaged = lines
+	} else {
+		unmanaged = append(append([]string{}, lines[:start]...), lines[end+1:]...)
+	}
+
+	var conflicts []string
+	for _, line := range unmanaged {
+		if matches := dotenvAssignmentRegex.FindStringSubmatch(line); matches != nil {
+			if _, ok := secrets[matches[1]]; ok && !Contains(conflicts, matches[1]) {
+				conflicts = append(conflicts, matches[1])
+			}
+		}
+	}
+	sort.Strings(conflicts)
+
+	block := []string{dotenvManagedBlockStart + " (this block is rewritten by 'doppler secrets pull'; edit outside of it)"}
+	block = append(block, MapToEnvFormat(secrets, true)...)
+	block = append(block, dotenvManagedBlockEnd)
+
+	var merged []string
+	if start == -1 {
+		merged = append(merged, lines...)
+		if len(merged) > 0 {
+			merged = append(merged, "")
+		}
+		merged = append(merged, block...)
+	} else {
+		merged = append(merged, lines[:start]...)
+		merged = append(merged, block...)
+		merged = append(merged, lines[end+1:]...)
+	}
+
+	return strings.Join(merged, "\n") + "\n", conflicts, nil
+}
+
+var dotenvLineRegex = regexp.MustCompile(`^(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+
+// ParseDotenv parses a dotenv file's KEY=value lines, ignoring blank lines, comments, and any 'export ' prefix.
+// Single-quoted values are literal. Double-quoted values may span multiple lines and support the escapes \\, \", and \n.
+// Their content is otherwise kept as is, including trailing whitespace and carriage returns, so that values written by
+// MapToEnvFormat are read back unchanged. Unquoted values end at a ' #' comment. Later assignments of the same name take
+// precedence
+func ParseDotenv(content string) (map[string]string, error) {
+	secrets := map[string]string{}
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// only leading whitespace is trimmed, as trailing whitespace may be part of a multi-line quoted value
+		matches := dotenvLineRegex.FindStringSubmatch(strings.TrimLeftFunc(lines[i], unicode.IsSpace))
+		if matches == nil {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNumber)
+		}
+		name, value := matches[1], matches[2]
+
+		var rest string
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated single-quoted value", lineNumber)
+			}
+			rest = value[end+2:]
+			value = value[1 : end+1]
+		case strings.HasPrefix(value, "\""):
+			// the value continues onto the following lines until the closing quote
+			var sb strings.Builder
+			remaining := value[1:]
+			closed := false
+			for !closed {
+				for j := 0; j < len(remaining); j++ {
+					c := remaining[j]
+					if c == '\\' && j+1 < len(remaining) {
+						j++
+						switch remaining[j] {
+						case 'n':
+							sb.WriteByte('\n')
+						case '\\', '"':
+							sb.WriteByte(remaining[j])
+						default:
+							sb.WriteByte(c)
+							sb.WriteByte(remaining[j])
+						}
+					} else if c == '"' {
+						rest = remaining[j+1:]
+						closed = true
+						break
+					} else {
+						sb.WriteByte(c)
+					}
+				}
+				if !closed {
+					i++
+					if i >= len(lines) {
+						return nil, fmt.Errorf("line %d: unterminated double-quoted value", lineNumber)
+					}
+					sb.WriteByte('\n')
+					remaining = lines[i]
+				}
+			}
+			value = sb.String()
+		default:
+			if comment := strings.Index(value, " #"); comment != -1 {
+				value = value[:comment]
+			}
+			value = strings.TrimSpace(value)
+		}
+
+		rest = strings.TrimSpace(rest)
+		if rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, fmt.Errorf("line %d: unexpected characters after the quoted value of %s", lineNumber, name)
+		}
+		secrets[name] = value
+	}
+	return secrets, nil
+}
@@ -0,0 +1,231 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapToTOMLFormat(t *testing.T) {
+	secrets := map[string]string{
+		"B":        "say \"hi\"\\",
+		"A":        "123",
+		"WITH.DOT": "x",
+		"CERT":     "line1\nline2",
+		"CTRL":     "a\tb\x01",
+	}
+
+	expected := `[backend.dev]
+A = "123"
+B = "say \"hi\"\\"
+CERT = """
+line1
+line2"""
+CTRL = "a\tb\u0001"
+"WITH.DOT" = "x"`
+	assert.Equal(t, expected, MapToTOMLFormat(secrets, []string{"backend", "dev"}))
+
+	assert.Equal(t, "[\"my project\".dev]\nA = \"123\"", MapToTOMLFormat(map[string]string{"A": "123"}, []string{"my project", "dev"}))
+	assert.Equal(t, `A = "123"`, MapToTOMLFormat(map[string]string{"A": "123"}, nil))
+}
+
+func TestMapToCloudInitFormat(t *testing.T) {
+	secrets := map[string]string{"B": "multi\nline", "A": "say \"hi\""}
+	content := base64.StdEncoding.EncodeToString([]byte("A=\"say \\\"hi\\\"\"\nB=\"multi\nline\"\n"))
+
+	expected := `#cloud-config
+write_files:
+    - path: /etc/doppler/secrets.env
+      owner: root:root
+      permissions: "0600"
+      encoding: b64
+      content: ` + content + "\n"
+	body, err := MapToCloudInitFormat(secrets, CloudInitOptions{Path: "/etc/doppler/secrets.env", Owner: "root:root", Permissions: "0600"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, body)
+
+	_, err = MapToCloudInitFormat(secrets, CloudInitOptions{Path: "/etc/doppler/secrets.env", Permissions: "rw"})
+	assert.Error(t, err)
+	_, err = MapToCloudInitFormat(secrets, CloudInitOptions{Permissions: "0600"})
+	assert.Error(t, err)
+}
+
+func TestMapToSystemdEnvFormat(t *testing.T) {
+	secrets := map[string]string{
+		"B":       "say \"hi\" to $USER `now` \\",
+		"A":       "123",
+		"CERT":    "line1\nline2",
+		"BAD-KEY": "x",
+	}
+
+	body, warnings, err := MapToSystemdEnvFormat(secrets, false)
+	assert.NoError(t, err)
+	expected := "A=\"123\"\n" +
+		"B=\"say \\\"hi\\\" to \\$USER \\`now\\` \\\\\"\n" +
+		"CERT=\"line1\\nline2\""
+	assert.Equal(t, expected, body)
+	assert.Len(t, warnings, 2)
+
+	_, _, err = MapToSystemdEnvFormat(map[string]string{"CERT": "line1\nline2"}, true)
+	assert.Error(t, err)
+	_, _, err = MapToSystemdEnvFormat(map[string]string{"BAD-KEY": "x"}, true)
+	assert.Error(t, err)
+}
+
+func TestMapToXMLFormat(t *testing.T) {
+	secrets := map[string]string{
+		"B":    `<a href="x">Tom & Jerry's</a>`,
+		"A":    "123",
+		"CERT": "line1\nline2",
+	}
+
+	body, warnings, err := MapToXMLFormat(secrets, false)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	expected := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+		"<secrets>\n" +
+		"  <secret name=\"A\">123</secret>\n" +
+		"  <secret name=\"B\">&lt;a href=&#34;x&#34;&gt;Tom &amp; Jerry&#39;s&lt;/a&gt;</secret>\n" +
+		"  <secret name=\"CERT\">line1&#xA;line2</secret>\n" +
+		"</secrets>\n"
+	assert.Equal(t, expected, body)
+
+	body, warnings, err = MapToXMLFormat(map[string]string{"BELL": "ding\a", "BAD\x00KEY": "x"}, false)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 2)
+	assert.Contains(t, body, "<secret name=\"BELL\">ding\uFFFD</secret>")
+	assert.NotContains(t, body, "KEY")
+
+	_, _, err = MapToXMLFormat(map[string]string{"BELL": "ding\a"}, true)
+	assert.Error(t, err)
+	_, _, err = MapToXMLFormat(map[string]string{"BAD\x00KEY": "x"}, true)
+	assert.Error(t, err)
+}
+
+func TestEncodeValues(t *testing.T) {
+	secrets := map[string]string{"API_KEY": "abc", "CERT": "line1\nline2", "EMPTY": ""}
+
+	encoded, err := EncodeValues(secrets, "base64")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "YWJj", "CERT": "bGluZTEKbGluZTI=", "EMPTY": ""}, encoded)
+	// the original secrets aren't modified
+	assert.Equal(t, "abc", secrets["API_KEY"])
+
+	decoded, err := DecodeValues(encoded, "base64")
+	assert.NoError(t, err)
+	assert.Equal(t, secrets, decoded)
+
+	_, err = DecodeValues(map[string]string{"A": "YWJj", "B": "not base64!", "C": "YWJ"}, "base64")
+	assert.EqualError(t, err, "the following secrets are not valid base64: B, C")
+
+	_, err = EncodeValues(secrets, "hex")
+	assert.Error(t, err)
+}
+
+func TestMapToJSONArray(t *testing.T) {
+	list := MapToJSONArray(map[string]string{"PORT": "5432", "API_KEY": "abc", "HOST": ""})
+	assert.Equal(t, []NamedSecret{{Name: "API_KEY", Value: "abc"}, {Name: "HOST", Value: ""}, {Name: "PORT", Value: "5432"}}, list)
+
+	body, err := json.Marshal(MapToJSONArray(map[string]string{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(body))
+}
+
+func TestMergeDotenv(t *testing.T) {
+	secrets := map[string]string{"API_KEY": "abc", "DB_HOST": "localhost"}
+
+	merged, conflicts, err := MergeDotenv("", secrets)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, "# BEGIN DOPPLER MANAGED SECRETS (this block is rewritten by 'doppler secrets pull'; edit outside of it)\n"+
+		"API_KEY=\"abc\"\nDB_HOST=\"localhost\"\n# END DOPPLER MANAGED SECRETS\n", merged)
+
+	// the block is appended after existing content
+	merged, conflicts, err = MergeDotenv("# local overrides\nDEBUG=true\nexport DB_HOST=127.0.0.1\n", secrets)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"DB_HOST"}, conflicts)
+	assert.Equal(t, "# local overrides\nDEBUG=true\nexport DB_HOST=127.0.0.1\n\n"+
+		"# BEGIN DOPPLER MANAGED SECRETS (this block is rewritten by 'doppler secrets pull'; edit outside of it)\n"+
+		"API_KEY=\"abc\"\nDB_HOST=\"localhost\"\n# END DOPPLER MANAGED SECRETS\n", merged)
+
+	// only the existing block is replaced
+	existing := "DEBUG=true\n# BEGIN DOPPLER MANAGED SECRETS\nOLD=\"1\"\n# END DOPPLER MANAGED SECRETS\n# trailing comment\n"
+	merged, conflicts, err = MergeDotenv(existing, map[string]string{"NEW": "2"})
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, "DEBUG=true\n"+
+		"# BEGIN DOPPLER MANAGED SECRETS (this block is rewritten by 'doppler secrets pull'; edit outside of it)\n"+
+		"NEW=\"2\"\n# END DOPPLER MANAGED SECRETS\n# trailing comment\n", merged)
+
+	_, _, err = MergeDotenv("# BEGIN DOPPLER MANAGED SECRETS\nA=\"1\"\n", secrets)
+	assert.Error(t, err)
+}
+
+func TestParseDotenv(t *testing.T) {
+	content := `# local overrides
+API_URL=http://localhost:3000 # dev server
+export DEBUG=true
+EMPTY=
+SINGLE='$NOT_EXPANDED "literal"'
+DOUBLE="say \"hi\"\\n"
+CERT="-----BEGIN CERT-----
+abc
+-----END CERT-----" # multi-line
+DEBUG=false
+`
+	secrets, err := ParseDotenv(content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"API_URL": "http://localhost:3000",
+		"DEBUG":   "false",
+		"EMPTY":   "",
+		"SINGLE":  `$NOT_EXPANDED "literal"`,
+		"DOUBLE":  "say \"hi\"\\n",
+		"CERT":    "-----BEGIN CERT-----\nabc\n-----END CERT-----",
+	}, secrets)
+
+	// values written by MapToEnvFormat round-trip
+	original := map[string]string{
+		"A":    "multi\nline",
+		"B":    `back\slash "quoted"`,
+		"CRLF": "-----BEGIN KEY-----\r\nabc\r\n-----END KEY-----\r\n",
+		"CR":   "trailing\r",
+		"WS":   "  trailing spaces  \n\tand tabs\t",
+		"MIX":  "\"\\n\\\" \r\n'single' # not a comment",
+	}
+	secrets, err = ParseDotenv(strings.Join(MapToEnvFormat(original, true), "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, original, secrets)
+
+	// CRLF line endings outside of quoted values are ignored
+	secrets, err = ParseDotenv("A=1\r\nB='2'\r\nC=\"3\"\r\n")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1", "B": "2", "C": "3"}, secrets)
+
+	_, err = ParseDotenv("A=1\nnot an assignment")
+	assert.EqualError(t, err, "line 2: expected KEY=value")
+	_, err = ParseDotenv("A='unterminated")
+	assert.EqualError(t, err, "line 1: unterminated single-quoted value")
+	_, err = ParseDotenv("A=\"unterminated\nB=2")
+	assert.EqualError(t, err, "line 1: unterminated double-quoted value")
+	_, err = ParseDotenv(`A="quoted" trailing`)
+	assert.EqualError(t, err, "line 1: unexpected characters after the quoted value of A")
+}
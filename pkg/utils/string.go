@@ -15,9 +15,113 @@ limitations under the License.
 */
 package utils
 
-import "github.com/google/uuid"
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 func IsValidUUID(s string) bool {
 	_, err := uuid.Parse(s)
 	return err == nil
 }
+
+// LevenshteinDistance the number of single-character insertions, deletions, or substitutions needed to change a into b
+func LevenshteinDistance(a string, b string) int {
+	ar := []rune(a)
+	br := []rune(b)
+
+	// only the previous row of the matrix is needed
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(br)]
+}
+
+// ClosestMatch the candidate nearest to name, ignoring case, if it's within maxDistance edits. ties go to the earliest candidate.
+// the distance is also limited to a quarter of name's length, as a few edits can turn a short name into almost anything
+func ClosestMatch(name string, candidates []string, maxDistance int) (string, bool) {
+	maxDistance = min(maxDistance, len([]rune(name))/4)
+
+	match := ""
+	bestDistance := maxDistance + 1
+	for _, candidate := range candidates {
+		distance := LevenshteinDistance(strings.ToUpper(name), strings.ToUpper(candidate))
+		if distance < bestDistance {
+			match = candidate
+			bestDistance = distance
+		}
+	}
+
+	return match, bestDistance <= maxDistance
+}
+
+// BracketIPv6Host encloses an IPv6 literal host in brackets (e.g. 'https://::1' becomes 'https://[::1]') so the URL can be parsed.
+// An unbracketed IPv6 host can't include a port, as the port would be ambiguous with the address
+func BracketIPv6Host(host string) string {
+	scheme := ""
+	authority := host
+	if i := strings.Index(host, "://"); i >= 0 {
+		scheme = host[:i+3]
+		authority = host[i+3:]
+	}
+	path := ""
+	if i := strings.Index(authority, "/"); i >= 0 {
+		path = authority[i:]
+		authority = authority[:i]
+	}
+
+	// zones (e.g. 'fe80::1%en0') aren't part of the address
+	address := authority
+	if i := strings.Index(address, "%"); i >= 0 {
+		address = address[:i]
+	}
+	if !strings.Contains(address, ":") || net.ParseIP(address) == nil {
+		return host
+	}
+
+	// zones must be escaped within a URL
+	if i := strings.Index(authority, "%"); i >= 0 && !strings.HasPrefix(authority[i:], "%25") {
+		authority = authority[:i] + "%25" + authority[i+1:]
+	}
+	return scheme + "[" + authority + "]" + path
+}
+
+// FormatAge describes a duration in its largest whole unit (e.g. '3 days ago')
+func FormatAge(age time.Duration) string {
+	pluralize := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return pluralize(int(age/time.Minute), "minute")
+	case age < 24*time.Hour:
+		return pluralize(int(age/time.Hour), "hour")
+	default:
+		return pluralize(int(age/(24*time.Hour)), "day")
+	}
+}
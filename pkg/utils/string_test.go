@@ -0,0 +1,91 @@
+/*
+Copyright © 2026 Doppler <support@doppler.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshteinDistance(t *testing.T) {
+	assert.Equal(t, 0, LevenshteinDistance("", ""))
+	assert.Equal(t, 3, LevenshteinDistance("", "abc"))
+	assert.Equal(t, 1, LevenshteinDistance("DATABSE_URL", "DATABASE_URL"))
+	assert.Equal(t, 3, LevenshteinDistance("kitten", "sitting"))
+	assert.Equal(t, 1, LevenshteinDistance("héllo", "hello"))
+}
+
+func TestClosestMatch(t *testing.T) {
+	names := []string{"API_KEY", "DATABASE_URL", "DATABASE_USER"}
+
+	match, ok := ClosestMatch("DATABSE_URL", names, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "DATABASE_URL", match)
+
+	match, ok = ClosestMatch("api_key", names, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "API_KEY", match)
+
+	_, ok = ClosestMatch("PORT", names, 2)
+	assert.False(t, ok)
+
+	_, ok = ClosestMatch("PORT", nil, 2)
+	assert.False(t, ok)
+
+	// short names only match with few or no edits
+	_, ok = ClosestMatch("DB", []string{"DB_URL", "ID"}, 2)
+	assert.False(t, ok)
+	_, ok = ClosestMatch("KEY", []string{"API_KEY", "KEYS"}, 2)
+	assert.False(t, ok)
+	match, ok = ClosestMatch("db", []string{"DB"}, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "DB", match)
+	_, ok = ClosestMatch("PROT", []string{"PORT"}, 2)
+	assert.False(t, ok)
+	match, ok = ClosestMatch("PORTT", []string{"PORT"}, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "PORT", match)
+}
+
+func TestBracketIPv6Host(t *testing.T) {
+	tests := map[string]string{
+		"https://::1":               "https://[::1]",
+		"https://::1/":              "https://[::1]/",
+		"https://2001:db8::10/api":  "https://[2001:db8::10]/api",
+		"https://fe80::1%en0":       "https://[fe80::1%25en0]",
+		"https://fe80::1%25en0":     "https://[fe80::1%25en0]",
+		"https://[::1]:8443":        "https://[::1]:8443",
+		"https://api.doppler.com":   "https://api.doppler.com",
+		"http://localhost:8080":     "http://localhost:8080",
+		"https://127.0.0.1:8443":    "https://127.0.0.1:8443",
+		"https://api.example.com/x": "https://api.example.com/x",
+	}
+	for host, expected := range tests {
+		assert.Equal(t, expected, BracketIPv6Host(host), host)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	assert.Equal(t, "just now", FormatAge(-time.Hour))
+	assert.Equal(t, "just now", FormatAge(59*time.Second))
+	assert.Equal(t, "1 minute ago", FormatAge(time.Minute))
+	assert.Equal(t, "59 minutes ago", FormatAge(time.Hour-time.Second))
+	assert.Equal(t, "2 hours ago", FormatAge(2*time.Hour+30*time.Minute))
+	assert.Equal(t, "1 day ago", FormatAge(47*time.Hour))
+	assert.Equal(t, "400 days ago", FormatAge(400*24*time.Hour))
+}
@@ -24,9 +24,12 @@ import (
 	"os/signal"
 	"os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -105,20 +108,66 @@ func Cwd() string {
 	return cwd
 }
 
+// CommandOptions optional settings for a spawned process
+type CommandOptions struct {
+	// Dir the working directory of the process. defaults to the current directory
+	Dir string
+	// User the user to run the process as. defaults to the current user
+	User *ProcessUser
+	// ExtraFiles files inherited by the process. entry i becomes file descriptor 3+i; nil entries are closed
+	ExtraFiles []*os.File
+	// TTY attach the process to a new pseudo-terminal, relayed to its stdin and stdout. stderr is merged into stdout
+	TTY bool
+}
+
+// ptyCleanups functions to run once a command started with a pseudo-terminal exits, keyed by *exec.Cmd
+var ptyCleanups sync.Map
+
+// terminalRestores functions that take the user's terminal out of raw mode, keyed by the *exec.Cmd that put it in raw mode
+var terminalRestores sync.Map
+
+// RestoreTerminals takes the user's terminal out of raw mode. it's called before exiting, as a terminal left in raw mode
+// outlives the process and deferred calls don't run on os.Exit
+func RestoreTerminals() {
+	terminalRestores.Range(func(cmd, restore interface{}) bool {
+		restore.(func())()
+		terminalRestores.Delete(cmd)
+		return true
+	})
+}
+
+// ProcessUser the credentials a process runs as
+type ProcessUser struct {
+	UID uint32
+	GID uint32
+}
+
+func applyCommandOptions(cmd *exec.Cmd, opts CommandOptions) {
+	cmd.Dir = opts.Dir
+	cmd.ExtraFiles = opts.ExtraFiles
+	if opts.User != nil {
+		setProcessUser(cmd, opts.User)
+	}
+}
+
 // RunCommand runs the specified command
-func RunCommand(command []string, env []string, inFile io.Reader, outFile io.Writer, errFile io.Writer, forwardSignals bool) (*exec.Cmd, error) {
+func RunCommand(command []string, env []string, inFile io.Reader, outFile io.Writer, errFile io.Writer, forwardSignals bool, opts CommandOptions) (*exec.Cmd, error) {
 	cmd := exec.Command(command[0], command[1:]...) // #nosec G204 nosemgrep: semgrep_configs.prohibit-exec-command
 	cmd.Env = env
 	cmd.Stdin = inFile
 	cmd.Stdout = outFile
 	cmd.Stderr = errFile
+	applyCommandOptions(cmd, opts)
 
+	if opts.TTY {
+		return cmd, execCommandWithPTY(cmd, inFile, outFile, forwardSignals)
+	}
 	err := execCommand(cmd, forwardSignals)
 	return cmd, err
 }
 
 // RunCommandString runs the specified command string
-func RunCommandString(command string, env []string, inFile io.Reader, outFile io.Writer, errFile io.Writer, forwardSignals bool) (*exec.Cmd, error) {
+func RunCommandString(command string, env []string, inFile io.Reader, outFile io.Writer, errFile io.Writer, forwardSignals bool, opts CommandOptions) (*exec.Cmd, error) {
 	shell := [2]string{"sh", "-c"}
 	if IsWindows() {
 		shell = [2]string{"cmd", "/C"}
@@ -138,15 +187,28 @@ func RunCommandString(command string, env []string, inFile io.Reader, outFile io
 	cmd.Stdin = inFile
 	cmd.Stdout = outFile
 	cmd.Stderr = errFile
+	applyCommandOptions(cmd, opts)
 
+	if opts.TTY {
+		return cmd, execCommandWithPTY(cmd, inFile, outFile, forwardSignals)
+	}
 	err := execCommand(cmd, forwardSignals)
 	return cmd, err
 }
 
+// signalsHandled set once a child process's signal handler is registered
+var signalsHandled int32
+
+// SignalsHandled whether signals are handled on behalf of a child process, rather than by their default action
+func SignalsHandled() bool {
+	return atomic.LoadInt32(&signalsHandled) == 1
+}
+
 func execCommand(cmd *exec.Cmd, forwardSignals bool) error {
 	// signal handling logic adapted from aws-vault https://github.com/99designs/aws-vault/
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan)
+	atomic.StoreInt32(&signalsHandled, 1)
 
 	if err := cmd.Start(); err != nil {
 		return err
@@ -172,7 +234,12 @@ func execCommand(cmd *exec.Cmd, forwardSignals bool) error {
 }
 
 func WaitCommand(cmd *exec.Cmd) (int, error) {
-	if err := cmd.Wait(); err != nil {
+	err := cmd.Wait()
+	if cleanup, ok := ptyCleanups.LoadAndDelete(cmd); ok {
+		cleanup.(func())()
+	}
+
+	if err != nil {
 		// ignore errors
 		cmd.Process.Signal(os.Kill) // #nosec G104
 
@@ -284,6 +351,31 @@ func GetDurationFlagIfChanged(cmd *cobra.Command, flag string, def time.Duration
 	return GetDurationFlag(cmd, flag)
 }
 
+var pathVariableRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandPathVariables replaces '${VAR}' in the path with the value from vars, falling back to the environment.
+// An error is returned if a variable isn't set
+func ExpandPathVariables(path string, vars map[string]string) (string, error) {
+	var missing []string
+	expanded := pathVariableRegex.ReplaceAllStringFunc(path, func(match string) string {
+		name := pathVariableRegex.FindStringSubmatch(match)[1]
+		if value, ok := vars[name]; ok && value != "" {
+			return value
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		missing = append(missing, name)
+		return match
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unable to expand path, the following variables are not set: %s", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
+
 // GetFilePath verify a file path and name are provided
 func GetFilePath(fullPath string) (string, error) {
 	if fullPath == "" {
@@ -306,6 +398,15 @@ func GetFilePath(fullPath string) (string, error) {
 	return filepath.Join(parsedPath, parsedName), nil
 }
 
+// ParseFileMode parses octal file permissions (e.g. '0600')
+func ParseFileMode(mode string) (os.FileMode, error) {
+	parsed, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || parsed > 0777 {
+		return 0, fmt.Errorf("invalid file mode %q; must be octal permissions (e.g. '0600')", mode)
+	}
+	return os.FileMode(parsed), nil
+}
+
 // ConfirmationPrompt prompt user to confirm yes/no
 func ConfirmationPrompt(message string, defaultValue bool) bool {
 	confirm := false
@@ -325,6 +426,58 @@ func ConfirmationPrompt(message string, defaultValue bool) bool {
 	return confirm
 }
 
+// RetypeConfirmationPrompt prompt user to confirm a destructive action by retyping the expected value.
+// The prompt uses the controlling terminal, so it works even when stdin or stdout is redirected
+func RetypeConfirmationPrompt(message string, expected string) bool {
+	answer := ""
+	prompt := &survey.Input{
+		Message: fmt.Sprintf("%s. Type %s to confirm:", message, expected),
+	}
+
+	var opts []survey.AskOpt
+	if in, out, err := openTTY(); err == nil {
+		defer in.Close()
+		if out != in {
+			defer out.Close()
+		}
+		opts = append(opts, survey.WithStdio(in, out, out))
+	} else {
+		LogDebug(fmt.Sprintf("Unable to open controlling terminal, using stdio: %s", err))
+	}
+
+	err := survey.AskOne(prompt, &answer, opts...)
+	if err != nil {
+		if err == terminal.InterruptErr {
+			Log("Exiting")
+			os.Exit(1)
+		}
+		HandleError(err)
+	}
+	return strings.TrimSpace(answer) == expected
+}
+
+// openTTY opens the controlling terminal for reading and writing
+func openTTY() (*os.File, *os.File, error) {
+	if IsWindows() {
+		in, err := os.OpenFile("CONIN$", os.O_RDWR, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, err := os.OpenFile("CONOUT$", os.O_RDWR, 0)
+		if err != nil {
+			in.Close()
+			return nil, nil, err
+		}
+		return in, out, nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tty, tty, nil
+}
+
 // SelectPrompt prompt user to select from a list of options
 func SelectPrompt(message string, options []string, defaultOption string) string {
 	prompt := &survey.Select{
@@ -136,3 +136,44 @@ func TestGetFilePath(t *testing.T) {
 		t.Error(fmt.Sprintf("Got %s, expected %s", path, "/root"))
 	}
 }
+
+func TestExpandPathVariables(t *testing.T) {
+	t.Setenv("DOPPLER_TEST_PATH_VAR", "env")
+
+	path, err := ExpandPathVariables("/tmp/${DOPPLER_TEST_PATH_VAR}/${DOPPLER_CONFIG}.json", map[string]string{"DOPPLER_CONFIG": "dev"})
+	if err != nil || path != "/tmp/env/dev.json" {
+		t.Errorf("Expected '/tmp/env/dev.json' but got '%s' (%v)", path, err)
+	}
+
+	// known variables take precedence over the environment
+	t.Setenv("DOPPLER_CONFIG", "prd")
+	path, err = ExpandPathVariables("${DOPPLER_CONFIG}.json", map[string]string{"DOPPLER_CONFIG": "dev"})
+	if err != nil || path != "dev.json" {
+		t.Errorf("Expected 'dev.json' but got '%s' (%v)", path, err)
+	}
+
+	// only the braced form is expanded
+	path, err = ExpandPathVariables("/tmp/$DOPPLER_TEST_PATH_VAR.json", nil)
+	if err != nil || path != "/tmp/$DOPPLER_TEST_PATH_VAR.json" {
+		t.Errorf("Expected path to be unchanged but got '%s' (%v)", path, err)
+	}
+
+	if _, err = ExpandPathVariables("/tmp/${DOPPLER_TEST_UNSET_PATH_VAR}.json", nil); err == nil {
+		t.Error("Expected an error when a variable is not set")
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	if mode, err := ParseFileMode("0600"); err != nil || mode != 0600 {
+		t.Error(fmt.Sprintf("Got %o, expected 600", mode))
+	}
+	if mode, err := ParseFileMode("644"); err != nil || mode != 0644 {
+		t.Error(fmt.Sprintf("Got %o, expected 644", mode))
+	}
+
+	for _, invalid := range []string{"", "rw", "0800", "01777", "-1"} {
+		if mode, err := ParseFileMode(invalid); err == nil {
+			t.Error(fmt.Sprintf("Got %o, expected error for %q", mode, invalid))
+		}
+	}
+}
@@ -46,6 +46,8 @@ beforeEach
 for flag in "${flags[@]}"; do
   [[ "$("$DOPPLER_BINARY" configure flags get "$flag" --plain --config-dir=$TEST_CONFIG_DIR)" == 'true' ]] || error "ERROR: incorrect default for $flag"
 done
+# opt-in flags are disabled by default
+[[ "$("$DOPPLER_BINARY" configure flags get protect-locked-configs --plain --config-dir=$TEST_CONFIG_DIR)" == 'false' ]] || error "ERROR: incorrect default for protect-locked-configs"
 
 beforeEach
 
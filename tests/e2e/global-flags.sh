@@ -46,6 +46,8 @@ beforeEach
 # verify env var is read
 token="$(DOPPLER_TOKEN=123 "$DOPPLER_BINARY" configure debug --json --configuration=./temp-config 2>/dev/null | jq -r ".[\"/\"].token")"
 [[ "$token" == "123" ]] || error "ERROR: expected token from environment"
+source="$(DOPPLER_TOKEN=123 "$DOPPLER_BINARY" configure debug --json --sources --configuration=./temp-config 2>/dev/null | jq -r ".[\"/\"].token.source")"
+[[ "$source" == "Environment" ]] || error "ERROR: expected token source to be environment"
 # verify env var is ignored
 token="$(DOPPLER_TOKEN=123 "$DOPPLER_BINARY" configure debug --json --configuration=./temp-config --no-read-env 2>/dev/null | jq -r ".[\"/\"].token")"
 [[ "$token" == "" ]] || error "ERROR: expected blank config value"
@@ -78,5 +80,7 @@ beforeEach
 "$DOPPLER_BINARY" configure set token "$CONFIG_VALUE" --scope=/ --configuration=./temp-config >/dev/null 2>&1
 token="$(DOPPLER_TOKEN="$ENV_VALUE" "$DOPPLER_BINARY" configure debug --json --token="$FLAG_VALUE" --configuration=./temp-config 2>/dev/null | jq -r ".[\"/\"].token")"
 [[ "$token" == "$FLAG_VALUE" ]] || error "ERROR: expected token from flag"
+source="$(DOPPLER_TOKEN="$ENV_VALUE" "$DOPPLER_BINARY" configure debug --json --sources --token="$FLAG_VALUE" --configuration=./temp-config 2>/dev/null | jq -r ".[\"/\"].token.source")"
+[[ "$source" == "Flag" ]] || error "ERROR: expected token source to be flag"
 
 afterAll